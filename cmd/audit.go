@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/handler"
+)
+
+var auditAnchor string
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of changes",
+	Long: `Every change River makes, and every change of an employee made in SQL, is
+recorded in the audit_log table with a hash chaining it to the entry before.`,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that no audit log entry was edited or deleted",
+	Long: `Checks the hash chain of the audit log against an anchor kept outside the
+database and prints its head. The anchor is read from AUDIT_ANCHOR_FILE,
+which River writes after every command, or given as --anchor. Exits with an
+error if the log was tampered with, or without an anchor.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var verifyErr error
+		withHandler(func(h *handler.Handler) error {
+			verifyErr = h.VerifyAudit(commandContext(), os.Stdout, auditAnchor)
+			return verifyErr
+		})
+
+		if verifyErr != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	auditVerifyCmd.Flags().StringVar(&auditAnchor, "anchor", "", "seq:hash of an entry the log must still contain")
+
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run payroll on the configured schedule and repay failed payments",
+	Long: `Runs payroll on DAEMON_SCHEDULE, a cron expression or @period (the last day
+of every PAY_SCHEDULE period), and retries unfinished payments with backoff
+between REPAY_BACKOFF and REPAY_BACKOFF_MAX. A database lock ensures that only
+one River instance pays at a time. SIGINT or SIGTERM stop the daemon between
+payments.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		h, closeFn, err := newHandler()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer closeFn()
+
+		ctx, stop := signalContext()
+		defer stop()
+
+		err = h.Daemon(ctx)
+		if err != nil {
+			log.Println(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+}
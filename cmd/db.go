@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/db"
+	"gitlab.midas.dev/back/river/internal/config"
+)
+
+var (
+	rollbackSteps int
+	skipBackup    bool
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the database schema and backups",
+	Long: `The schema is changed by numbered migrations embedded in River. A new
+database is created at the latest version; an existing one must be migrated
+with "db migrate" after upgrading River, which backs it up first.
+
+Backups of a SQLite database are encrypted with DATABASE_KEY or
+DATABASE_KEY_FILE when either is set. "db encrypt" encrypts employee names
+and addresses in the database with the same key.`,
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup [path]",
+	Short: "Copy the SQLite database while River may be using it",
+	Long: `Writes a consistent copy of the SQLite database to path, or next to it named
+after the time, with SQLite's online backup API. Postgres databases are
+backed up with their own tools, e.g. pg_dump.`,
+	Args: cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+			key, err := cfg.DatabaseKey()
+			if err != nil {
+				return err
+			}
+
+			path := fmt.Sprintf("%s.%s.bak", cfg.DatabasePath, time.Now().UTC().Format("20060102T150405Z"))
+			if len(args) > 0 {
+				path = args[0]
+			} else if key != nil {
+				path += ".enc"
+			}
+
+			err = db.Backup(ctx, dbDriver, path, key)
+			if errors.Is(err, db.ErrBackupUnsupported) {
+				return fmt.Errorf("%w: back up the database with its own tools, e.g. pg_dump", err)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to back up the database: %w", err)
+			}
+			fmt.Printf("backed up the database to %s\n", path)
+			return nil
+		})
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Back up the database and replace it with a backup",
+	Long: `Replaces the SQLite database with a backup or snapshot, decrypting it with
+DATABASE_KEY or DATABASE_KEY_FILE if it is encrypted. The database is backed
+up first. Stop River daemons before restoring, as payments they send
+meanwhile would not be recorded.`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+			key, err := cfg.DatabaseKey()
+			if err != nil {
+				return err
+			}
+
+			if !confirm(fmt.Sprintf("Replace the database with %s, losing what changed since", args[0])) {
+				return nil
+			}
+
+			if err := backup(ctx, cfg, dbDriver, m); err != nil {
+				return err
+			}
+
+			err = db.Restore(ctx, dbDriver, args[0], key)
+			if errors.Is(err, db.ErrBackupUnsupported) {
+				return fmt.Errorf("%w: restore the database with its own tools, e.g. pg_restore", err)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore the database: %w", err)
+			}
+			fmt.Printf("restored the database from %s\n", args[0])
+
+			// a backup taken before upgrading River needs migrating
+			return m.Check(ctx)
+		})
+	},
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the migrations and whether they were applied",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+			status, err := m.Status(ctx)
+			if err != nil {
+				return err
+			}
+
+			version, err := m.Version(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("schema version %d, River needs %d\n\n", version, m.Latest())
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+			for _, s := range status {
+				applied := "pending"
+				if s.AppliedAt != nil {
+					applied = s.AppliedAt.Format(time.RFC3339)
+				}
+				_, _ = fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Back up the database and apply the pending migrations",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+			pending, err := m.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if len(pending) == 0 {
+				fmt.Printf("schema is up to date at version %d\n", m.Latest())
+				return m.Check(ctx)
+			}
+
+			if err := backup(ctx, cfg, dbDriver, m); err != nil {
+				return err
+			}
+
+			applied, err := m.Migrate(ctx)
+			for _, migration := range applied {
+				fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+			}
+			return err
+		})
+	},
+}
+
+var dbRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Back up the database and undo the last migrations",
+	Long: `Undoes the last --steps migrations, dropping the tables and columns they
+added along with their data. Only an older River can use the database then.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+			version, err := m.Version(ctx)
+			if err != nil {
+				return err
+			}
+
+			if !confirm(fmt.Sprintf("Roll back %d migration(s) from version %d, dropping what they added", rollbackSteps, version)) {
+				return nil
+			}
+
+			if err := backup(ctx, cfg, dbDriver, m); err != nil {
+				return err
+			}
+
+			undone, err := m.Rollback(ctx, rollbackSteps)
+			for _, migration := range undone {
+				fmt.Printf("rolled back %04d_%s\n", migration.Version, migration.Name)
+			}
+			return err
+		})
+	},
+}
+
+var dbEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Back up the database and encrypt employee names and addresses",
+	Long: `Encrypts the names and addresses of employees with DATABASE_KEY or
+DATABASE_KEY_FILE, along with their copies in payments and address changes.
+The database is backed up first, encrypted with the same key.
+
+From then on River needs the key to run, and the database refuses names and
+addresses in plain text: add and rename employees with "employee add" and
+"employee rename". The audit log is never rewritten, so entries recorded
+before keep them in plain text, and later ones record them encrypted. Keep
+the key safe, as nothing encrypted with it can be read without it.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+			if err := m.Check(ctx); err != nil {
+				return err
+			}
+
+			key, err := cfg.DatabaseKey()
+			if err != nil {
+				return err
+			}
+			if key == nil {
+				return errors.New("set DATABASE_KEY or DATABASE_KEY_FILE to the key to encrypt the database with")
+			}
+
+			if !confirm("Encrypt employee names and addresses, which River cannot read without the key from then on") {
+				return nil
+			}
+
+			if err := backup(ctx, cfg, dbDriver, m); err != nil {
+				return err
+			}
+
+			if err := db.EncryptFields(ctx, dbDriver, key); err != nil {
+				return fmt.Errorf("failed to encrypt the database: %w", err)
+			}
+			fmt.Println("encrypted employee names and addresses")
+			return nil
+		})
+	},
+}
+
+var dbDiagnoseCmd = &cobra.Command{
+	Use:   "diagnose",
+	Short: "List the rows River cannot read",
+	Long: `Reads every employee, salary, payment and deduction as payroll runs do and
+lists the rows that cannot be read, e.g. a salary amount that is not a number.
+Runs fail while such rows exist rather than leaving them out; repair them with
+SQL.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+			if err := m.Check(ctx); err != nil {
+				return err
+			}
+
+			malformed, err := db.Diagnose(ctx, dbDriver)
+			if err != nil {
+				return err
+			}
+			if len(malformed) == 0 {
+				fmt.Println("every row can be read")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "TABLE\tID\tERROR")
+			for _, row := range malformed {
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%v\n", row.Table, row.ID, row.Err)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("%d malformed row(s)", len(malformed))
+		})
+	},
+}
+
+// withMigrator runs fn with a migrator of the configured database, without
+// checking its schema, and exits with an error if fn fails
+func withMigrator(fn func(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	dbDriver, closeFn, err := openDatabase(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeFn()
+
+	migrator, err := db.NewMigrator(dbDriver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := fn(commandContext(), cfg, dbDriver, migrator); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// backup copies a SQLite database that has tables next to it, named after
+// its schema version and the time. A Postgres database must be backed up
+// beforehand, which --skip-backup confirms.
+func backup(ctx context.Context, cfg *config.Config, dbDriver *sql.DB, m *db.Migrator) error {
+	empty, err := m.Empty(ctx)
+	if err != nil || empty || skipBackup {
+		return err
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := cfg.DatabaseKey()
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("%s.v%d-%s.bak", cfg.DatabasePath, version, time.Now().UTC().Format("20060102T150405Z"))
+	if key != nil {
+		path += ".enc"
+	}
+	err = db.Backup(ctx, dbDriver, path, key)
+	if errors.Is(err, db.ErrBackupUnsupported) {
+		return fmt.Errorf("%w: back up the database with its own tools, e.g. pg_dump, and pass --skip-backup", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up the database: %w", err)
+	}
+	fmt.Printf("backed up the database to %s\n", path)
+
+	return nil
+}
+
+func init() {
+	dbRollbackCmd.Flags().IntVar(&rollbackSteps, "steps", 1, "number of migrations to undo")
+	for _, c := range []*cobra.Command{dbMigrateCmd, dbRollbackCmd, dbRestoreCmd, dbEncryptCmd} {
+		c.Flags().BoolVar(&skipBackup, "skip-backup", false, "do not back up the database first, it was backed up otherwise")
+	}
+
+	dbCmd.AddCommand(dbStatusCmd, dbMigrateCmd, dbRollbackCmd, dbDiagnoseCmd, dbBackupCmd, dbRestoreCmd, dbEncryptCmd)
+	rootCmd.AddCommand(dbCmd)
+}
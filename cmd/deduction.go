@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/handler"
+)
+
+var deductionOpts handler.DeductionOptions
+
+var deductionCmd = &cobra.Command{
+	Use:   "deduction",
+	Short: "Manage deductions withheld from gross salaries",
+}
+
+var deductionAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a fixed or percentage deduction for an employee",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.AddDeduction(commandContext(), deductionOpts)
+		})
+	},
+}
+
+var deductionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deductions",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.ListDeductions(commandContext(), os.Stdout)
+		})
+	},
+}
+
+var deductionRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Stop applying a deduction",
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("invalid deduction id %q", args[0])
+		}
+
+		withHandler(func(h *handler.Handler) error {
+			return h.RemoveDeduction(commandContext(), id)
+		})
+	},
+}
+
+func init() {
+	flags := deductionAddCmd.Flags()
+	flags.Int64Var(&deductionOpts.EmployeeID, "employee", 0, "employee id")
+	flags.StringVar(&deductionOpts.Name, "name", "", "name of the deduction, e.g. tax or loan")
+	flags.Int64Var(&deductionOpts.Fixed, "fixed", 0, "fixed amount in the token's smallest unit")
+	flags.Float64Var(&deductionOpts.Percent, "percent", 0, "percentage of the gross salary")
+	flags.StringVar(&deductionOpts.Treasury, "treasury", "", "address receiving the withheld amount")
+	_ = deductionAddCmd.MarkFlagRequired("employee")
+	_ = deductionAddCmd.MarkFlagRequired("name")
+	deductionAddCmd.MarkFlagsMutuallyExclusive("fixed", "percent")
+
+	deductionCmd.AddCommand(deductionAddCmd, deductionListCmd, deductionRemoveCmd)
+	rootCmd.AddCommand(deductionCmd)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/handler"
+)
+
+var (
+	employeeOpts     handler.EmployeeOptions
+	addressOpts      handler.AddressChangeOptions
+	addressSignature string
+)
+
+var employeeCmd = &cobra.Command{
+	Use:   "employee",
+	Short: "Manage employees",
+}
+
+var employeeAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add an employee",
+	Long: `Adds an employee paid --salary a period at --addr. Employees may also be
+added with SQL, unless the database was encrypted with "db encrypt": their
+names and addresses are then encrypted, which only River can do.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.AddEmployee(commandContext(), employeeOpts)
+		})
+	},
+}
+
+var employeeRenameCmd = &cobra.Command{
+	Use:   "rename <employee> <name>",
+	Short: "Change the name of an employee",
+	Args:  cobra.ExactArgs(2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id := parseID("employee", args[0])
+
+		withHandler(func(h *handler.Handler) error {
+			return h.RenameEmployee(commandContext(), id, args[1])
+		})
+	},
+}
+
+var employeeAddressCmd = &cobra.Command{
+	Use:   "address",
+	Short: "Request, verify and list payout address changes",
+	Long: `Payout address changes do not take effect right away: they wait for
+ADDRESS_CHANGE_COOLDOWN and, with ADDRESS_CHANGE_VERIFY, for a signature from
+the new address. Every change is kept with who requested it.`,
+}
+
+var employeeAddressSetCmd = &cobra.Command{
+	Use:   "set <employee> <address>",
+	Short: "Request a new payout address for an employee",
+	Args:  cobra.ExactArgs(2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		addressOpts.EmployeeID = parseID("employee", args[0])
+		addressOpts.Address = args[1]
+
+		if addressOpts.RequestedBy == "" {
+			if u, err := user.Current(); err == nil {
+				addressOpts.RequestedBy = u.Username
+			}
+		}
+
+		withHandler(func(h *handler.Handler) error {
+			return h.RequestAddressChange(commandContext(), addressOpts)
+		})
+	},
+}
+
+var employeeAddressVerifyCmd = &cobra.Command{
+	Use:   "verify <change>",
+	Short: "Verify an address change with a signature from the new address",
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id := parseID("address change", args[0])
+
+		withHandler(func(h *handler.Handler) error {
+			return h.VerifyAddressChange(commandContext(), id, addressSignature)
+		})
+	},
+}
+
+var employeeAddressCancelCmd = &cobra.Command{
+	Use:   "cancel <change>",
+	Short: "Cancel a pending address change",
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id := parseID("address change", args[0])
+
+		withHandler(func(h *handler.Handler) error {
+			return h.CancelAddressChange(commandContext(), id)
+		})
+	},
+}
+
+var employeeAddressListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List address changes",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.ListAddressChanges(commandContext(), os.Stdout)
+		})
+	},
+}
+
+// parseID parses a numeric id argument or exits
+func parseID(what, arg string) int64 {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		log.Fatalf("invalid %s id %q", what, arg)
+	}
+	return id
+}
+
+func init() {
+	flags := employeeAddCmd.Flags()
+	flags.StringVar(&employeeOpts.Name, "name", "", "name of the employee")
+	flags.StringVar(&employeeOpts.Address, "addr", "", "payout address of the employee")
+	flags.IntVar(&employeeOpts.Salary, "salary", 0, "salary for a full period, in the smallest unit of the token")
+	flags.StringVar(&employeeOpts.StartDate, "start-date", "", "first day of work, YYYY-MM-DD (default employed for whole periods)")
+	_ = employeeAddCmd.MarkFlagRequired("name")
+	_ = employeeAddCmd.MarkFlagRequired("addr")
+	_ = employeeAddCmd.MarkFlagRequired("salary")
+
+	flags = employeeAddressSetCmd.Flags()
+	flags.StringVar(&addressOpts.Reason, "reason", "", "why the address changes, e.g. the ticket")
+	flags.StringVar(&addressOpts.RequestedBy, "by", "", "who requested the change (default the current user)")
+
+	employeeAddressVerifyCmd.Flags().StringVar(&addressSignature, "signature", "", "hex personal_sign signature of the change message")
+	_ = employeeAddressVerifyCmd.MarkFlagRequired("signature")
+
+	employeeAddressCmd.AddCommand(employeeAddressSetCmd, employeeAddressVerifyCmd, employeeAddressCancelCmd, employeeAddressListCmd)
+	employeeCmd.AddCommand(employeeAddCmd, employeeRenameCmd, employeeAddressCmd)
+	rootCmd.AddCommand(employeeCmd)
+}
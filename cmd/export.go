@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/accounting"
+	"gitlab.midas.dev/back/river/internal/handler"
+)
+
+var accountingOpts handler.AccountingOptions
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export payroll data for other systems",
+}
+
+var exportAccountingCmd = &cobra.Command{
+	Use:   "accounting",
+	Short: "Export the journal entries of the payments of a period",
+	Long: `Writes a journal entry for every payment paid in the period: its gross amount
+debited to the expense account of its category, the net amount credited to
+the paying wallet and the deductions withheld to withholdings payable. Gas
+River paid for the payment is debited to gas fees from the same wallet.
+Entries reference the transaction that sent the payment.
+
+--accounts reads a JSON file of account names, e.g.
+{"salaries": "477", "wallet": "Wallet %s"}, for the accounts of a ledger.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.ExportAccounting(commandContext(), os.Stdout, accountingOpts)
+		})
+	},
+}
+
+func init() {
+	flags := exportAccountingCmd.Flags()
+	flags.StringVar(&accountingOpts.Period, "period", "", "accounting period, e.g. 2026-10 or 2026-10-01..2026-12-31")
+	flags.StringVar(&accountingOpts.Format, "format", "csv", "output format: "+strings.Join(accounting.Formats(), ", "))
+	flags.StringVar(&accountingOpts.Accounts, "accounts", "", "JSON file of account names overriding the defaults")
+	_ = exportAccountingCmd.MarkFlagRequired("period")
+
+	exportCmd.AddCommand(exportAccountingCmd)
+	rootCmd.AddCommand(exportCmd)
+}
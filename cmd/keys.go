@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/config"
+	"gitlab.midas.dev/back/river/internal/signer"
+	"golang.org/x/term"
+)
+
+var keyFile string
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage encrypted signing keys in KEYSTORE_DIR",
+	Long: `Manages Web3 Secret Storage key files in KEYSTORE_DIR. Passphrases are read
+from KEYSTORE_PASSWORD, KEYSTORE_PASSWORD_FILE or an interactive prompt.
+Private keys and passphrases are never printed.`,
+}
+
+var keysNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Generate a new encrypted key",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, ks := openConfiguredKeystore()
+
+		passphrase, err := keystorePassphrase(cfg, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		acc, err := ks.NewAccount(passphrase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s\t%s\n", acc.Address.Hex(), acc.URL.Path)
+	},
+}
+
+var keysImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Encrypt a hex private key into the keystore",
+	Long: `Reads a hex private key from --file or a hidden prompt and stores it encrypted
+in KEYSTORE_DIR. Remove the plaintext key from PRIVATE_KEYS afterwards.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, ks := openConfiguredKeystore()
+
+		var hexKey string
+		var err error
+		if keyFile != "" {
+			hexKey, err = signer.ReadPassphraseFile(keyFile)
+		} else {
+			hexKey, err = readSecret("Private key: ")
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		passphrase, err := keystorePassphrase(cfg, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		acc, err := ks.Import(hexKey, passphrase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s\t%s\n", acc.Address.Hex(), acc.URL.Path)
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the accounts in the keystore",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		_, ks := openConfiguredKeystore()
+
+		for _, acc := range ks.Accounts() {
+			fmt.Printf("%s\t%s\n", acc.Address.Hex(), acc.URL.Path)
+		}
+	},
+}
+
+// openConfiguredKeystore opens KEYSTORE_DIR without requiring the rest of the configuration
+func openConfiguredKeystore() (*config.Config, *signer.Keystore) {
+	cfg, err := config.Read()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if cfg.KeystoreDir == "" {
+		log.Fatal("KEYSTORE_DIR is required")
+	}
+
+	return cfg, signer.OpenKeystore(cfg.KeystoreDir)
+}
+
+// loadSigners returns signers for PRIVATE_KEYS, the KEYSTORE_DIR accounts and
+// the REMOTE_SIGNER_ACCOUNTS
+func loadSigners(cfg *config.Config) ([]signer.Signer, error) {
+	var signers []signer.Signer
+
+	if len(cfg.PrivateKeys) > 0 {
+		keySigners, err := signer.FromHexKeys(cfg.PrivateKeys)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, keySigners...)
+	}
+
+	if cfg.KeystoreDir != "" {
+		passphrase, err := keystorePassphrase(cfg, false)
+		if err != nil {
+			return nil, err
+		}
+
+		keystoreSigners, err := signer.OpenKeystore(cfg.KeystoreDir).Signers(cfg.KeystoreAccounts, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, keystoreSigners...)
+	}
+
+	if cfg.RemoteSignerURL != "" {
+		tlsConfig, err := signer.TLSConfig(cfg.RemoteSignerCAFile, cfg.RemoteSignerCertFile, cfg.RemoteSignerKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("remote signer TLS: %w", err)
+		}
+
+		remoteSigners, err := signer.NewRemote(cfg.RemoteSignerURL, cfg.RemoteSignerAccounts, signer.RemoteOptions{
+			API:     cfg.RemoteSignerAPI,
+			Timeout: cfg.RemoteSignerTimeout,
+			TLS:     tlsConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, remoteSigners...)
+	}
+
+	return signers, nil
+}
+
+// signingAddresses returns the addresses of PRIVATE_KEYS, the KEYSTORE_DIR
+// accounts and the REMOTE_SIGNER_ACCOUNTS, without unlocking any key
+func signingAddresses(cfg *config.Config) ([]string, error) {
+	var addresses []string
+
+	if len(cfg.PrivateKeys) > 0 {
+		keySigners, err := signer.FromHexKeys(cfg.PrivateKeys)
+		if err != nil {
+			return nil, err
+		}
+		for _, sgn := range keySigners {
+			addresses = append(addresses, sgn.Address().Hex())
+		}
+	}
+
+	if cfg.KeystoreDir != "" {
+		if len(cfg.KeystoreAccounts) > 0 {
+			addresses = append(addresses, cfg.KeystoreAccounts...)
+		} else {
+			for _, acc := range signer.OpenKeystore(cfg.KeystoreDir).Accounts() {
+				addresses = append(addresses, acc.Address.Hex())
+			}
+		}
+	}
+
+	return append(addresses, cfg.RemoteSignerAccounts...), nil
+}
+
+// keystorePassphrase returns the passphrase from the environment, the
+// passphrase file or a prompt, asking twice for new keys
+func keystorePassphrase(cfg *config.Config, confirm bool) (string, error) {
+	if cfg.KeystorePassword != "" {
+		return cfg.KeystorePassword, nil
+	}
+
+	if cfg.KeystorePasswordFile != "" {
+		return signer.ReadPassphraseFile(cfg.KeystorePasswordFile)
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("set KEYSTORE_PASSWORD or KEYSTORE_PASSWORD_FILE to unlock the keystore")
+	}
+
+	passphrase, err := readSecret("Keystore passphrase: ")
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		repeated, err := readSecret("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if repeated != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// readSecret prompts for a value without echoing it
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+func init() {
+	keysImportCmd.Flags().StringVar(&keyFile, "file", "", "file containing the hex private key")
+
+	keysCmd.AddCommand(keysNewCmd, keysImportCmd, keysListCmd)
+	rootCmd.AddCommand(keysCmd)
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/config"
+	"gitlab.midas.dev/back/river/internal/offline"
+	"gitlab.midas.dev/back/river/internal/recipient"
+	"gitlab.midas.dev/back/river/internal/service/payment"
+	"gitlab.midas.dev/back/river/internal/service/salary"
+)
+
+var signOut string
+
+var signCmd = &cobra.Command{
+	Use:   "sign <file>",
+	Short: "Sign exported payments with a keystore, offline",
+	Long: `Signs a file written by pay --export-unsigned with the OFFLINE_ACCOUNT key
+of KEYSTORE_DIR. Needs neither the node nor the database, so that it can run on
+a machine that is never online. Every transaction is checked to pay exactly the
+payment it lists before the summary is shown for confirmation.`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, ks := openConfiguredKeystore()
+
+		batch, err := offline.Read(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := batch.Validate(); err != nil {
+			log.Fatal(err)
+		}
+
+		printBatch(batch)
+		if !confirm(fmt.Sprintf("Sign %d payments of %d from %s", len(batch.Transactions), batch.Total(), batch.From.Hex())) {
+			return
+		}
+
+		passphrase, err := keystorePassphrase(cfg, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		signers, err := ks.Signers([]string{batch.From.Hex()}, passphrase)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := offline.Sign(commandContext(), batch, signers[0]); err != nil {
+			log.Fatal(err)
+		}
+
+		out := signOut
+		if out == "" {
+			out = signedPath(args[0])
+		}
+		if err := offline.Write(out, batch); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("signed %d transactions to %s\n", len(batch.Transactions), out)
+	},
+}
+
+var broadcastCmd = &cobra.Command{
+	Use:   "broadcast <file>",
+	Short: "Send payments signed offline",
+	Long: `Sends the transactions of a file signed with river sign, after checking each
+against the payment it was exported for. Running it again after an
+interruption skips the payments already sent. Exits with an error if a
+transaction reverted, listing the payments to export again.`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		h, closeFn, err := openHandler(offlineMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer closeFn()
+
+		ctx, stop := signalContext()
+		defer stop()
+
+		if err := h.Broadcast(ctx, args[0]); err != nil {
+			log.Println(err)
+			stop()
+			closeFn()
+			os.Exit(1)
+		}
+	},
+}
+
+// newOffline connects to the node to export payments of OFFLINE_ACCOUNT and
+// broadcast them, setting the recipient checks in opts
+func newOffline(cfg *config.Config, opts *salary.Options) (*payment.Offline, error) {
+	if cfg.OfflineAccount == "" {
+		return nil, errors.New("OFFLINE_ACCOUNT is required to sign payments offline")
+	}
+	if cfg.SafeAddress != "" {
+		return nil, errors.New("payments from a Safe cannot be exported, unset SAFE_ADDRESS")
+	}
+
+	ethClient, err := ethclient.Dial(cfg.Node)
+	if err != nil {
+		return nil, err
+	}
+	client := ethereum.NewClient(ethClient)
+
+	exporter, err := payment.NewOffline(client, payment.OfflineOptions{
+		From:      common.HexToAddress(cfg.OfflineAccount),
+		ExportDir: cfg.OfflineExportDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Recipients = recipient.NewChecker(client, map[common.Address]string{
+		common.HexToAddress(payment.USDCContractAddress): "token contract",
+		exporter.Address(): "River offline account",
+	})
+	return exporter, nil
+}
+
+// printBatch prints the payments of a batch for review before signing
+func printBatch(batch *offline.Batch) {
+	fmt.Printf("salary %d, chain %s, from %s, token %s\n", batch.SalaryID, batch.ChainID, batch.From.Hex(), batch.Token.Hex())
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PAYMENT\tNONCE\tRECIPIENT\tAMOUNT\tGAS PRICE")
+	for _, t := range batch.Transactions {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%s\n", t.PaymentID, t.Nonce, t.Recipient.Hex(), t.Amount, t.GasPrice)
+	}
+	_ = tw.Flush()
+}
+
+// signedPath names the signed file after the unsigned one
+func signedPath(path string) string {
+	dir, name := filepath.Split(path)
+	if strings.HasPrefix(name, "unsigned-") {
+		return filepath.Join(dir, strings.TrimPrefix(name, "un"))
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".signed.json"
+}
+
+func init() {
+	signCmd.Flags().StringVar(&signOut, "out", "", "file to write the signed transactions to (defaults to signed-<salary>-<nonce>.json)")
+
+	rootCmd.AddCommand(signCmd, broadcastCmd)
+}
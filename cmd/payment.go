@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/handler"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+var (
+	paymentOpts   handler.PaymentOptions
+	reviewApprove bool
+	reviewReject  bool
+)
+
+var paymentCmd = &cobra.Command{
+	Use:   "payment",
+	Short: "Manage one-off payments",
+}
+
+var paymentAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a bonus, reimbursement or adjustment",
+	Long: `Adds a one-off payment for an employee. By default it is queued and paid with
+the next payroll run; --salary attaches it to an unfinished run and
+--standalone pays it right away in a run of its own.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if paymentOpts.Standalone && !askForConfirmation() {
+			return
+		}
+
+		h, closeFn, err := newHandler()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer closeFn()
+
+		ctx, stop := signalContext()
+		defer stop()
+
+		err = h.AddPayment(ctx, paymentOpts)
+		if err != nil {
+			log.Println(err)
+		}
+	},
+}
+
+var paymentReviewCmd = &cobra.Command{
+	Use:   "review [id]",
+	Short: "List payments held back by spending limits, or approve or reject one",
+	Long: `Without arguments, lists the payments held back by a spending limit and why.
+With a payment id, --approve releases it to be sent by the next repay and
+--reject cancels it.`,
+	Args: cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			withHandler(func(h *handler.Handler) error {
+				return h.ListPaymentsForReview(commandContext(), os.Stdout)
+			})
+			return
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("invalid payment id %q", args[0])
+		}
+
+		if reviewApprove == reviewReject {
+			log.Fatal("pass either --approve or --reject")
+		}
+
+		withHandler(func(h *handler.Handler) error {
+			return h.ReviewPayment(commandContext(), id, reviewApprove)
+		})
+	},
+}
+
+func init() {
+	flags := paymentAddCmd.Flags()
+	flags.Int64Var(&paymentOpts.EmployeeID, "employee", 0, "employee id")
+	flags.Int64Var(&paymentOpts.Amount, "amount", 0, "amount in the token's smallest unit")
+	flags.StringVar(&paymentOpts.Category, "category", string(repository.BonusCategory), "bonus, reimbursement or adjustment")
+	flags.StringVar(&paymentOpts.Memo, "memo", "", "note stored with the payment")
+	flags.Int64Var(&paymentOpts.SalaryID, "salary", 0, "attach to this unfinished payroll run")
+	flags.BoolVar(&paymentOpts.Standalone, "standalone", false, "pay right away instead of with a payroll run")
+	_ = paymentAddCmd.MarkFlagRequired("employee")
+	_ = paymentAddCmd.MarkFlagRequired("amount")
+	paymentAddCmd.MarkFlagsMutuallyExclusive("salary", "standalone")
+
+	paymentReviewCmd.Flags().BoolVar(&reviewApprove, "approve", false, "send the payment despite the limit")
+	paymentReviewCmd.Flags().BoolVar(&reviewReject, "reject", false, "never send the payment")
+	paymentReviewCmd.MarkFlagsMutuallyExclusive("approve", "reject")
+
+	paymentCmd.AddCommand(paymentAddCmd, paymentReviewCmd)
+	rootCmd.AddCommand(paymentCmd)
+}
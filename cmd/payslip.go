@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/handler"
+)
+
+var payslipOpts handler.PayslipOptions
+
+var payslipCmd = &cobra.Command{
+	Use:   "payslip",
+	Short: "Generate the payslips of the payroll runs of a period",
+	Long: `Writes a payslip for every employee paid by the payroll runs of the period,
+as HTML and PDF, rendered locally. A payslip lists the payments of the run
+with their gross amount, deductions, net amount, token, the salary rate the
+amount was prorated from, and the transaction that paid it linked on the
+block explorer set by EXPLORER_URL. Payments not paid yet are left out.
+
+Files are named payslip-<period>-run<salary id>-employee<employee id>.
+--templates reads payslip.html (an html/template) and payslip.txt (a
+text/template laid out in the PDF) from a directory instead of the built-in
+ones.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.GeneratePayslips(commandContext(), os.Stdout, payslipOpts)
+		})
+	},
+}
+
+func init() {
+	flags := payslipCmd.Flags()
+	flags.StringVar(&payslipOpts.Period, "period", "", "payroll period, e.g. 2026-10 or 2026-W42")
+	flags.Int64Var(&payslipOpts.EmployeeID, "employee", 0, "generate the payslips of one employee only")
+	flags.StringVar(&payslipOpts.Dir, "out", ".", "directory to write the payslips to")
+	flags.StringVar(&payslipOpts.Format, "format", "both", "output format: html, pdf or both")
+	flags.StringVar(&payslipOpts.Templates, "templates", "", "directory of payslip.html and payslip.txt templates")
+	_ = payslipCmd.MarkFlagRequired("period")
+
+	rootCmd.AddCommand(payslipCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/config"
+	"gitlab.midas.dev/back/river/internal/handler"
+	"gitlab.midas.dev/back/river/internal/reconcile"
+)
+
+var reconcileOpts handler.ReconcileOptions
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Check the payments recorded against the Transfer events on chain",
+	Long: `Scans the ERC-20 Transfer events sent from the treasury addresses in a block
+range and matches them to the done payments by transaction hash, recipient
+and amount. Treasuries are the signing addresses, the paying wallets recorded
+on payments, SAFE_ADDRESS, OFFLINE_ACCOUNT and any passed with --treasury.
+
+Lists every discrepancy:
+  unmatched_transfer  a transfer from a treasury no payment records
+  missing_payment     a done payment whose transaction, mined in the range,
+                      pays nothing to its recipient, failed or is unknown
+  amount_mismatch     a transfer of a payment's transaction to its recipient
+                      of another amount or token`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withChain(func(h *handler.Handler, client ethereum.Client, signers []string) error {
+			opts := reconcileOpts
+			opts.Treasuries = append(opts.Treasuries, signers...)
+			return h.Reconcile(commandContext(), os.Stdout, client, opts)
+		})
+	},
+}
+
+// withChain runs fn with a handler that cannot send payments, a client of
+// the node and the signing addresses, which are not unlocked
+func withChain(fn func(h *handler.Handler, client ethereum.Client, signers []string) error) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+	signers, err := signingAddresses(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ethClient, err := ethclient.Dial(cfg.Node)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ethClient.Close()
+
+	withHandler(func(h *handler.Handler) error {
+		return fn(h, ethereum.NewClient(ethClient), signers)
+	})
+}
+
+func init() {
+	flags := reconcileCmd.Flags()
+	flags.Uint64Var(&reconcileOpts.FromBlock, "from-block", 0, "first block to scan")
+	flags.Uint64Var(&reconcileOpts.ToBlock, "to-block", 0, "last block to scan")
+	flags.Uint64Var(&reconcileOpts.BatchSize, "batch", reconcile.DefaultBatchSize, "blocks scanned per log query")
+	flags.StringSliceVar(&reconcileOpts.Treasuries, "treasury", nil, "other address payments were sent from, repeatable")
+	flags.StringVar(&reconcileOpts.Format, "format", "table", "output format: table, csv or json")
+	_ = reconcileCmd.MarkFlagRequired("from-block")
+	_ = reconcileCmd.MarkFlagRequired("to-block")
+
+	rootCmd.AddCommand(reconcileCmd)
+}
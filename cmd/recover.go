@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/handler"
+	"gitlab.midas.dev/back/river/internal/reconcile"
+)
+
+var recoverOpts handler.RecoverOptions
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Rebuild the payments sent in a block range from the chain",
+	Long: `Rebuilds the payments lost with the database, e.g. after restoring an older
+backup, from the ERC-20 Transfer events sent from the treasury addresses in a
+block range: the signing addresses, the paying wallets recorded, SAFE_ADDRESS,
+OFFLINE_ACCOUNT and any passed with --treasury. Each transfer is listed as
+
+  recorded    a done payment records it already
+  settle      it paid the unfinished payment of the same recipient, amount
+              and token whose attempt it matches: the nonce of an exported
+              payment, the Safe transaction of a proposed one, or a block
+              mined after a payment River sends itself was created; the
+              payment is marked done with its transaction
+  create      it paid an employee no payment accounts for, and is recorded as
+              a done payment of an ad hoc payroll run of recovered payments
+  ambiguous   it may pay unfinished payments but matches the attempt of none
+              or several of them, and is left to the operator
+  unknown     no employee or payment has its recipient
+
+followed by the transactions of the treasuries, found from their nonces,
+that reverted or transferred nothing.
+
+Nothing is written without --apply, which takes the payroll lock. The
+recovery is recorded in the audit log along with the transactions each
+treasury sent, with their nonces.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withChain(func(h *handler.Handler, client ethereum.Client, signers []string) error {
+			opts := recoverOpts
+			opts.Treasuries = append(opts.Treasuries, signers...)
+			return h.Recover(commandContext(), os.Stdout, client, opts)
+		})
+	},
+}
+
+func init() {
+	flags := recoverCmd.Flags()
+	flags.Uint64Var(&recoverOpts.FromBlock, "from-block", 0, "first block to scan")
+	flags.Uint64Var(&recoverOpts.ToBlock, "to-block", 0, "last block to scan")
+	flags.Uint64Var(&recoverOpts.BatchSize, "batch", reconcile.DefaultBatchSize, "blocks scanned per log query")
+	flags.StringSliceVar(&recoverOpts.Treasuries, "treasury", nil, "other address payments were sent from, repeatable")
+	flags.BoolVar(&recoverOpts.Apply, "apply", false, "record the recovered payments instead of only listing them")
+	flags.StringVar(&recoverOpts.Format, "format", "table", "output format: table, csv or json")
+	_ = recoverCmd.MarkFlagRequired("from-block")
+	_ = recoverCmd.MarkFlagRequired("to-block")
+
+	rootCmd.AddCommand(recoverCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/handler"
+)
+
+var reportOpts handler.ReportOptions
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report on past payroll runs",
+	Long: `Reports on what River paid, as a table, CSV or JSON. --period limits a report
+to a month (2026-10), an ISO week (2026-W42) or a date range
+(2026-01-01..2026-12-31); without it, reports cover every run.`,
+}
+
+var reportHistoryCmd = &cobra.Command{
+	Use:   "history <employee id>",
+	Short: "List the payments of an employee",
+	Long: `Lists the payments of an employee paid in the period, along with those created
+in it and not paid yet.`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id := parseID("employee", args[0])
+
+		withHandler(func(h *handler.Handler) error {
+			return h.ReportHistory(commandContext(), os.Stdout, id, reportOpts)
+		})
+	},
+}
+
+var reportTotalsCmd = &cobra.Command{
+	Use:   "totals",
+	Short: "Sum what each payroll run paid by token",
+	Long: `Sums the paid payments of the payroll runs whose period starts in the period,
+and of the ad hoc runs created in it, by token.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.ReportTotals(commandContext(), os.Stdout, reportOpts)
+		})
+	},
+}
+
+var reportGasCmd = &cobra.Command{
+	Use:   "gas",
+	Short: "Sum the gas spent by each payroll run",
+	Long: `Sums the gas of the transactions River sent itself for the payroll runs of the
+period. Safe transactions are executed, and offline transactions paid for,
+outside River and are not included.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.ReportGas(commandContext(), os.Stdout, reportOpts)
+		})
+	},
+}
+
+var reportOutstandingCmd = &cobra.Command{
+	Use:   "outstanding",
+	Short: "List the payments not paid yet",
+	Long: `Lists every payment that is neither done nor rejected: payments that failed or
+were interrupted, held for review, queued for the next run, proposed to the
+Safe or exported for offline signing.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.ReportOutstanding(commandContext(), os.Stdout, reportOpts)
+		})
+	},
+}
+
+func init() {
+	flags := reportCmd.PersistentFlags()
+	flags.StringVar(&reportOpts.Period, "period", "", "limit the report to a period, e.g. 2026-10")
+	flags.StringVar(&reportOpts.Format, "format", "table", "output format: table, csv or json")
+
+	reportCmd.AddCommand(reportHistoryCmd, reportTotalsCmd, reportGasCmd, reportOutstandingCmd)
+	rootCmd.AddCommand(reportCmd)
+}
@@ -6,17 +6,43 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"syscall"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
 	"gitlab.midas.dev/back/river/db"
+	"gitlab.midas.dev/back/river/internal/approval"
+	"gitlab.midas.dev/back/river/internal/audit"
 	"gitlab.midas.dev/back/river/internal/client/ethereum"
 	"gitlab.midas.dev/back/river/internal/config"
 	"gitlab.midas.dev/back/river/internal/handler"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/recipient"
 	"gitlab.midas.dev/back/river/internal/service/payment"
 	"gitlab.midas.dev/back/river/internal/service/salary"
 )
 
+var (
+	period         string
+	force          bool
+	exportUnsigned bool
+)
+
+// handlerMode is what a command needs the handler to do
+type handlerMode int
+
+const (
+	// manageMode manages the database, without the node or signing keys
+	manageMode handlerMode = iota
+	// payMode sends payments, unlocking the signers
+	payMode
+	// offlineMode exports payments to be signed offline and broadcasts them
+	offlineMode
+)
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "./river or ./river repay",
@@ -24,63 +50,304 @@ var rootCmd = &cobra.Command{
 	Long:  ``,
 
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			log.Fatalf("Failed to load configuration: %v", err)
-		}
+		runPay(handler.PayOptions{Period: period, Force: force})
+	},
+}
 
-		// Open database connection
-		dbDriver, err := sql.Open("sqlite3", cfg.DatabasePath)
-		if err != nil {
-			log.Fatal(err)
-		}
+// payCmd is an explicit alias of the root command
+var payCmd = &cobra.Command{
+	Use:   "pay",
+	Short: "Create a payroll run for the current period and pay it",
 
-		defer func() {
-			err = dbDriver.Close()
-			if err != nil {
-				log.Fatal(err)
-			}
-		}()
+	Run: func(cmd *cobra.Command, args []string) {
+		runPay(handler.PayOptions{Period: period, Force: force})
+	},
+}
 
-		// Initialize database schema
-		_, err = dbDriver.Exec(db.Schema)
-		if err != nil {
-			log.Printf("%q: %s\n", err, db.Schema)
-			return
+var repayCmd = &cobra.Command{
+	Use:   "repay",
+	Short: "Retry failed or interrupted payments",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		runPay(handler.PayOptions{Repay: true})
+	},
+}
+
+// runPay asks for confirmation and executes a payroll or repay run
+func runPay(opts handler.PayOptions) {
+	if !askForConfirmation() {
+		return
+	}
+
+	mode := payMode
+	if exportUnsigned {
+		mode = offlineMode
+	}
+
+	h, closeFn, err := openHandler(mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeFn()
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	// Execute the command
+	err = h.Pay(ctx, opts)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// withHandler runs fn with a handler that cannot send payments, so that
+// commands managing the database do not need the node or signing keys
+func withHandler(fn func(h *handler.Handler) error) {
+	h, closeFn, err := openHandler(manageMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeFn()
+
+	err = fn(h)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// newHandler wires the handler with its dependencies from the configuration
+func newHandler() (*handler.Handler, func(), error) {
+	return openHandler(payMode)
+}
+
+// openHandler wires the handler, connecting to the node and unlocking the
+// signers only if it is to send payments
+func openHandler(mode handlerMode) (*handler.Handler, func(), error) {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	dbDriver, closeFn, err := openDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = prepareSchema(commandContext(), dbDriver)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+
+	key, err := cfg.DatabaseKey()
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+
+	// names and addresses of employees are encrypted if "db encrypt" was run
+	fields, err := db.LoadFields(commandContext(), dbDriver, key)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+
+	// Initialize repositories
+	salaryRepository := db.NewSalaryRepository(dbDriver, fields)
+	repos := handler.Repositories{
+		Locks:          db.NewLockRepository(dbDriver),
+		Deductions:     db.NewDeductionRepository(dbDriver),
+		AddressChanges: db.NewAddressChangeRepository(dbDriver, fields),
+		Audit:          db.NewAuditRepository(dbDriver),
+		Reports:        db.NewReportRepository(dbDriver, fields),
+		Employees:      db.NewEmployeeRepository(dbDriver, fields),
+		Recovery:       db.NewRecoveryRepository(dbDriver, fields),
+	}
+
+	err = repos.Audit.AppendChanged(commandContext(), "config.load", "config", 0, cfg.AuditDetails())
+	if err != nil {
+		closeFn()
+		return nil, nil, fmt.Errorf("failed to record configuration: %w", err)
+	}
+
+	policy, err := approval.NewPolicy(cfg.Approvers, cfg.ApprovalsRequired)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+
+	opts := salary.Options{
+		Limits: payroll.Limits{
+			MaxPayment:        cfg.MaxPayment,
+			MaxRunTotal:       cfg.MaxRunTotal,
+			MaxEmployeePeriod: cfg.MaxEmployeePeriod,
+			MaxChangePercent:  cfg.MaxChangePercent,
+		},
+		Approval: policy,
+	}
+
+	var paymentService salary.PaymentService
+	switch {
+	case mode == payMode:
+		paymentService, err = newPaymentService(cfg, &opts)
+	case mode == offlineMode:
+		opts.Offline, err = newOffline(cfg, &opts)
+	case cfg.SafeAddress != "":
+		// tracking Safe proposals needs the node but no signing key
+		opts.Safe, err = newSafeTracker(cfg)
+	}
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+
+	salaryService := salary.New(salaryRepository, paymentService, opts)
+
+	// Initialize handler
+	h := handler.New(dbDriver, salaryService, repos, cfg)
+
+	// the head of the audit log is kept outside the database once the
+	// command is done
+	closeHandler := func() {
+		if err := h.AnchorAudit(commandContext()); err != nil {
+			log.Printf("audit anchor error: %v", err)
 		}
+		closeFn()
+	}
 
-		// Initialize repositories
-		salaryRepository := db.NewSalaryRepository(dbDriver)
+	return h, closeHandler, nil
+}
+
+// openDatabase opens the database of the configuration, Postgres when
+// DATABASE_URL is set and SQLite otherwise
+func openDatabase(cfg *config.Config) (*sql.DB, func(), error) {
+	dbDriver, err := db.Open(cfg.DatabaseURL, cfg.DatabasePath)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// Initialize Ethereum client
-		ethClient, err := ethclient.Dial(cfg.Node)
+	closeFn := func() {
+		err := dbDriver.Close()
 		if err != nil {
 			log.Fatal(err)
 		}
-		client := ethereum.NewClient(ethClient)
+	}
+
+	return dbDriver, closeFn, nil
+}
 
-		// Initialize services
-		paymentService := payment.New(client)
-		salaryService := salary.New(salaryRepository, paymentService)
+// prepareSchema creates the schema of a new database, and otherwise checks
+// that it is up to date: migrating an existing database is left to
+// `river db migrate`, which backs it up first
+func prepareSchema(ctx context.Context, dbDriver *sql.DB) error {
+	migrator, err := db.NewMigrator(dbDriver)
+	if err != nil {
+		return err
+	}
 
-		// Initialize handler
-		h := handler.New(dbDriver, salaryService, cfg)
+	empty, err := migrator.Empty(ctx)
+	if err != nil {
+		return err
+	}
+	if empty {
+		_, err = migrator.Migrate(ctx)
+		return err
+	}
 
-		// Check if this is a repay command
-		isRepay := false
-		for _, v := range args {
-			if v == "repay" {
-				isRepay = true
-			}
+	return migrator.Check(ctx)
+}
+
+// newPaymentService connects to the node and unlocks the signers, setting
+// the recipient checks and the Safe payments are proposed to in opts
+func newPaymentService(cfg *config.Config, opts *salary.Options) (*payment.Service, error) {
+	ethClient, err := ethclient.Dial(cfg.Node)
+	if err != nil {
+		return nil, err
+	}
+	client := ethereum.NewClient(ethClient)
+
+	signers, err := loadSigners(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentService, err := payment.New(client, signers)
+	if err != nil {
+		return nil, err
+	}
+	reserved := paymentService.ReservedAddresses()
+
+	if cfg.SafeAddress != "" {
+		owner, err := safeOwner(cfg, signers)
+		if err != nil {
+			return nil, err
 		}
 
-		// Execute the command
-		err = h.Pay(context.Background(), isRepay)
+		safe, err := newSafe(cfg, client, owner)
 		if err != nil {
-			fmt.Println(err)
+			return nil, err
 		}
-	},
+		opts.Safe = safe
+		reserved[safe.Address()] = "Safe treasury"
+	}
+
+	opts.Recipients = recipient.NewChecker(client, reserved)
+	return paymentService, nil
+}
+
+// signalContext returns a command context cancelled on SIGINT or SIGTERM
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(commandContext(), os.Interrupt, syscall.SIGTERM)
+}
+
+// commandContext returns the context of a command, recording the changes it
+// makes in the audit log as done by the user running River
+func commandContext() context.Context {
+	return audit.WithActor(context.Background(), operator())
+}
+
+// operator identifies the user running River as user@host
+func operator() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+
+	return fmt.Sprintf("%s@%s", name, host)
+}
+
+func askForConfirmation() bool {
+	return confirm("Pay a salary")
+}
+
+// confirm asks a yes or no question, defaulting to no
+func confirm(question string) bool {
+	var s string
+
+	fmt.Printf("%s (y/N): ", question)
+	_, err := fmt.Scan(&s)
+	if err != nil {
+		return false
+	}
+
+	s = strings.TrimSpace(s)
+	s = strings.ToLower(s)
+
+	return s == "y" || s == "yes"
+}
+
+func init() {
+	for _, c := range []*cobra.Command{rootCmd, payCmd} {
+		c.Flags().StringVar(&period, "period", "", "period to pay: YYYY-MM, YYYY-Www or YYYY-MM-DD..YYYY-MM-DD (defaults to the current period of PAY_SCHEDULE)")
+		c.Flags().BoolVar(&force, "force", false, "pay the period even if it has already been paid")
+	}
+	for _, c := range []*cobra.Command{rootCmd, payCmd, repayCmd} {
+		c.Flags().BoolVar(&exportUnsigned, "export-unsigned", false, "export the payments unsigned for OFFLINE_ACCOUNT instead of sending them")
+	}
+
+	rootCmd.AddCommand(payCmd, repayCmd)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/config"
+	"gitlab.midas.dev/back/river/internal/handler"
+	"gitlab.midas.dev/back/river/internal/safe"
+	"gitlab.midas.dev/back/river/internal/service/payment"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// safeServiceTimeout bounds requests to the Safe transaction service
+const safeServiceTimeout = 30 * time.Second
+
+var safeCmd = &cobra.Command{
+	Use:   "safe",
+	Short: "Track payroll runs proposed to the Safe",
+	Long: `With SAFE_ADDRESS set, payroll runs are proposed to the owners of the Safe as
+a single MultiSend transaction instead of being sent from River's keys.`,
+}
+
+var safeStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Record executed proposals and list the pending ones",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		withHandler(func(h *handler.Handler) error {
+			return h.SafeStatus(commandContext(), os.Stdout)
+		})
+	},
+}
+
+var safeConfirmCmd = &cobra.Command{
+	Use:   "confirm <proposal> <tx-hash>",
+	Short: "Record the transaction that executed a proposal",
+	Long: `Records the transaction that executed a pending proposal, after checking its
+receipt on chain. Needed when proposals are exported rather than tracked by
+the Safe transaction service.`,
+	Args: cobra.ExactArgs(2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id := parseID("proposal", args[0])
+
+		withHandler(func(h *handler.Handler) error {
+			return h.ConfirmSafeProposal(commandContext(), id, args[1])
+		})
+	},
+}
+
+// newSafe creates the Safe payments are proposed to, signing with owner
+func newSafe(cfg *config.Config, client ethereum.Client, owner signer.MessageSigner) (*payment.Safe, error) {
+	opts := payment.SafeOptions{
+		Safe:      common.HexToAddress(cfg.SafeAddress),
+		ExportDir: cfg.SafeExportDir,
+	}
+	if cfg.SafeMultiSendAddress != "" {
+		opts.MultiSend = common.HexToAddress(cfg.SafeMultiSendAddress)
+	}
+	if cfg.SafeServiceURL != "" {
+		opts.API = safe.NewAPI(cfg.SafeServiceURL, safeServiceTimeout)
+	}
+
+	return payment.NewSafe(client, owner, opts)
+}
+
+// newSafeTracker creates a Safe that only tracks proposals, without unlocking a key
+func newSafeTracker(cfg *config.Config) (*payment.Safe, error) {
+	ethClient, err := ethclient.Dial(cfg.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSafe(cfg, ethereum.NewClient(ethClient), nil)
+}
+
+// safeOwner returns the signer of SAFE_OWNER, or the first signer
+func safeOwner(cfg *config.Config, signers []signer.Signer) (signer.MessageSigner, error) {
+	for _, sgn := range signers {
+		if cfg.SafeOwner != "" && sgn.Address() != common.HexToAddress(cfg.SafeOwner) {
+			continue
+		}
+
+		owner, ok := sgn.(signer.MessageSigner)
+		if !ok {
+			return nil, fmt.Errorf("signer %s cannot sign Safe transactions", sgn.Address().Hex())
+		}
+		return owner, nil
+	}
+
+	return nil, fmt.Errorf("SAFE_OWNER %s is not a configured signer", cfg.SafeOwner)
+}
+
+func init() {
+	safeCmd.AddCommand(safeStatusCmd, safeConfirmCmd)
+	rootCmd.AddCommand(safeCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"gitlab.midas.dev/back/river/internal/config"
+	"gitlab.midas.dev/back/river/internal/handler"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+var (
+	approveSignature string
+	approveAccount   string
+	approveKeystore  string
+)
+
+var salaryCmd = &cobra.Command{
+	Use:   "salary",
+	Short: "Inspect and approve payroll runs",
+}
+
+var salaryShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Print the manifest of a payroll run, its hash and approvals",
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id := parseID("salary", args[0])
+
+		withHandler(func(h *handler.Handler) error {
+			return h.ShowSalary(commandContext(), id, os.Stdout)
+		})
+	},
+}
+
+var salaryApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a draft payroll run",
+	Long: `Approves a draft payroll run by signing its manifest hash (see salary show)
+with EIP-191 personal_sign. Either pass a signature made elsewhere, e.g. with
+a hardware wallet, or an approver account in a keystore to sign with here.`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		id := parseID("salary", args[0])
+
+		opts := handler.ApproveOptions{Signature: approveSignature}
+		if approveAccount != "" {
+			opts.Signer = approverSigner()
+		}
+
+		withHandler(func(h *handler.Handler) error {
+			return h.ApproveSalary(commandContext(), id, opts)
+		})
+	},
+}
+
+// approverSigner unlocks the approver account, always asking for its
+// passphrase since it is not the payroll wallet's
+func approverSigner() signer.MessageSigner {
+	dir := approveKeystore
+	if dir == "" {
+		cfg, err := config.Read()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		dir = cfg.KeystoreDir
+	}
+	if dir == "" {
+		log.Fatal("pass --keystore or set KEYSTORE_DIR")
+	}
+
+	if !common.IsHexAddress(approveAccount) {
+		log.Fatalf("invalid account %q", approveAccount)
+	}
+
+	passphrase, err := readSecret(fmt.Sprintf("Passphrase for %s: ", approveAccount))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	signers, err := signer.OpenKeystore(dir).Signers([]string{approveAccount}, passphrase)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return signers[0].(signer.MessageSigner)
+}
+
+func init() {
+	flags := salaryApproveCmd.Flags()
+	flags.StringVar(&approveSignature, "signature", "", "hex personal_sign signature of the manifest hash")
+	flags.StringVar(&approveAccount, "account", "", "approver account to sign with")
+	flags.StringVar(&approveKeystore, "keystore", "", "keystore holding the approver account (default KEYSTORE_DIR)")
+	salaryApproveCmd.MarkFlagsMutuallyExclusive("signature", "account")
+
+	salaryCmd.AddCommand(salaryShowCmd, salaryApproveCmd)
+	rootCmd.AddCommand(salaryCmd)
+}
@@ -0,0 +1,348 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func NewAddressChangeRepository(db *sql.DB, fields *Fields) repository.AddressChangeRepository {
+	return &addressChangeRepository{db: db, fields: fields}
+}
+
+type addressChangeRepository struct {
+	db     *sql.DB
+	fields *Fields
+}
+
+const selectAddressChanges = `
+		SELECT id, employee_id, old_addr, new_addr, requested_by, COALESCE(reason, ''), status,
+			COALESCE(signature, ''), requested_at, effective_at, verified_at, applied_at
+		FROM address_changes`
+
+func (a *addressChangeRepository) Request(ctx context.Context, change *entity.AddressChange) (int64, error) {
+	// the old address is copied from the employee as stored
+	newAddr, err := a.fields.seal("addr", change.NewAddr)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := a.db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var oldAddr string
+	err = tx.QueryRowContext(ctx, `SELECT addr FROM employers WHERE id = $1`, change.EmployeeID).Scan(&oldAddr)
+
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %d", repository.ErrEmployeeNotFound, change.EmployeeID)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	// The latest request replaces earlier ones that were not applied yet
+	_, err = tx.ExecContext(ctx, `
+		UPDATE address_changes SET status = $1 WHERE employee_id = $2 AND status = $3`,
+		repository.CancelledAddressChange, change.EmployeeID, repository.PendingAddressChange)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var reason sql.NullString
+	if change.Reason != "" {
+		reason = sql.NullString{String: change.Reason, Valid: true}
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO address_changes (employee_id, old_addr, new_addr, requested_by, reason, status, requested_at, effective_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;
+	`, change.EmployeeID, oldAddr, newAddr, change.RequestedBy, reason, repository.PendingAddressChange,
+		utcSecond(change.RequestedAt), utcSecond(change.EffectiveAt)).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = appendAudit(ctx, tx, "address_change.request", "address_change", id, map[string]interface{}{
+		"employee_id":  change.EmployeeID,
+		"old_addr":     oldAddr,
+		"new_addr":     newAddr,
+		"requested_by": change.RequestedBy,
+		"effective_at": utcSecond(change.EffectiveAt),
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (a *addressChangeRepository) Get(ctx context.Context, id int64) (*entity.AddressChange, error) {
+	rows, err := a.db.QueryContext(ctx, selectAddressChanges+` WHERE id = $1`, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %d", repository.ErrAddressChangeNotFound, id)
+	}
+
+	change, err := scanAddressChange(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.fields.openAddressChange(change); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (a *addressChangeRepository) Verify(ctx context.Context, id int64, signature string, at time.Time) error {
+	tx, err := a.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE address_changes SET signature = $1, verified_at = $2 WHERE id = $3 AND status = $4`,
+		signature, utcSecond(at), id, repository.PendingAddressChange)
+
+	if err := expectAddressChange(res, err, id); err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "address_change.verify", "address_change", id, map[string]interface{}{"signature": signature})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (a *addressChangeRepository) Cancel(ctx context.Context, id int64) error {
+	tx, err := a.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE address_changes SET status = $1 WHERE id = $2 AND status = $3`,
+		repository.CancelledAddressChange, id, repository.PendingAddressChange)
+
+	if err := expectAddressChange(res, err, id); err != nil {
+		return err
+	}
+
+	if err := appendAudit(ctx, tx, "address_change.cancel", "address_change", id, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (a *addressChangeRepository) List(ctx context.Context) ([]*entity.AddressChange, error) {
+	rows, err := a.db.QueryContext(ctx, selectAddressChanges+` ORDER BY id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	changes := make([]*entity.AddressChange, 0)
+	for rows.Next() {
+		change, err := scanAddressChange(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := a.fields.openAddressChange(change); err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (a *addressChangeRepository) ApplyDue(ctx context.Context, now time.Time, requireVerified bool) error {
+	tx, err := a.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := applyDueAddressChanges(ctx, tx, now, requireVerified); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applyDueAddressChanges updates the addresses of employees whose pending
+// change is past its cooldown, and verified if required, and releases the
+// salaries held for them to the new address. It returns the changes still
+// pending by employee.
+func applyDueAddressChanges(ctx context.Context, tx *sql.Tx, now time.Time, requireVerified bool) (map[int64]*entity.AddressChange, error) {
+	rows, err := tx.QueryContext(ctx, selectAddressChanges+` WHERE status = $1 ORDER BY id`,
+		repository.PendingAddressChange)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*entity.AddressChange
+	for rows.Next() {
+		change, err := scanAddressChange(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now = utcSecond(now)
+	pending := make(map[int64]*entity.AddressChange)
+	for _, change := range changes {
+		if change.EffectiveAt.After(now) || (requireVerified && change.VerifiedAt == nil) {
+			pending[change.EmployeeID] = change
+			continue
+		}
+
+		// the change is applied first, as employee addresses only change to
+		// the address of an applied change
+		_, err = tx.ExecContext(ctx, `
+			UPDATE address_changes SET status = $1, applied_at = $2 WHERE id = $3`,
+			repository.AppliedAddressChange, now, change.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE employers SET addr = $1 WHERE id = $2`, change.NewAddr, change.EmployeeID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE payments SET addr = $1, status = $2, error = NULL, version = version + 1
+			WHERE employee_id = $3 AND status = $4 AND error = $5`,
+			change.NewAddr, repository.CreatedStatus, change.EmployeeID, repository.NeedsReviewStatus,
+			addressHoldReason(change))
+		if err != nil {
+			return nil, err
+		}
+
+		err = appendAudit(ctx, tx, "address_change.apply", "address_change", change.ID, map[string]interface{}{
+			"employee_id": change.EmployeeID,
+			"new_addr":    change.NewAddr,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pending, nil
+}
+
+// addressHoldReason is the error of salaries held for the address change
+func addressHoldReason(change *entity.AddressChange) string {
+	return fmt.Sprintf("address change %d pending", change.ID)
+}
+
+func expectAddressChange(res sql.Result, err error, id int64) error {
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %d", repository.ErrAddressChangeNotFound, id)
+	}
+
+	return nil
+}
+
+func scanAddressChange(rows *sql.Rows) (*entity.AddressChange, error) {
+	change := new(entity.AddressChange)
+
+	var verifiedAt, appliedAt sql.NullTime
+	err := rows.Scan(&change.ID, &change.EmployeeID, &change.OldAddr, &change.NewAddr, &change.RequestedBy,
+		&change.Reason, &change.Status, &change.Signature, &change.RequestedAt, &change.EffectiveAt,
+		&verifiedAt, &appliedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if verifiedAt.Valid {
+		change.VerifiedAt = &verifiedAt.Time
+	}
+	if appliedAt.Valid {
+		change.AppliedAt = &appliedAt.Time
+	}
+
+	return change, nil
+}
+
+// utcSecond normalizes timestamps so that they compare correctly as text in SQLite
+func utcSecond(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
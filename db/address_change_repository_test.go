@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestAddressChangeRepository_Cooldown(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	changes := NewAddressChangeRepository(dbDriver, nil)
+	salaries := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+
+	requested := time.Date(2026, time.October, 29, 12, 0, 0, 0, time.UTC)
+	id, err := changes.Request(ctx, &entity.AddressChange{
+		EmployeeID:  1,
+		NewAddr:     "0x02",
+		RequestedBy: "bob",
+		RequestedAt: requested,
+		EffectiveAt: requested.Add(72 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = changes.Request(ctx, &entity.AddressChange{EmployeeID: 2, NewAddr: "0x02", RequestedBy: "bob"})
+	assert.ErrorIs(t, err, repository.ErrEmployeeNotFound)
+
+	// during the cooldown the salary is held instead of paid to the old address
+	october := payroll.MonthPeriod(requested)
+	require.NoError(t, salaries.Create(ctx, repository.CreateSalaryParams{
+		Period: october, Schedule: string(payroll.Monthly), Now: requested.Add(time.Hour), HoldPendingAddress: true,
+	}))
+
+	payments, err := salaries.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, "0x01", payments[0].Addr)
+	assert.Equal(t, string(repository.NeedsReviewStatus), payments[0].Status)
+
+	// unverified changes wait when verification is required
+	require.NoError(t, changes.ApplyDue(ctx, requested.Add(73*time.Hour), true))
+	change, err := changes.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, string(repository.PendingAddressChange), change.Status)
+	assert.Equal(t, "0x01", change.OldAddr)
+	assert.Equal(t, "bob", change.RequestedBy)
+
+	// once due and verified, the change is applied and the held salary released to the new address
+	require.NoError(t, changes.Verify(ctx, id, "0x1234", requested.Add(time.Hour)))
+	require.NoError(t, changes.ApplyDue(ctx, requested.Add(73*time.Hour), true))
+
+	change, err = changes.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, string(repository.AppliedAddressChange), change.Status)
+	require.NotNil(t, change.AppliedAt)
+
+	payments, err = salaries.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, "0x02", payments[0].Addr)
+	assert.Equal(t, string(repository.CreatedStatus), payments[0].Status)
+
+	assert.ErrorIs(t, changes.Cancel(ctx, id), repository.ErrAddressChangeNotFound)
+}
+
+func TestAddressChangeRepository_DirectUpdate(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	changes := NewAddressChangeRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+
+	// changing the address directly would skip the cooldown
+	_, err = dbDriver.Exec(`UPDATE employers SET addr = '0x03' WHERE id = 1`)
+	assert.Error(t, err)
+
+	requested := time.Date(2026, time.October, 29, 12, 0, 0, 0, time.UTC)
+	_, err = changes.Request(ctx, &entity.AddressChange{
+		EmployeeID: 1, NewAddr: "0x02", RequestedBy: "bob", RequestedAt: requested, EffectiveAt: requested.Add(72 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	// so does changing it to a pending address
+	_, err = dbDriver.Exec(`UPDATE employers SET addr = '0x02' WHERE id = 1`)
+	assert.Error(t, err)
+
+	// once due, the change is applied, and other columns still change freely
+	require.NoError(t, changes.ApplyDue(ctx, requested.Add(73*time.Hour), false))
+	_, err = dbDriver.Exec(`UPDATE employers SET amount_salary = 1200 WHERE id = 1`)
+	require.NoError(t, err)
+
+	employees, err := NewEmployeeRepository(dbDriver, nil).List(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	assert.Equal(t, "0x02", employees[0].Addr)
+}
+
+func TestAddressChangeRepository_LatestRequestWins(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	changes := NewAddressChangeRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+
+	now := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	for _, addr := range []string{"0x02", "0x03"} {
+		_, err = changes.Request(ctx, &entity.AddressChange{
+			EmployeeID: 1, NewAddr: addr, RequestedBy: "bob", RequestedAt: now, EffectiveAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	list, err := changes.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, string(repository.CancelledAddressChange), list[0].Status)
+	assert.Equal(t, string(repository.PendingAddressChange), list[1].Status)
+
+	require.NoError(t, changes.ApplyDue(ctx, now, false))
+
+	var addr string
+	require.NoError(t, dbDriver.QueryRow(`SELECT addr FROM employers WHERE id = 1`).Scan(&addr))
+	assert.Equal(t, "0x03", addr)
+}
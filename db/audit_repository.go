@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/audit"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func NewAuditRepository(db *sql.DB) repository.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func (a *auditRepository) Append(ctx context.Context, action, entityType string, entityID int64, details map[string]interface{}) error {
+	tx, err := a.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := appendAudit(ctx, tx, action, entityType, entityID, details); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (a *auditRepository) AppendChanged(ctx context.Context, action, entityType string, entityID int64, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	tx, err := a.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var last string
+	err = tx.QueryRowContext(ctx, `
+		SELECT details FROM audit_log WHERE action = $1 ORDER BY id DESC LIMIT 1`, action).Scan(&last)
+
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if err == nil && last == string(data) {
+		return nil
+	}
+
+	if err := appendAudit(ctx, tx, action, entityType, entityID, details); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (a *auditRepository) List(ctx context.Context) ([]*entity.AuditEntry, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT id, COALESCE(seq, 0), at, actor, action, entity, COALESCE(entity_id, 0), details,
+			COALESCE(prev_hash, ''), COALESCE(hash, '')
+		FROM audit_log ORDER BY seq IS NULL, seq, id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	entries := make([]*entity.AuditEntry, 0)
+	for rows.Next() {
+		entry := new(entity.AuditEntry)
+		err = rows.Scan(&entry.ID, &entry.Seq, &entry.At, &entry.Actor, &entry.Action, &entry.Entity,
+			&entry.EntityID, &entry.Details, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// appendAudit records a change in the audit log, in the transaction making
+// the change, and seals it into the hash chain
+func appendAudit(ctx context.Context, tx *sql.Tx, action, entityType string, entityID int64, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	var id sql.NullInt64
+	if entityID != 0 {
+		id = sql.NullInt64{Int64: entityID, Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (at, actor, action, entity, entity_id, details)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		utcSecond(time.Now()), audit.Actor(ctx), action, entityType, id, string(data))
+
+	if err != nil {
+		return err
+	}
+
+	return sealAudit(ctx, tx)
+}
+
+// sealAudit chains the unsealed entries, those just appended and those the
+// database triggers recorded, after the last sealed one. Entries keep the
+// sequence number they were recorded with, so that a deleted one leaves a
+// gap; those recorded without one are numbered after the last. Entries are
+// sealed as read back, so that the hash covers the stored values.
+func sealAudit(ctx context.Context, tx *sql.Tx) error {
+	var prev string
+	err := tx.QueryRowContext(ctx, `
+		SELECT hash FROM audit_log WHERE hash IS NOT NULL ORDER BY seq DESC LIMIT 1`).Scan(&prev)
+
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	var last int64
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM audit_log`).Scan(&last)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, COALESCE(seq, 0), at, actor, action, entity, COALESCE(entity_id, 0), details
+		FROM audit_log WHERE hash IS NULL ORDER BY seq IS NULL, seq, id`)
+
+	if err != nil {
+		return err
+	}
+
+	var entries []*entity.AuditEntry
+	for rows.Next() {
+		entry := new(entity.AuditEntry)
+		err = rows.Scan(&entry.ID, &entry.Seq, &entry.At, &entry.Actor, &entry.Action, &entry.Entity, &entry.EntityID, &entry.Details)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Seq == 0 {
+			last++
+			entry.Seq = last
+		}
+		entry.PrevHash = prev
+		entry.Hash = audit.Hash(prev, entry)
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE audit_log SET seq = $1, prev_hash = $2, hash = $3 WHERE id = $4`,
+			entry.Seq, entry.PrevHash, entry.Hash, entry.ID)
+		if err != nil {
+			return err
+		}
+
+		prev = entry.Hash
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/audit"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestAuditRepository_Chain(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := audit.WithActor(context.Background(), "alice@host")
+	log := NewAuditRepository(dbDriver)
+	salaries := NewSalaryRepository(dbDriver, nil)
+
+	// employees are edited in SQL, the triggers record it unsealed
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+	_, err = dbDriver.Exec(`UPDATE employers SET amount_salary = 1200 WHERE id = 1`)
+	require.NoError(t, err)
+
+	entries, err := log.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "employee.update", entries[1].Action)
+	assert.Equal(t, "database", entries[1].Actor)
+
+	// SQLite and Postgres space the JSON differently
+	var details struct {
+		New struct {
+			AmountSalary int64 `json:"amount_salary"`
+		} `json:"new"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(entries[1].Details), &details))
+	assert.Equal(t, int64(1200), details.New.AmountSalary)
+
+	report := audit.Verify(entries)
+	assert.NoError(t, report.Err())
+	assert.Equal(t, 2, report.Unsealed)
+
+	// River's changes are recorded in their transaction and seal the trigger rows
+	require.NoError(t, salaries.Create(ctx, repository.CreateSalaryParams{
+		Period: payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)), Schedule: string(payroll.Monthly),
+	}))
+	payments, err := salaries.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, salaries.ClaimPayment(ctx, payments[0], repository.ProcessingStatus))
+
+	config := map[string]interface{}{"max_payment": 100}
+	require.NoError(t, log.AppendChanged(ctx, "config.load", "config", 0, config))
+	require.NoError(t, log.AppendChanged(ctx, "config.load", "config", 0, config))
+
+	entries, err = log.List(ctx)
+	require.NoError(t, err)
+
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{"employee.insert", "employee.update", "salary.create", "payment.status", "config.load"}, actions)
+	assert.Equal(t, "alice@host", entries[2].Actor)
+	assert.Equal(t, int64(5), entries[4].Seq)
+
+	report = audit.Verify(entries)
+	require.NoError(t, report.Err())
+	assert.Equal(t, 5, report.Sealed)
+	assert.Equal(t, 0, report.Unsealed)
+	head := report.Head
+
+	// an edited row no longer matches its hash
+	_, err = dbDriver.Exec(`UPDATE audit_log SET details = '{"status":"created"}' WHERE seq = 4`)
+	require.NoError(t, err)
+	assertTampered(t, log, "entry 4 was edited")
+
+	// a deleted row leaves a gap
+	_, err = dbDriver.Exec(`DELETE FROM audit_log WHERE seq = 4`)
+	require.NoError(t, err)
+	assertTampered(t, log, "entries 4 to 4 are missing or out of order")
+
+	// deleting the last rows is only detected against an anchor
+	_, err = dbDriver.Exec(`DELETE FROM audit_log WHERE seq >= 4`)
+	require.NoError(t, err)
+	entries, err = log.List(ctx)
+	require.NoError(t, err)
+
+	report = audit.Verify(entries)
+	assert.NoError(t, report.Err())
+	report.Anchor(entries, head.Seq, head.Hash)
+	assert.ErrorIs(t, report.Err(), audit.ErrTampered)
+}
+
+func TestAuditRepository_UnsealedDeleted(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	log := NewAuditRepository(dbDriver)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+	_, err = dbDriver.Exec(`UPDATE employers SET amount_salary = 9000 WHERE id = 1`)
+	require.NoError(t, err)
+	_, err = dbDriver.Exec(`UPDATE employers SET amount_salary = 1000 WHERE id = 1`)
+	require.NoError(t, err)
+
+	// trigger rows are numbered as recorded, so deleting one before it is
+	// sealed leaves a gap, before and after sealing
+	_, err = dbDriver.Exec(`DELETE FROM audit_log WHERE seq = 2`)
+	require.NoError(t, err)
+	assertTampered(t, log, "entries 2 to 2 are missing or out of order")
+
+	require.NoError(t, log.Append(ctx, "config.load", "config", 0, nil))
+	assertTampered(t, log, "entries 2 to 2 are missing or out of order")
+}
+
+func assertTampered(t *testing.T, log repository.AuditRepository, problem string) {
+	t.Helper()
+
+	entries, err := log.List(context.Background())
+	require.NoError(t, err)
+
+	report := audit.Verify(entries)
+	assert.ErrorIs(t, report.Err(), audit.ErrTampered)
+	assert.Contains(t, report.Problems, problem)
+}
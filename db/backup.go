@@ -0,0 +1,344 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrBackupUnsupported is returned when backing up a database River cannot copy
+var ErrBackupUnsupported = errors.New("backing up this database is not supported")
+
+// ErrBackupEncrypted is returned when restoring an encrypted backup without a key
+var ErrBackupEncrypted = errors.New("the backup is encrypted, set DATABASE_KEY or DATABASE_KEY_FILE to restore it")
+
+// sealedMagic starts the files of encrypted backups, and is authenticated
+// along with them
+var sealedMagic = []byte("RIVERENC1\n")
+
+// snapshotPrefix starts the names of snapshots, which sort by time
+const snapshotPrefix = "river-"
+
+// busyWait is how long a backup waits for a writer to finish
+const busyWait = 50 * time.Millisecond
+
+// Backup writes a consistent copy of a SQLite database to path with the
+// online backup API, readable by the owner only and encrypted with key
+// unless it is nil. An existing file is not overwritten. Postgres databases
+// are backed up with their own tools.
+func Backup(ctx context.Context, db *sql.DB, path string, key []byte) error {
+	if dialectOf(db) != SQLite {
+		return ErrBackupUnsupported
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		err = file.Close()
+		if err == nil {
+			err = copyDatabase(ctx, db, path, false)
+		}
+	} else {
+		err = backupSealed(ctx, db, file, key)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// backupSealed copies the database into memory and writes the encrypted
+// image of the copy to file, so that no plaintext copy is ever written to disk
+func backupSealed(ctx context.Context, db *sql.DB, file *os.File, key []byte) error {
+	var data []byte
+	err := withMemory(ctx, func(memory *sqlite3.SQLiteConn) error {
+		err := withConn(ctx, db, func(conn *sqlite3.SQLiteConn) error {
+			return step(ctx, memory, conn)
+		})
+		if err != nil {
+			return err
+		}
+
+		data, err = memory.Serialize("main")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	sealed, err := seal(key, data)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(sealed)
+	return err
+}
+
+// Restore replaces the content of a SQLite database with the backup at
+// path, decrypting it with key. The backup must be a River database.
+// Encrypted backups are decrypted into memory only.
+func Restore(ctx context.Context, db *sql.DB, path string, key []byte) error {
+	if dialectOf(db) != SQLite {
+		return ErrBackupUnsupported
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(data, sealedMagic) {
+		if err := checkBackup(ctx, path); err != nil {
+			return err
+		}
+		return copyDatabase(ctx, db, path, true)
+	}
+
+	if key == nil {
+		return ErrBackupEncrypted
+	}
+	data, err = unseal(key, data)
+	if err != nil {
+		return err
+	}
+
+	return withMemory(ctx, func(memory *sqlite3.SQLiteConn) error {
+		if err := memory.Deserialize(data, "main"); err != nil {
+			return fmt.Errorf("%s is not a SQLite database: %w", path, err)
+		}
+		if err := checkRiver(memory, path); err != nil {
+			return err
+		}
+
+		return withConn(ctx, db, func(conn *sqlite3.SQLiteConn) error {
+			return step(ctx, conn, memory)
+		})
+	})
+}
+
+// checkBackup checks that the SQLite database at path is one of River
+func checkBackup(ctx context.Context, path string) error {
+	backup, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	var count int
+	err = backup.QueryRowContext(ctx, SQLite.tableQuery(), "schema_version").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("%s is not a SQLite database: %w", path, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%s is not a River database", path)
+	}
+	return nil
+}
+
+// checkRiver checks that the SQLite database of conn, read from path, is one
+// of River
+func checkRiver(conn *sqlite3.SQLiteConn, path string) error {
+	rows, err := conn.Query(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`, nil)
+	if err != nil {
+		return fmt.Errorf("%s is not a SQLite database: %w", path, err)
+	}
+	defer rows.Close()
+
+	count := make([]driver.Value, 1)
+	if err := rows.Next(count); err != nil {
+		return fmt.Errorf("%s is not a SQLite database: %w", path, err)
+	}
+	if n, _ := count[0].(int64); n == 0 {
+		return fmt.Errorf("%s is not a River database", path)
+	}
+	return nil
+}
+
+// Snapshot backs up a SQLite database to a new file in dir named after the
+// time, and removes the oldest snapshots beyond keep, unless keep is zero.
+// It returns the path of the snapshot.
+func Snapshot(ctx context.Context, db *sql.DB, dir string, key []byte, keep int) (string, error) {
+	if dialectOf(db) != SQLite {
+		return "", ErrBackupUnsupported
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	name := snapshotPrefix + time.Now().UTC().Format("20060102T150405.000000Z") + ".db"
+	if key != nil {
+		name += ".enc"
+	}
+	path := filepath.Join(dir, name)
+	if err := Backup(ctx, db, path, key); err != nil {
+		return "", err
+	}
+
+	if keep > 0 {
+		if err := pruneSnapshots(dir, keep); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// pruneSnapshots removes the oldest snapshots in dir beyond keep
+func pruneSnapshots(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, snapshotPrefix) &&
+			(strings.HasSuffix(name, ".db") || strings.HasSuffix(name, ".db.enc")) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	sort.Strings(snapshots)
+
+	for len(snapshots) > keep {
+		if err := os.Remove(filepath.Join(dir, snapshots[0])); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+// copyDatabase copies db to the SQLite database file at path, or path to db
+// when restoring, with the online backup API
+func copyDatabase(ctx context.Context, db *sql.DB, path string, restore bool) error {
+	other, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer other.Close()
+
+	return withConn(ctx, db, func(src *sqlite3.SQLiteConn) error {
+		return withConn(ctx, other, func(dest *sqlite3.SQLiteConn) error {
+			if restore {
+				src, dest = dest, src
+			}
+			return step(ctx, dest, src)
+		})
+	})
+}
+
+// withConn runs fn with a SQLite connection of db
+func withConn(ctx context.Context, db *sql.DB, fn func(conn *sqlite3.SQLiteConn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return ErrBackupUnsupported
+		}
+		return fn(sqliteConn)
+	})
+}
+
+// withMemory runs fn with the connection of a new in-memory SQLite database,
+// which lives as long as the connection
+func withMemory(ctx context.Context, fn func(memory *sqlite3.SQLiteConn) error) error {
+	memory, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return err
+	}
+	defer memory.Close()
+
+	return withConn(ctx, memory, fn)
+}
+
+// step copies every page of src to dest, waiting while a writer holds src
+func step(ctx context.Context, dest, src *sqlite3.SQLiteConn) error {
+	backup, err := dest.Backup("main", src, "main")
+	if err != nil {
+		return err
+	}
+
+	for {
+		done, err := backup.Step(-1)
+		if err != nil {
+			_ = backup.Finish()
+			return err
+		}
+		if done {
+			return backup.Finish()
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = backup.Finish()
+			return ctx.Err()
+		case <-time.After(busyWait):
+		}
+	}
+}
+
+// seal encrypts data with AES-256-GCM
+func seal(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := append(append([]byte{}, sealedMagic...), nonce...)
+	return aead.Seal(sealed, nonce, data, sealedMagic), nil
+}
+
+// unseal decrypts what seal encrypted
+func unseal(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed = sealed[len(sealedMagic):]
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("the backup is truncated")
+	}
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], sealedMagic)
+	if err != nil {
+		return nil, errors.New("failed to decrypt the backup: the key is not the one it was encrypted with, or it was altered")
+	}
+	return data, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("the backup key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFileTestDB creates a SQLite database with the migrations applied and
+// one employee in dir
+func newFileTestDB(t *testing.T, dir string) *sql.DB {
+	t.Helper()
+
+	dbDriver := migrateTestDB(t, openTestDB(t, filepath.Join(dir, "main.db")))
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+	return dbDriver
+}
+
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbDriver := newFileTestDB(t, dir)
+
+	path := filepath.Join(dir, "main.db.bak")
+	require.NoError(t, Backup(ctx, dbDriver, path, nil))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	backup := openTestDB(t, path)
+	migrator, err := NewMigrator(backup)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Check(ctx))
+
+	employees, err := NewEmployeeRepository(backup, nil).List(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	assert.Equal(t, "alice", employees[0].Name)
+
+	// an existing file is not overwritten
+	assert.ErrorIs(t, Backup(ctx, dbDriver, path, nil), os.ErrExist)
+}
+
+func TestBackup_Encrypted(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbDriver := newFileTestDB(t, dir)
+	key := bytes.Repeat([]byte{7}, 32)
+
+	path := filepath.Join(dir, "main.db.enc")
+	require.NoError(t, Backup(ctx, dbDriver, path, key))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, sealedMagic))
+	assert.NotContains(t, string(data), "alice")
+	assert.NotContains(t, string(data), "SQLite format")
+
+	// nothing is left in plain text next to it
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	_, err = dbDriver.Exec(`UPDATE employers SET name = 'mallory'`)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, Restore(ctx, dbDriver, path, nil), ErrBackupEncrypted)
+	assert.Error(t, Restore(ctx, dbDriver, path, bytes.Repeat([]byte{8}, 32)))
+
+	// encrypted files that are not River databases are refused
+	garbage, err := seal(key, []byte("not a database, but long enough to look like a header of one"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "garbage.enc"), garbage, 0600))
+	assert.Error(t, Restore(ctx, dbDriver, filepath.Join(dir, "garbage.enc"), key))
+
+	require.NoError(t, Restore(ctx, dbDriver, path, key))
+	employees, err := NewEmployeeRepository(dbDriver, nil).List(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	assert.Equal(t, "alice", employees[0].Name)
+
+	// the backup is decrypted in memory, next to it are only the files
+	// of the test
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbDriver := newFileTestDB(t, dir)
+
+	path := filepath.Join(dir, "main.db.bak")
+	require.NoError(t, Backup(ctx, dbDriver, path, nil))
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('bob', '0x02', 2000)`)
+	require.NoError(t, err)
+
+	require.NoError(t, Restore(ctx, dbDriver, path, nil))
+	employees, err := NewEmployeeRepository(dbDriver, nil).List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, employees, 1)
+
+	// only River databases are restored
+	other := filepath.Join(dir, "other.db")
+	_, err = openTestDB(t, other).Exec(`CREATE TABLE notes (body TEXT)`)
+	require.NoError(t, err)
+	assert.Error(t, Restore(ctx, dbDriver, other, nil))
+
+	garbage := filepath.Join(dir, "garbage.db")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a database, but long enough to look like a header of one"), 0600))
+	assert.Error(t, Restore(ctx, dbDriver, garbage, nil))
+
+	employees, err = NewEmployeeRepository(dbDriver, nil).List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, employees, 1)
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dbDriver := newFileTestDB(t, t.TempDir())
+	dir := filepath.Join(t.TempDir(), "snapshots")
+
+	var paths []string
+	for i := 0; i < 4; i++ {
+		path, err := Snapshot(ctx, dbDriver, dir, nil, 3)
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600))
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	// the oldest snapshot is removed, and other files are left alone
+	_, err = os.Stat(paths[0])
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
+
+	path, err := Snapshot(ctx, dbDriver, dir, bytes.Repeat([]byte{7}, 32), 0)
+	require.NoError(t, err)
+	assert.Equal(t, ".enc", filepath.Ext(path))
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 5)
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func NewDeductionRepository(db *sql.DB) repository.DeductionRepository {
+	return &deductionRepository{db: db}
+}
+
+type deductionRepository struct {
+	db *sql.DB
+}
+
+const selectDeductions = `
+		SELECT id, employee_id, name, kind, amount, COALESCE(treasury_addr, ''), active, created_at FROM deductions`
+
+func (d *deductionRepository) Add(ctx context.Context, deduction *entity.Deduction) (int64, error) {
+	var treasury sql.NullString
+	if deduction.TreasuryAddr != "" {
+		treasury = sql.NullString{String: deduction.TreasuryAddr, Valid: true}
+	}
+
+	tx, err := d.db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO deductions (employee_id, name, kind, amount, treasury_addr)
+		SELECT id, $1, $2, CAST($3 AS BIGINT), $4 FROM employers WHERE id = $5
+		RETURNING id;
+	`, deduction.Name, deduction.Kind, deduction.Amount, treasury, deduction.EmployeeID).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return 0, repository.ErrEmployeeNotFound
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = appendAudit(ctx, tx, "deduction.create", "deduction", id, map[string]interface{}{
+		"employee_id":   deduction.EmployeeID,
+		"name":          deduction.Name,
+		"kind":          deduction.Kind,
+		"amount":        deduction.Amount,
+		"treasury_addr": deduction.TreasuryAddr,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (d *deductionRepository) List(ctx context.Context) ([]*entity.Deduction, error) {
+	rows, err := d.db.QueryContext(ctx, selectDeductions+` ORDER BY employee_id, id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	deductions := make([]*entity.Deduction, 0)
+	var malformed malformedRows
+	for rows.Next() {
+		deduction, err := scanDeduction(rows)
+		if err != nil {
+			malformed.add(err)
+			continue
+		}
+
+		deductions = append(deductions, deduction)
+	}
+
+	if err := malformed.err(rows); err != nil {
+		return nil, err
+	}
+
+	return deductions, nil
+}
+
+func (d *deductionRepository) Deactivate(ctx context.Context, id int64) error {
+	tx, err := d.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE deductions SET active = FALSE WHERE id = $1`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := appendAudit(ctx, tx, "deduction.deactivate", "deduction", id, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// listActiveDeductions returns the active deductions by employee in evaluation order
+func listActiveDeductions(ctx context.Context, q queryer) (map[int64][]*entity.Deduction, error) {
+	rows, err := q.QueryContext(ctx, selectDeductions+` WHERE active = TRUE ORDER BY employee_id, id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	deductions := make(map[int64][]*entity.Deduction)
+	var malformed malformedRows
+	for rows.Next() {
+		deduction, err := scanDeduction(rows)
+		if err != nil {
+			malformed.add(err)
+			continue
+		}
+
+		deductions[deduction.EmployeeID] = append(deductions[deduction.EmployeeID], deduction)
+	}
+
+	if err := malformed.err(rows); err != nil {
+		return nil, err
+	}
+
+	return deductions, nil
+}
+
+func scanDeduction(rows *sql.Rows) (*entity.Deduction, error) {
+	deduction := new(entity.Deduction)
+
+	err := rows.Scan(&deduction.ID, &deduction.EmployeeID, &deduction.Name, &deduction.Kind, &deduction.Amount,
+		&deduction.TreasuryAddr, &deduction.Active, &deduction.CreateAt)
+	if err != nil {
+		return nil, &repository.RowError{Table: "deductions", ID: deduction.ID, Err: err}
+	}
+
+	return deduction, nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// diagnosed are the tables Diagnose reads, with the query and scan function
+// of the repositories
+var diagnosed = []struct {
+	query string
+	scan  func(rows *sql.Rows) error
+}{
+	{selectEmployees, func(rows *sql.Rows) error {
+		_, err := scanEmployee(rows)
+		return err
+	}},
+	{`SELECT ` + salaryColumns + ` FROM salaries ORDER BY id`, func(rows *sql.Rows) error {
+		_, err := scanSalary(rows)
+		return err
+	}},
+	{`SELECT ` + paymentColumns + ` FROM payments ORDER BY id`, func(rows *sql.Rows) error {
+		_, err := scanPayment(rows)
+		return err
+	}},
+	{selectDeductions + ` ORDER BY id`, func(rows *sql.Rows) error {
+		_, err := scanDeduction(rows)
+		return err
+	}},
+}
+
+// Diagnose reads every employee, salary, payment and deduction as River does
+// and returns the rows that cannot be read, which make payroll runs fail
+// until they are repaired
+func Diagnose(ctx context.Context, db *sql.DB) ([]*repository.RowError, error) {
+	var malformed malformedRows
+
+	for _, table := range diagnosed {
+		err := diagnoseTable(ctx, db, table.query, table.scan, &malformed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return malformed, nil
+}
+
+func diagnoseTable(ctx context.Context, db *sql.DB, query string, scan func(rows *sql.Rows) error, malformed *malformedRows) error {
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			malformed.add(err)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	dbDriver := newSQLiteTestDB(t)
+	ctx := context.Background()
+
+	_, err := dbDriver.Exec(`
+		INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000), ('bob', '0x02', 2000);
+		INSERT INTO payments (employee_id, amount, status, addr) VALUES (1, 500, 'created', '0x01');
+		INSERT INTO deductions (employee_id, name, kind, amount) VALUES (1, 'tax', 'percent', 1000)`)
+	require.NoError(t, err)
+
+	malformed, err := Diagnose(ctx, dbDriver)
+	require.NoError(t, err)
+	assert.Empty(t, malformed)
+
+	_, err = dbDriver.Exec(`
+		UPDATE employers SET amount_salary = 'lots' WHERE id = 2;
+		UPDATE payments SET status = NULL WHERE id = 1;
+		UPDATE deductions SET amount = '10%' WHERE id = 1`)
+	require.NoError(t, err)
+
+	malformed, err = Diagnose(ctx, dbDriver)
+	require.NoError(t, err)
+	require.Len(t, malformed, 3)
+
+	var tables []string
+	for _, row := range malformed {
+		tables = append(tables, row.Table)
+		assert.NotZero(t, row.ID)
+		assert.Error(t, row.Err)
+	}
+	assert.Equal(t, []string{"employers", "payments", "deductions"}, tables)
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Dialect is the SQL database River stores its data in. The repositories
+// use SQL both understand; the schema and its catalog differ.
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// Open opens the Postgres database at url, or the SQLite database at path
+// without one. A new SQLite database file is readable by the owner only.
+func Open(url, path string) (*sql.DB, error) {
+	if url != "" {
+		return sql.Open("postgres", url)
+	}
+
+	if path != "" && path != ":memory:" && !strings.HasPrefix(path, "file:") {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			err = file.Close()
+		}
+		if err != nil && !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+	}
+	return sql.Open("sqlite3", path)
+}
+
+// dialectOf returns the dialect of the database's driver
+func dialectOf(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(*pq.Driver); ok {
+		return Postgres
+	}
+	return SQLite
+}
+
+// tablesQuery counts the tables other than those of the database itself and
+// schema_version
+func (d Dialect) tablesQuery() string {
+	if d == Postgres {
+		return `SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name != 'schema_version'`
+	}
+	return `SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'table' AND name NOT IN ('schema_version', 'sqlite_sequence')`
+}
+
+// tableQuery counts the tables named $1
+func (d Dialect) tableQuery() string {
+	if d == Postgres {
+		return `SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name = $1`
+	}
+	return `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1`
+}
+
+// columnQuery counts the columns of table $1 named $2
+func (d Dialect) columnQuery() string {
+	if d == Postgres {
+		return `SELECT COUNT(*) FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`
+	}
+	return `SELECT COUNT(*) FROM pragma_table_info($1) WHERE name = $2`
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/audit"
+)
+
+// openPostgresTestDB opens the Postgres database at rawURL in a new schema,
+// dropped after the test, so that tests do not see each other's rows
+func openPostgresTestDB(t *testing.T, rawURL string) *sql.DB {
+	t.Helper()
+
+	admin, err := sql.Open("postgres", rawURL)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = admin.Close()
+	})
+
+	schema := fmt.Sprintf("river_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec(`CREATE SCHEMA ` + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+	})
+
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	dbDriver, err := Open(u.String(), "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = dbDriver.Close()
+	})
+
+	return dbDriver
+}
+
+func TestOpen(t *testing.T) {
+	sqlite, err := Open("", ":memory:")
+	require.NoError(t, err)
+	defer sqlite.Close()
+	assert.Equal(t, SQLite, dialectOf(sqlite))
+
+	// opening does not connect yet
+	postgres, err := Open("postgres://river@localhost/river", "ignored.db")
+	require.NoError(t, err)
+	defer postgres.Close()
+	assert.Equal(t, Postgres, dialectOf(postgres))
+
+	assert.ErrorIs(t, Backup(context.Background(), postgres, "river.bak", nil), ErrBackupUnsupported)
+
+	// a new database file is readable by the owner only
+	path := filepath.Join(t.TempDir(), "main.db")
+	file, err := Open("", path)
+	require.NoError(t, err)
+	defer file.Close()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestPostgres_AuditConcurrently(t *testing.T) {
+	rawURL := os.Getenv("RIVER_TEST_DATABASE_URL")
+	if rawURL == "" {
+		t.Skip("RIVER_TEST_DATABASE_URL is not set")
+	}
+
+	dbDriver := newTestDB(t)
+	ctx := audit.WithActor(context.Background(), "alice@host")
+	log := NewAuditRepository(dbDriver)
+
+	// transactions appending at once are sealed one after the other
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- log.Append(ctx, "config.load", "config", 0, map[string]interface{}{"run": i})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	entries, err := log.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 20)
+
+	report := audit.Verify(entries)
+	require.NoError(t, report.Err())
+	assert.Equal(t, 20, report.Sealed)
+}
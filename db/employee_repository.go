@@ -3,22 +3,132 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"gitlab.midas.dev/back/river/internal/entity"
 	"gitlab.midas.dev/back/river/internal/repository"
 )
 
-func NewEmployeeRepository(db *sql.DB) repository.EmployeeRepository {
-	return &employeeRepositorySQLLite{db: db}
+func NewEmployeeRepository(db *sql.DB, fields *Fields) repository.EmployeeRepository {
+	return &employeeRepository{db: db, fields: fields}
 }
 
-type employeeRepositorySQLLite struct {
-	db *sql.DB
+type employeeRepository struct {
+	db     *sql.DB
+	fields *Fields
 }
 
-func (e *employeeRepositorySQLLite) List(ctx context.Context) ([]*entity.Employee, error) {
-	rows, err := e.db.QueryContext(ctx, `
-		SELECT id, name, addr, amount_salary FROM employers;`)
+const selectEmployees = `
+		SELECT id, name, addr, amount_salary, status, start_date, end_date FROM employers;`
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (e *employeeRepository) List(ctx context.Context) ([]*entity.Employee, error) {
+	emps, err := listEmployees(ctx, e.db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, emp := range emps {
+		if err := e.fields.openEmployee(emp); err != nil {
+			return nil, err
+		}
+	}
+
+	return emps, nil
+}
+
+// Add inserts an employee, encrypting their name and address if the
+// database is encrypted
+func (e *employeeRepository) Add(ctx context.Context, emp *entity.Employee) (int64, error) {
+	name, err := e.fields.seal("name", emp.Name)
+	if err != nil {
+		return 0, err
+	}
+	addr, err := e.fields.seal("addr", emp.Addr)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := e.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var startDate sql.NullTime
+	if emp.StartDate != nil {
+		startDate = sql.NullTime{Time: *emp.StartDate, Valid: true}
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO employers (name, addr, amount_salary, start_date) VALUES ($1, $2, $3, $4) RETURNING id`,
+		name, addr, emp.SalaryAmount, startDate).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// the change is recorded by the trigger on employers
+	if err := sealAudit(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// Rename changes the name of an employee, encrypting it if the database is
+// encrypted
+func (e *employeeRepository) Rename(ctx context.Context, id int64, name string) error {
+	name, err := e.fields.seal("name", name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := e.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE employers SET name = $1 WHERE id = $2`, name, id)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %d", repository.ErrEmployeeNotFound, id)
+	}
+
+	if err := sealAudit(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// listEmployees loads every employee row for payroll generation, failing if
+// any of them cannot be read
+func listEmployees(ctx context.Context, q queryer) ([]*entity.Employee, error) {
+	rows, err := q.QueryContext(ctx, selectEmployees)
 
 	if err != nil {
 		return nil, err
@@ -29,15 +139,39 @@ func (e *employeeRepositorySQLLite) List(ctx context.Context) ([]*entity.Employe
 	}()
 
 	emps := make([]*entity.Employee, 0)
+	var malformed malformedRows
 	for rows.Next() {
-		emp := new(entity.Employee)
-		err = rows.Scan(&emp.ID, &emp.Name, &emp.Addr, &emp.SalaryAmount)
-
+		emp, err := scanEmployee(rows)
 		if err != nil {
+			malformed.add(err)
 			continue
 		}
 
 		emps = append(emps, emp)
 	}
-	return emps, err
+
+	if err := malformed.err(rows); err != nil {
+		return nil, err
+	}
+
+	return emps, nil
+}
+
+func scanEmployee(rows *sql.Rows) (*entity.Employee, error) {
+	emp := new(entity.Employee)
+
+	var startDate, endDate sql.NullTime
+	err := rows.Scan(&emp.ID, &emp.Name, &emp.Addr, &emp.SalaryAmount, &emp.Status, &startDate, &endDate)
+	if err != nil {
+		return nil, &repository.RowError{Table: "employers", ID: emp.ID, Err: err}
+	}
+
+	if startDate.Valid {
+		emp.StartDate = &startDate.Time
+	}
+	if endDate.Valid {
+		emp.EndDate = &endDate.Time
+	}
+
+	return emp, nil
 }
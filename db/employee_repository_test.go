@@ -1,12 +1,71 @@
 package db
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
 )
 
 func TestEmployeeRepository(t *testing.T) {
 	// This is a placeholder test. In a real implementation, we would test the actual logic.
 	assert.True(t, true)
 }
+
+func TestEmployeeRepository_List(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+
+	_, err := dbDriver.Exec(`
+		INSERT INTO employers (name, addr, amount_salary, status, start_date) VALUES
+			('alice', '0x01', 1000, 'active', NULL),
+			('bob', '0x02', 2000, 'on_leave', '2026-10-01')`)
+	require.NoError(t, err)
+
+	employees, err := NewEmployeeRepository(dbDriver, nil).List(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 2)
+	assert.Equal(t, "alice", employees[0].Name)
+	assert.Nil(t, employees[0].StartDate)
+	assert.Equal(t, 2000, employees[1].SalaryAmount)
+	assert.Equal(t, "on_leave", employees[1].Status)
+	require.NotNil(t, employees[1].StartDate)
+	assert.Equal(t, time.October, employees[1].StartDate.Month())
+}
+
+func TestEmployeeRepository_MalformedRows(t *testing.T) {
+	dbDriver := newSQLiteTestDB(t)
+	ctx := context.Background()
+
+	_, err := dbDriver.Exec(`
+		INSERT INTO employers (name, addr, amount_salary) VALUES
+			('alice', '0x01', 1000), ('bob', '0x02', 'lots'), ('carol', '0x03', 3000), ('dave', '0x04', '1k')`)
+	require.NoError(t, err)
+
+	// every malformed row is listed, and no employee is returned
+	employees, err := NewEmployeeRepository(dbDriver, nil).List(ctx)
+	assert.Nil(t, employees)
+	assert.ErrorIs(t, err, repository.ErrMalformedRows)
+
+	var scanErr *repository.ScanError
+	require.ErrorAs(t, err, &scanErr)
+	require.Len(t, scanErr.Rows, 2)
+	assert.Equal(t, "employers", scanErr.Rows[0].Table)
+	assert.Equal(t, int64(2), scanErr.Rows[0].ID)
+	assert.Equal(t, int64(4), scanErr.Rows[1].ID)
+	assert.Contains(t, err.Error(), "2 malformed row(s)")
+
+	// a payroll run is not created without bob
+	salaries := NewSalaryRepository(dbDriver, nil)
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	err = salaries.Create(ctx, repository.CreateSalaryParams{Period: period, Schedule: string(payroll.Monthly)})
+	assert.ErrorIs(t, err, repository.ErrMalformedRows)
+
+	created, err := salaries.ListByStatus(ctx, repository.CreatedStatus)
+	require.NoError(t, err)
+	assert.Empty(t, created)
+}
@@ -0,0 +1,312 @@
+package db
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// ErrFieldsEncrypted is returned when reading the employees of an encrypted
+// database without its key
+var ErrFieldsEncrypted = errors.New("employee names and addresses are encrypted, set DATABASE_KEY or DATABASE_KEY_FILE")
+
+// ErrFieldKey is returned when the key is not the one the database was
+// encrypted with
+var ErrFieldKey = errors.New("DATABASE_KEY is not the key the database was encrypted with")
+
+// fieldPrefix starts the stored values of encrypted fields
+const fieldPrefix = "enc1:"
+
+// Fields encrypts the names and addresses of employees, and the copies of
+// them in payments, address changes and the audit log, with AES-256-GCM.
+// Repositories store them encrypted and return them decrypted. A nil Fields
+// stores them in plain text, as in databases not encrypted with
+// EncryptFields.
+type Fields struct {
+	aead cipher.AEAD
+	// check is stored in the database to recognize the key
+	check string
+}
+
+// newFields derives the key of the fields from the database key, so that
+// the key encrypting backups does not encrypt anything else
+func newFields(key []byte) (*Fields, error) {
+	if len(key) != 32 {
+		return nil, errors.New("the database key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(derive(key, "river fields"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Fields{aead: aead, check: hex.EncodeToString(derive(key, "river fields check"))}, nil
+}
+
+func derive(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// LoadFields returns the Fields of a database encrypted with EncryptFields,
+// failing without the key it was encrypted with, and nil for databases that
+// are not encrypted
+func LoadFields(ctx context.Context, db *sql.DB, key []byte) (*Fields, error) {
+	check, err := fieldKeyCheck(ctx, db)
+	if err != nil || check == "" {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrFieldsEncrypted
+	}
+
+	fields, err := newFields(key)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(check), []byte(fields.check)) {
+		return nil, ErrFieldKey
+	}
+	return fields, nil
+}
+
+// fieldKeyCheck returns the check of the key a database is encrypted with,
+// nothing if it is not
+func fieldKeyCheck(ctx context.Context, db *sql.DB) (string, error) {
+	var count int
+	err := db.QueryRowContext(ctx, dialectOf(db).tableQuery(), "field_encryption").Scan(&count)
+	if err != nil || count == 0 {
+		return "", err
+	}
+
+	var check string
+	err = db.QueryRowContext(ctx, `SELECT key_check FROM field_encryption`).Scan(&check)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return check, err
+}
+
+// seal encrypts the value of a field of kind, name or addr. Values of one
+// kind are copied between tables, so the kind is what they are bound to.
+func (f *Fields) seal(kind, value string) (string, error) {
+	if f == nil || strings.HasPrefix(value, fieldPrefix) {
+		return value, nil
+	}
+
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := f.aead.Seal(nonce, nonce, []byte(value), []byte(kind))
+	return fieldPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts what seal encrypted, and returns plain text values as they are
+func (f *Fields) open(kind, value string) (string, error) {
+	if !strings.HasPrefix(value, fieldPrefix) {
+		return value, nil
+	}
+	if f == nil {
+		return "", ErrFieldsEncrypted
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, fieldPrefix))
+	if err != nil || len(sealed) < f.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted %s", kind)
+	}
+	plain, err := f.aead.Open(nil, sealed[:f.aead.NonceSize()], sealed[f.aead.NonceSize():], []byte(kind))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: it was altered, or encrypted with another key", kind)
+	}
+	return string(plain), nil
+}
+
+// openEmployee decrypts the name and address of an employee
+func (f *Fields) openEmployee(emp *entity.Employee) (err error) {
+	if emp.Name, err = f.open("name", emp.Name); err != nil {
+		return &repository.RowError{Table: "employers", ID: emp.ID, Err: err}
+	}
+	if emp.Addr, err = f.open("addr", emp.Addr); err != nil {
+		return &repository.RowError{Table: "employers", ID: emp.ID, Err: err}
+	}
+	return nil
+}
+
+// openPayments decrypts the addresses of payments
+func (f *Fields) openPayments(payments []*entity.Payment) (err error) {
+	for _, payment := range payments {
+		if payment.Addr, err = f.open("addr", payment.Addr); err != nil {
+			return &repository.RowError{Table: "payments", ID: payment.ID, Err: err}
+		}
+	}
+	return nil
+}
+
+// openAddressChange decrypts the addresses of an address change
+func (f *Fields) openAddressChange(change *entity.AddressChange) (err error) {
+	if change.OldAddr, err = f.open("addr", change.OldAddr); err != nil {
+		return &repository.RowError{Table: "address_changes", ID: change.ID, Err: err}
+	}
+	if change.NewAddr, err = f.open("addr", change.NewAddr); err != nil {
+		return &repository.RowError{Table: "address_changes", ID: change.ID, Err: err}
+	}
+	return nil
+}
+
+// EncryptFields encrypts the names and addresses of employees with key, and
+// their copies in payments and address changes, in a single transaction.
+// From then on the database refuses them in plain text, and River needs the
+// key to read them. Audit log entries are sealed and never rewritten, so
+// those recorded before keep them as they were, and later ones record them
+// encrypted.
+func EncryptFields(ctx context.Context, db *sql.DB, key []byte) error {
+	fields, err := newFields(key)
+	if err != nil {
+		return err
+	}
+
+	check, err := fieldKeyCheck(ctx, db)
+	if err != nil {
+		return err
+	}
+	if check != "" {
+		return errors.New("the database is encrypted already")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var lastID int64
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM audit_log`).Scan(&lastID)
+
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]interface{})
+	for _, table := range []struct {
+		name    string
+		columns []string
+	}{
+		{"employers", []string{"name", "addr"}},
+		{"payments", []string{"addr"}},
+		{"address_changes", []string{"old_addr", "new_addr"}},
+	} {
+		n, err := encryptTable(ctx, tx, fields, table.name, table.columns)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", table.name, err)
+		}
+		counts[table.name] = n
+	}
+
+	// encrypting employees changed nothing the audit log should record, so
+	// the entries their triggers just recorded are dropped before sealing
+	_, err = tx.ExecContext(ctx, `DELETE FROM audit_log WHERE id > $1 AND hash IS NULL`, lastID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO field_encryption (id, key_check) VALUES (1, $1)`, fields.check)
+
+	if err != nil {
+		return err
+	}
+
+	if err := appendAudit(ctx, tx, "database.encrypt", "database", 0, map[string]interface{}{"encrypted": counts}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// encryptTable encrypts the columns of every row of a table, returning how
+// many rows it has
+func encryptTable(ctx context.Context, tx *sql.Tx, fields *Fields, table string, columns []string) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, `+strings.Join(columns, ", ")+` FROM `+table+` ORDER BY id`)
+
+	if err != nil {
+		return 0, err
+	}
+
+	type row struct {
+		id     int64
+		values []string
+	}
+	var all []row
+	for rows.Next() {
+		r := row{values: make([]string, len(columns))}
+		dest := []interface{}{&r.id}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		all = append(all, r)
+	}
+
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	for _, r := range all {
+		args := make([]interface{}, 0, len(columns)+1)
+		for i, column := range columns {
+			sealed, err := fields.seal(fieldKind(column), r.values[i])
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, sealed)
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d`, table, strings.Join(set, ", "), len(columns)+1),
+			append(args, r.id)...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(all), nil
+}
+
+// fieldKind returns the kind of the values of an encrypted column
+func fieldKind(column string) string {
+	if column == "name" {
+		return "name"
+	}
+	return "addr"
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/audit"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestFields_SealOpen(t *testing.T) {
+	fields, err := newFields(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+
+	sealed, err := fields.seal("addr", "0xa11ce")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, fieldPrefix))
+	again, err := fields.seal("addr", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, sealed, again, "sealed values are not sealed twice")
+
+	plain, err := fields.open("addr", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "0xa11ce", plain)
+
+	// values are bound to their kind, and to the key
+	_, err = fields.open("name", sealed)
+	assert.Error(t, err)
+	other, err := newFields(bytes.Repeat([]byte{8}, 32))
+	require.NoError(t, err)
+	_, err = other.open("addr", sealed)
+	assert.Error(t, err)
+
+	// plain text is read as it is, sealed values need the key
+	plain, err = fields.open("addr", "0xa11ce")
+	require.NoError(t, err)
+	assert.Equal(t, "0xa11ce", plain)
+	_, err = (*Fields)(nil).open("addr", sealed)
+	assert.ErrorIs(t, err, ErrFieldsEncrypted)
+}
+
+func TestEncryptFields(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{7}, 32)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0xa11ce', 1000)`)
+	require.NoError(t, err)
+
+	requested := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	_, err = NewAddressChangeRepository(dbDriver, nil).Request(ctx, &entity.AddressChange{
+		EmployeeID: 1, NewAddr: "0xb0b", RequestedBy: "carol", RequestedAt: requested, EffectiveAt: requested,
+	})
+	require.NoError(t, err)
+	require.NoError(t, NewSalaryRepository(dbDriver, nil).Create(ctx, repository.CreateSalaryParams{
+		Period: payroll.MonthPeriod(requested), Schedule: string(payroll.Monthly), Now: requested.Add(time.Hour),
+	}))
+
+	fields, err := LoadFields(ctx, dbDriver, key)
+	require.NoError(t, err)
+	assert.Nil(t, fields, "the database is not encrypted yet")
+	before, err := NewAuditRepository(dbDriver).List(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, EncryptFields(ctx, dbDriver, key))
+	assert.Error(t, EncryptFields(ctx, dbDriver, key), "the database is encrypted already")
+
+	// nothing is left in plain text
+	for _, query := range []string{
+		`SELECT name || addr FROM employers`,
+		`SELECT addr FROM payments`,
+		`SELECT old_addr || new_addr FROM address_changes`,
+	} {
+		var value string
+		require.NoError(t, dbDriver.QueryRow(query).Scan(&value))
+		assert.True(t, strings.HasPrefix(value, fieldPrefix), query)
+		assert.NotContains(t, value, "a11ce", query)
+	}
+
+	// the audit log is left as it was, so that it still verifies against
+	// anchors kept before
+	entries, err := NewAuditRepository(dbDriver).List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, len(before)+1)
+	for i, entry := range before {
+		assert.Equal(t, entry, entries[i])
+	}
+	report := audit.Verify(entries)
+	assert.NoError(t, report.Err())
+	assert.Zero(t, report.Unsealed)
+	assert.Equal(t, "database.encrypt", entries[len(entries)-1].Action)
+
+	// River needs the key it was encrypted with
+	_, err = LoadFields(ctx, dbDriver, nil)
+	assert.ErrorIs(t, err, ErrFieldsEncrypted)
+	_, err = LoadFields(ctx, dbDriver, bytes.Repeat([]byte{8}, 32))
+	assert.ErrorIs(t, err, ErrFieldKey)
+	fields, err = LoadFields(ctx, dbDriver, key)
+	require.NoError(t, err)
+	require.NotNil(t, fields)
+
+	_, err = NewEmployeeRepository(dbDriver, nil).List(ctx)
+	assert.ErrorIs(t, err, ErrFieldsEncrypted)
+
+	// repositories read them decrypted
+	employees := NewEmployeeRepository(dbDriver, fields)
+	list, err := employees.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "alice", list[0].Name)
+	assert.Equal(t, "0xb0b", list[0].Addr, "the address change was applied")
+
+	payments, err := NewSalaryRepository(dbDriver, fields).ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, "0xb0b", payments[0].Addr)
+
+	change, err := NewAddressChangeRepository(dbDriver, fields).Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "0xa11ce", change.OldAddr)
+	assert.Equal(t, "0xb0b", change.NewAddr)
+
+	records, err := NewReportRepository(dbDriver, fields).EmployeeHistory(ctx, 1, time.Time{}, requested.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "alice", records[0].Employee)
+
+	// plain text is refused, River encrypts what it writes
+	_, err = dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('dave', '0xd0d', 1000)`)
+	assert.Error(t, err)
+	_, err = dbDriver.Exec(`UPDATE employers SET name = 'mallory' WHERE id = 1`)
+	assert.Error(t, err)
+
+	id, err := employees.Add(ctx, &entity.Employee{Name: "dave", Addr: "0xd0d", SalaryAmount: 2000})
+	require.NoError(t, err)
+	require.NoError(t, employees.Rename(ctx, 1, "alice smith"))
+	assert.ErrorIs(t, employees.Rename(ctx, 99, "nobody"), repository.ErrEmployeeNotFound)
+
+	list, err = employees.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "alice smith", list[0].Name)
+	assert.Equal(t, id, list[1].ID)
+	assert.Equal(t, "0xd0d", list[1].Addr)
+
+	// later entries record them encrypted
+	_, err = NewAddressChangeRepository(dbDriver, fields).Request(ctx, &entity.AddressChange{
+		EmployeeID: 2, NewAddr: "0xe7e", RequestedBy: "carol", RequestedAt: requested, EffectiveAt: requested,
+	})
+	require.NoError(t, err)
+
+	entries, err = NewAuditRepository(dbDriver).List(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, audit.Verify(entries).Err())
+	for _, entry := range entries[len(before):] {
+		for _, plain := range []string{`"alice`, `"dave"`, `"0xd0d"`, `"0xe7e"`, `"0xb0b"`} {
+			assert.NotContains(t, entry.Details, plain, entry.Action)
+		}
+	}
+}
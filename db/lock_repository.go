@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func NewLockRepository(db *sql.DB) repository.LockRepository {
+	return &lockRepository{db: db, now: time.Now}
+}
+
+type lockRepository struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func (l *lockRepository) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	// Timestamps are stored in UTC with second precision so that they compare
+	// correctly as text in SQLite.
+	now := l.now().UTC().Truncate(time.Second)
+
+	res, err := l.db.ExecContext(ctx, `
+		INSERT INTO locks (name, owner, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+		WHERE locks.owner = excluded.owner OR locks.expires_at < $4
+	`, name, owner, now.Add(ttl), now)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (l *lockRepository) Release(ctx context.Context, name, owner string) error {
+	_, err := l.db.ExecContext(ctx, `
+		DELETE FROM locks WHERE name = $1 AND owner = $2`, name, owner)
+
+	return err
+}
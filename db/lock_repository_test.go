@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+
+	repo := &lockRepository{db: newTestDB(t), now: func() time.Time { return now }}
+
+	ok, err := repo.Acquire(ctx, "payroll", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// held by a, renewable only by a
+	ok, err = repo.Acquire(ctx, "payroll", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = repo.Acquire(ctx, "payroll", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// other lock names are independent
+	ok, err = repo.Acquire(ctx, "other", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// an expired lock can be taken over
+	now = now.Add(2 * time.Minute)
+	ok, err = repo.Acquire(ctx, "payroll", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// releasing someone else's lock is a no-op
+	require.NoError(t, repo.Release(ctx, "payroll", "a"))
+	ok, err = repo.Acquire(ctx, "payroll", "a", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, repo.Release(ctx, "payroll", "b"))
+	ok, err = repo.Acquire(ctx, "payroll", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
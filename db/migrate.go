@@ -0,0 +1,412 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+var (
+	// ErrSchemaOutdated is returned when migrations are pending
+	ErrSchemaOutdated = errors.New("database schema is outdated, run `river db migrate`")
+	// ErrSchemaTooNew is returned for databases migrated by a newer River
+	ErrSchemaTooNew = errors.New("database schema is newer than this River")
+)
+
+var (
+	migrationName = regexp.MustCompile(`^(\d{4})_(\w+)\.(up|down)\.sql$`)
+	addColumn     = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+(\w+)`)
+)
+
+// Migration is a numbered change of the schema and the statements undoing it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, if it was
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations embedded in River to a database
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	now        func() time.Time
+}
+
+// NewMigrator creates a migrator of the database, applying the migrations of
+// its dialect
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	dialect := dialectOf(db)
+	migrations, err := loadMigrations(migrationFiles, path.Join("migrations", string(dialect)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations, now: time.Now}, nil
+}
+
+// Latest returns the version of the schema River uses
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version of the database schema, 0 if no migration was
+// ever applied
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if err := m.init(ctx); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := m.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
+
+// Empty reports whether the database has no table yet
+func (m *Migrator) Empty(ctx context.Context) (bool, error) {
+	var count int
+	err := m.db.QueryRowContext(ctx, m.dialect.tablesQuery()).Scan(&count)
+
+	return count == 0, err
+}
+
+// Check returns an error unless the database schema is the one River uses
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version < m.Latest():
+		return fmt.Errorf("%w: version %d, River needs %d", ErrSchemaOutdated, version, m.Latest())
+	case version > m.Latest():
+		return fmt.Errorf("%w: version %d, River knows up to %d", ErrSchemaTooNew, version, m.Latest())
+	}
+	return nil
+}
+
+// Status returns every migration with the time it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s := MigrationStatus{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+
+	return status, nil
+}
+
+// Pending returns the migrations not applied yet, in order
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations, each in a transaction of its own,
+// and returns them. Databases created before migrations existed hold some of
+// the columns added later, which are skipped, so that they are brought up to
+// date too.
+func (m *Migrator) Migrate(ctx context.Context) ([]Migration, error) {
+	if err := m.Check(ctx); err != nil && !errors.Is(err, ErrSchemaOutdated) {
+		return nil, err
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// a database created from scratch has no history to audit
+	audited, err := m.tableExists(ctx, m.db, "audit_log")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err := m.apply(ctx, migration.Version, migration.Up, "schema.migrate", audited, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO schema_version (version, name, applied_at) VALUES ($1, $2, $3)`,
+				migration.Version, migration.Name, utcSecond(m.now()))
+			return err
+		})
+
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// Rollback undoes the last steps applied migrations and returns them, the
+// last one first
+func (m *Migrator) Rollback(ctx context.Context, steps int) ([]Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version > m.Latest() {
+		return nil, fmt.Errorf("%w: version %d, River knows up to %d", ErrSchemaTooNew, version, m.Latest())
+	}
+
+	var undone []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(undone) < steps; i-- {
+		migration := m.migrations[i]
+		if migration.Version > version {
+			continue
+		}
+
+		err := m.apply(ctx, migration.Version, migration.Down, "schema.rollback", true, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_version WHERE version = $1`, migration.Version)
+			return err
+		})
+
+		if err != nil {
+			return undone, fmt.Errorf("rollback %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		undone = append(undone, migration)
+	}
+
+	return undone, nil
+}
+
+// apply runs the statements of a migration and record in a single
+// transaction, recording action in the audit log if audited and the log
+// still exists
+func (m *Migrator) apply(ctx context.Context, version int, script, action string, audited bool, record func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, stmt := range statements(script) {
+		if match := addColumn.FindStringSubmatch(stmt); match != nil {
+			exists, err := m.columnExists(ctx, tx, match[1], match[2])
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	if audited {
+		exists, err := m.tableExists(ctx, tx, "audit_log")
+		if err != nil {
+			return err
+		}
+		if exists {
+			err = appendAudit(ctx, tx, action, "schema", int64(version), nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// init creates the table recording the applied migrations
+func (m *Migrator) init(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`)
+	return err
+}
+
+// rowQueryer is satisfied by both *sql.DB and *sql.Tx
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (m *Migrator) tableExists(ctx context.Context, q rowQueryer, name string) (bool, error) {
+	var count int
+	err := q.QueryRowContext(ctx, m.dialect.tableQuery(), name).Scan(&count)
+
+	return count > 0, err
+}
+
+func (m *Migrator) columnExists(ctx context.Context, q rowQueryer, table, column string) (bool, error) {
+	var count int
+	err := q.QueryRowContext(ctx, m.dialect.columnQuery(), table, column).Scan(&count)
+
+	return count > 0, err
+}
+
+// loadMigrations reads the NNNN_name.up.sql and NNNN_name.down.sql files of
+// the migrations in dir, which must be numbered from 1 without gaps
+func loadMigrations(files fs.FS, dir string) ([]Migration, error) {
+	names, err := fs.Glob(files, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, name := range names {
+		match := migrationName.FindStringSubmatch(path.Base(name))
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %04d has two names, %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, migration := range migrations {
+		switch {
+		case migration.Version != i+1:
+			return nil, fmt.Errorf("missing migration %04d", i+1)
+		case strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "":
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down statements", migration.Version, migration.Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+// statements splits a script into its statements, keeping the body of a
+// SQLite trigger, BEGIN ... END, and of a Postgres function, $$ ... $$, with
+// the statement defining it
+func statements(script string) []string {
+	var stmts []string
+	var current []string
+	var block, quoted bool
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if len(current) == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		current = append(current, line)
+
+		if strings.Count(trimmed, "$$")%2 == 1 {
+			quoted = !quoted
+		}
+		if quoted {
+			continue
+		}
+		if strings.EqualFold(trimmed, "BEGIN") {
+			block = true
+		}
+
+		end := strings.HasSuffix(trimmed, ";")
+		if block {
+			end = strings.EqualFold(trimmed, "END;")
+		}
+		if end {
+			stmts = append(stmts, strings.Join(current, "\n"))
+			current, block = nil, false
+		}
+	}
+
+	if len(current) > 0 {
+		stmts = append(stmts, strings.Join(current, "\n"))
+	}
+	return stmts
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/audit"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// legacySchema is the schema River created before migrations, as of the
+// deductions release
+const legacySchema = `
+CREATE TABLE employers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    addr TEXT NOT NULL,
+    amount_salary INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    start_date DATE DEFAULT NULL,
+    end_date DATE DEFAULT NULL
+);
+CREATE TABLE payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    salary_id INT,
+    employee_id INT,
+    amount INT,
+    gross_amount INT,
+    deduction_amount INT NOT NULL DEFAULT 0,
+    status VARCHAR(16),
+    addr TEXT NOT NULL,
+    category VARCHAR(16) NOT NULL DEFAULT 'salary',
+    memo TEXT DEFAULT NULL,
+    error TEXT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE salaries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    status VARCHAR(16),
+    period VARCHAR(32),
+    period_start DATE,
+    period_end DATE,
+    schedule VARCHAR(16),
+    forced BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE locks (name VARCHAR(64) PRIMARY KEY, owner TEXT NOT NULL, expires_at TIMESTAMP NOT NULL);
+INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000);
+`
+
+// openTestDB opens the SQLite database at path
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	dbDriver, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+
+	// every connection to :memory: is a separate database
+	dbDriver.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = dbDriver.Close()
+	})
+
+	return dbDriver
+}
+
+// columns returns the columns of every table, as created by SQLite
+func columns(t *testing.T, dbDriver *sql.DB) map[string][]string {
+	t.Helper()
+
+	rows, err := dbDriver.Query(`
+		SELECT m.name, p.name, p.type, p."notnull", COALESCE(p.dflt_value, '')
+		FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type = 'table' AND m.name != 'sqlite_sequence'
+		ORDER BY m.name, p.name`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	tables := make(map[string][]string)
+	for rows.Next() {
+		var table, name, typ, def string
+		var notNull bool
+		require.NoError(t, rows.Scan(&table, &name, &typ, &notNull, &def))
+		tables[table] = append(tables[table], name+" "+typ+" "+def)
+	}
+	require.NoError(t, rows.Err())
+
+	return tables
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	ctx := context.Background()
+	dbDriver := openTestDB(t, ":memory:")
+
+	migrator, err := NewMigrator(dbDriver)
+	require.NoError(t, err)
+
+	empty, err := migrator.Empty(ctx)
+	require.NoError(t, err)
+	assert.True(t, empty)
+	assert.ErrorIs(t, migrator.Check(ctx), ErrSchemaOutdated)
+
+	applied, err := migrator.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, migrator.Latest())
+	require.NoError(t, migrator.Check(ctx))
+
+	// a database created from scratch has no history to audit
+	entries, err := NewAuditRepository(dbDriver).List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	applied, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, migrator.Latest())
+	assert.Equal(t, "baseline", status[0].Name)
+	assert.NotNil(t, status[len(status)-1].AppliedAt)
+
+	_, err = dbDriver.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES ($1, 'future', CURRENT_TIMESTAMP)`,
+		migrator.Latest()+1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(ctx), ErrSchemaTooNew)
+	_, err = migrator.Migrate(ctx)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
+
+func TestMigrator_LegacyDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	fresh := openTestDB(t, ":memory:")
+	migrator, err := NewMigrator(fresh)
+	require.NoError(t, err)
+	_, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+
+	legacy := openTestDB(t, ":memory:")
+	_, err = legacy.Exec(legacySchema)
+	require.NoError(t, err)
+
+	migrator, err = NewMigrator(legacy)
+	require.NoError(t, err)
+
+	empty, err := migrator.Empty(ctx)
+	require.NoError(t, err)
+	assert.False(t, empty)
+
+	_, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, columns(t, fresh), columns(t, legacy))
+
+	employees, err := NewEmployeeRepository(legacy, nil).List(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	assert.Equal(t, "alice", employees[0].Name)
+}
+
+func TestMigrator_Rollback(t *testing.T) {
+	ctx := context.Background()
+	dbDriver := newTestDB(t)
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+	month := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: month, Schedule: string(payroll.Monthly)}))
+
+	migrator, err := NewMigrator(dbDriver)
+	require.NoError(t, err)
+
+	undone, err := migrator.Rollback(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, undone, 1)
+	assert.Equal(t, "audit_sequence", undone[0].Name)
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest()-1, version)
+
+	entries, err := NewAuditRepository(dbDriver).List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "schema.rollback", entries[len(entries)-1].Action)
+	_, err = dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('bob', '0x02', 1000)`)
+	require.NoError(t, err)
+	var unnumbered int
+	require.NoError(t, dbDriver.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE seq IS NULL`).Scan(&unnumbered))
+	assert.Equal(t, 1, unnumbered, "entries are no longer numbered as recorded")
+
+	// the payment survives, and so does the audit log when migrating again
+	_, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+
+	payments, err := repo.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, payments, 1)
+
+	entries, err = NewAuditRepository(dbDriver).List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "schema.migrate", entries[len(entries)-1].Action)
+	assert.NoError(t, audit.Verify(entries).Err())
+
+	// every migration can be undone
+	undone, err = migrator.Rollback(ctx, migrator.Latest())
+	require.NoError(t, err)
+	assert.Len(t, undone, migrator.Latest())
+
+	empty, err := migrator.Empty(ctx)
+	require.NoError(t, err)
+	assert.True(t, empty)
+
+	_, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Check(ctx))
+}
+
+func TestStatements(t *testing.T) {
+	stmts := statements(`
+-- a comment
+CREATE TABLE a (id INT);
+ALTER TABLE a
+    ADD COLUMN b TEXT;
+
+CREATE TRIGGER t AFTER INSERT ON a
+BEGIN
+    INSERT INTO a (id) VALUES (1);
+    INSERT INTO a (id) VALUES (2);
+END;
+
+CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO a (id) VALUES (3);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER u AFTER INSERT ON b FOR EACH ROW EXECUTE FUNCTION f();
+DROP TABLE b;
+`)
+
+	require.Len(t, stmts, 6)
+	assert.Equal(t, "CREATE TABLE a (id INT);", stmts[0])
+	assert.Equal(t, []string{"a", "b"}, addColumn.FindStringSubmatch(stmts[1])[1:])
+	assert.Contains(t, stmts[2], "VALUES (2);\nEND;")
+	assert.Contains(t, stmts[3], "END;\n$$ LANGUAGE plpgsql;")
+	assert.Equal(t, "CREATE TRIGGER u AFTER INSERT ON b FOR EACH ROW EXECUTE FUNCTION f();", stmts[4])
+}
+
+func TestLoadMigrations(t *testing.T) {
+	sqlite, err := loadMigrations(migrationFiles, "migrations/sqlite")
+	require.NoError(t, err)
+	postgres, err := loadMigrations(migrationFiles, "migrations/postgres")
+	require.NoError(t, err)
+
+	// every migration is written for both databases
+	require.Equal(t, len(sqlite), len(postgres))
+	for i := range sqlite {
+		assert.Equal(t, sqlite[i].Name, postgres[i].Name)
+	}
+
+	_, err = loadMigrations(fstest.MapFS{
+		"migrations/0001_baseline.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+		"migrations/0001_baseline.down.sql": {Data: []byte("DROP TABLE a;")},
+		"migrations/0003_gap.up.sql":        {Data: []byte("CREATE TABLE b (id INT);")},
+		"migrations/0003_gap.down.sql":      {Data: []byte("DROP TABLE b;")},
+	}, "migrations")
+	assert.EqualError(t, err, "missing migration 0002")
+
+	_, err = loadMigrations(fstest.MapFS{
+		"migrations/0001_baseline.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+	}, "migrations")
+	assert.EqualError(t, err, "migration 0001_baseline needs both up and down statements")
+}
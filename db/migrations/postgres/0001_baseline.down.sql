@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS salaries;
+DROP TABLE IF EXISTS employers;
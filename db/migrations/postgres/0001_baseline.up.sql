@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS employers (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    addr TEXT NOT NULL,
+    amount_salary BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS salaries (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(16),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id BIGSERIAL PRIMARY KEY,
+    salary_id BIGINT REFERENCES salaries(id),
+    employee_id BIGINT REFERENCES employers(id),
+    amount BIGINT,
+    status VARCHAR(16),
+    addr TEXT NOT NULL,
+    error TEXT DEFAULT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+ALTER TABLE employers DROP COLUMN end_date;
+ALTER TABLE employers DROP COLUMN start_date;
+ALTER TABLE employers DROP COLUMN status;
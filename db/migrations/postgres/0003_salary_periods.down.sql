@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS salaries_period_unique;
+
+ALTER TABLE salaries DROP COLUMN forced;
+ALTER TABLE salaries DROP COLUMN schedule;
+ALTER TABLE salaries DROP COLUMN period_end;
+ALTER TABLE salaries DROP COLUMN period_start;
+ALTER TABLE salaries DROP COLUMN period;
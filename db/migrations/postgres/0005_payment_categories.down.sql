@@ -0,0 +1,2 @@
+ALTER TABLE payments DROP COLUMN memo;
+ALTER TABLE payments DROP COLUMN category;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS deductions;
+
+ALTER TABLE payments DROP COLUMN deduction_amount;
+ALTER TABLE payments DROP COLUMN gross_amount;
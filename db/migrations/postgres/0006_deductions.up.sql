@@ -0,0 +1,13 @@
+ALTER TABLE payments ADD COLUMN gross_amount BIGINT;
+ALTER TABLE payments ADD COLUMN deduction_amount BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS deductions (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employers(id),
+    name TEXT NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    amount BIGINT NOT NULL,
+    treasury_addr TEXT DEFAULT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
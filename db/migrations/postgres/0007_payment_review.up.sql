@@ -0,0 +1 @@
+ALTER TABLE payments ADD COLUMN reviewed BOOLEAN NOT NULL DEFAULT FALSE;
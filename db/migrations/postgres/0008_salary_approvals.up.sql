@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS salary_approvals (
+    id BIGSERIAL PRIMARY KEY,
+    salary_id BIGINT NOT NULL REFERENCES salaries(id),
+    approver TEXT NOT NULL,
+    manifest_hash TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(salary_id, approver, manifest_hash)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS address_changes (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employers(id),
+    old_addr TEXT NOT NULL,
+    new_addr TEXT NOT NULL,
+    requested_by TEXT NOT NULL,
+    reason TEXT DEFAULT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    signature TEXT DEFAULT NULL,
+    requested_at TIMESTAMPTZ NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    verified_at TIMESTAMPTZ DEFAULT NULL,
+    applied_at TIMESTAMPTZ DEFAULT NULL
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS safe_proposals;
+
+ALTER TABLE payments DROP COLUMN tx_hash;
+ALTER TABLE payments DROP COLUMN safe_tx_hash;
@@ -0,0 +1,13 @@
+ALTER TABLE payments ADD COLUMN safe_tx_hash TEXT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN tx_hash TEXT DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS safe_proposals (
+    id BIGSERIAL PRIMARY KEY,
+    salary_id BIGINT NOT NULL REFERENCES salaries(id),
+    safe TEXT NOT NULL,
+    safe_tx_hash TEXT NOT NULL UNIQUE,
+    nonce BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    tx_hash TEXT DEFAULT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS employers_audit_delete ON employers;
+DROP TRIGGER IF EXISTS employers_audit_update ON employers;
+DROP TRIGGER IF EXISTS employers_audit_insert ON employers;
+DROP FUNCTION IF EXISTS employers_audit();
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_lock();
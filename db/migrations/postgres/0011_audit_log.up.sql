@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    seq BIGINT UNIQUE DEFAULT NULL,
+    at TIMESTAMPTZ NOT NULL DEFAULT date_trunc('second', CURRENT_TIMESTAMP),
+    actor TEXT NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    entity VARCHAR(32) NOT NULL,
+    entity_id BIGINT DEFAULT NULL,
+    details TEXT NOT NULL,
+    prev_hash TEXT DEFAULT NULL,
+    hash TEXT DEFAULT NULL
+);
+
+-- entries are sealed in the order they are appended, so transactions
+-- appending to the log wait for each other until they commit
+CREATE OR REPLACE FUNCTION audit_log_lock() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('river.audit_log'));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_lock BEFORE INSERT ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_lock();
+
+-- employees are edited with SQL, so their changes are recorded by triggers
+-- and sealed into the audit log by River's next change
+CREATE OR REPLACE FUNCTION employers_audit() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO audit_log (actor, action, entity, entity_id, details)
+        VALUES ('database', 'employee.insert', 'employee', NEW.id, json_build_object('new', json_build_object('name', NEW.name, 'addr', NEW.addr, 'amount_salary', NEW.amount_salary, 'status', NEW.status, 'start_date', NEW.start_date, 'end_date', NEW.end_date))::text);
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO audit_log (actor, action, entity, entity_id, details)
+        VALUES ('database', 'employee.update', 'employee', NEW.id, json_build_object('old', json_build_object('name', OLD.name, 'addr', OLD.addr, 'amount_salary', OLD.amount_salary, 'status', OLD.status, 'start_date', OLD.start_date, 'end_date', OLD.end_date), 'new', json_build_object('name', NEW.name, 'addr', NEW.addr, 'amount_salary', NEW.amount_salary, 'status', NEW.status, 'start_date', NEW.start_date, 'end_date', NEW.end_date))::text);
+    ELSE
+        INSERT INTO audit_log (actor, action, entity, entity_id, details)
+        VALUES ('database', 'employee.delete', 'employee', OLD.id, json_build_object('old', json_build_object('name', OLD.name, 'addr', OLD.addr, 'amount_salary', OLD.amount_salary, 'status', OLD.status, 'start_date', OLD.start_date, 'end_date', OLD.end_date))::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER employers_audit_insert AFTER INSERT ON employers
+    FOR EACH ROW EXECUTE FUNCTION employers_audit();
+
+CREATE TRIGGER employers_audit_update AFTER UPDATE ON employers
+    FOR EACH ROW EXECUTE FUNCTION employers_audit();
+
+CREATE TRIGGER employers_audit_delete AFTER DELETE ON employers
+    FOR EACH ROW EXECUTE FUNCTION employers_audit();
@@ -0,0 +1,2 @@
+ALTER TABLE payments DROP COLUMN version;
+ALTER TABLE salaries DROP COLUMN version;
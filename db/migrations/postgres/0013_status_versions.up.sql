@@ -0,0 +1,4 @@
+-- every status change increments the version, so that a change can be made
+-- only if the row is still as it was read
+ALTER TABLE salaries ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
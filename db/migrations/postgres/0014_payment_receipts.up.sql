@@ -0,0 +1,6 @@
+-- what paying cost: the token sent, the gas of transactions River sent
+-- itself, and when the payment was done
+ALTER TABLE payments ADD COLUMN token TEXT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN gas_used BIGINT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN gas_fee BIGINT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN paid_at TIMESTAMPTZ DEFAULT NULL;
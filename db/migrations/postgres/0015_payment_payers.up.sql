@@ -0,0 +1,2 @@
+-- the wallet that sent the payment: the River signer, the Safe or the offline account
+ALTER TABLE payments ADD COLUMN payer TEXT DEFAULT NULL;
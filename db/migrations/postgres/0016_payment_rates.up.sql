@@ -0,0 +1,3 @@
+-- the employee's salary for a full period, which the gross amount of a salary
+-- payment was prorated from
+ALTER TABLE payments ADD COLUMN rate BIGINT DEFAULT NULL;
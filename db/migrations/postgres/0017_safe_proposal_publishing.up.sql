@@ -0,0 +1,5 @@
+-- proposals are recorded before they are published: the signed proposal is
+-- kept to publish it again, and the latest block when it was made bounds the
+-- search for its execution on chain
+ALTER TABLE safe_proposals ADD COLUMN block BIGINT DEFAULT NULL;
+ALTER TABLE safe_proposals ADD COLUMN payload TEXT DEFAULT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE payments DROP COLUMN claim_expires_at;
+ALTER TABLE payments DROP COLUMN claimed_by;
+ALTER TABLE salaries DROP COLUMN claim_expires_at;
+ALTER TABLE salaries DROP COLUMN claimed_by;
@@ -0,0 +1,6 @@
+-- the River process that claimed a salary or payment for processing, and
+-- until when no other process may claim it again
+ALTER TABLE salaries ADD COLUMN claimed_by TEXT DEFAULT NULL;
+ALTER TABLE salaries ADD COLUMN claim_expires_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN claimed_by TEXT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN claim_expires_at TIMESTAMPTZ DEFAULT NULL;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS address_changes_encrypted ON address_changes;
+DROP TRIGGER IF EXISTS payments_encrypted ON payments;
+DROP TRIGGER IF EXISTS employers_encrypted ON employers;
+DROP FUNCTION IF EXISTS fields_encrypted();
+DROP TABLE IF EXISTS field_encryption;
@@ -0,0 +1,34 @@
+-- set by "river db encrypt" once the names and addresses of employees, and
+-- their copies, are encrypted with the key key_check recognizes
+CREATE TABLE IF NOT EXISTS field_encryption (
+    id INT PRIMARY KEY CHECK (id = 1),
+    key_check TEXT NOT NULL,
+    encrypted_at TIMESTAMPTZ NOT NULL DEFAULT date_trunc('second', CURRENT_TIMESTAMP)
+);
+
+-- encrypted databases refuse names and addresses in plain text, which
+-- River would pay to or show as they are; the trigger arguments name the
+-- encrypted columns
+CREATE OR REPLACE FUNCTION fields_encrypted() RETURNS trigger AS $$
+DECLARE
+    col TEXT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM field_encryption) THEN
+        FOREACH col IN ARRAY TG_ARGV LOOP
+            IF (to_jsonb(NEW) ->> col) NOT LIKE 'enc1:%' THEN
+                RAISE EXCEPTION '%.% is encrypted, change employees with river employee', TG_TABLE_NAME, col;
+            END IF;
+        END LOOP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER employers_encrypted BEFORE INSERT OR UPDATE OF name, addr ON employers
+    FOR EACH ROW EXECUTE FUNCTION fields_encrypted('name', 'addr');
+
+CREATE TRIGGER payments_encrypted BEFORE INSERT OR UPDATE OF addr ON payments
+    FOR EACH ROW EXECUTE FUNCTION fields_encrypted('addr');
+
+CREATE TRIGGER address_changes_encrypted BEFORE INSERT OR UPDATE OF old_addr, new_addr ON address_changes
+    FOR EACH ROW EXECUTE FUNCTION fields_encrypted('old_addr', 'new_addr');
@@ -0,0 +1,3 @@
+-- the raw transaction River signed to send a payment, recorded with the
+-- claim before it is broadcast
+ALTER TABLE payments ADD COLUMN signed_tx TEXT DEFAULT NULL;
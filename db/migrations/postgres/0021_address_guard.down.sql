@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS employers_address_guard ON employers;
+DROP FUNCTION IF EXISTS employers_address_guard();
@@ -0,0 +1,16 @@
+-- employee addresses only change to the new address of an applied address
+-- change, so that changes made outside river employee address set do not
+-- skip their cooldown; encrypting the address in place is allowed
+CREATE OR REPLACE FUNCTION employers_address_guard() RETURNS trigger AS $$
+BEGIN
+    IF NEW.addr IS DISTINCT FROM OLD.addr
+        AND NOT (OLD.addr NOT LIKE 'enc1:%' AND NEW.addr LIKE 'enc1:%' AND NOT EXISTS (SELECT 1 FROM field_encryption))
+        AND NOT EXISTS (SELECT 1 FROM address_changes WHERE employee_id = NEW.id AND new_addr = NEW.addr AND status = 'applied') THEN
+        RAISE EXCEPTION 'employee addresses change with river employee address set, after their cooldown';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER employers_address_guard BEFORE UPDATE OF addr ON employers
+    FOR EACH ROW EXECUTE FUNCTION employers_address_guard();
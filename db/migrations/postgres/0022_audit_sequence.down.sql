@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS audit_log_sequence ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_sequence();
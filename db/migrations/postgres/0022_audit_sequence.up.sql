@@ -0,0 +1,19 @@
+-- entries are numbered as they are recorded, those of the employers
+-- triggers included, so that deleting one before River seals it leaves a
+-- gap in the sequence; audit_log_lock runs first, so that concurrent
+-- transactions number their entries one after the other
+UPDATE audit_log SET seq = (SELECT COALESCE(MAX(seq), 0) FROM audit_log WHERE hash IS NOT NULL)
+    + (SELECT COUNT(*) FROM audit_log a WHERE a.hash IS NULL AND a.id <= audit_log.id)
+WHERE hash IS NULL;
+
+CREATE OR REPLACE FUNCTION audit_log_sequence() RETURNS trigger AS $$
+BEGIN
+    IF NEW.seq IS NULL THEN
+        NEW.seq := (SELECT COALESCE(MAX(seq), 0) + 1 FROM audit_log);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_sequence BEFORE INSERT ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_sequence();
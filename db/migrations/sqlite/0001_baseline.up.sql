@@ -1,16 +1,17 @@
 CREATE TABLE IF NOT EXISTS employers (
-    id BIGINT PRIMARY KEY AUTOINCREMENT,
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL,
     addr TEXT NOT NULL,
     amount_salary INT NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS payments (
-    id BIGINT PRIMARY KEY AUTOINCREMENT,
-    salary_id BIGINT,
-    employee_id BIGINT,
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    salary_id INT,
+    employee_id INT,
     amount INT,
     status VARCHAR(16),
+    addr TEXT NOT NULL,
     error TEXT DEFAULT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY(salary_id) REFERENCES salaries(id),
@@ -18,8 +19,7 @@ CREATE TABLE IF NOT EXISTS payments (
 );
 
 CREATE TABLE IF NOT EXISTS salaries (
-    id BIGINT PIMARY KEY AUTOINCREMENT,
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
     status VARCHAR(16),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-)
-
+);
@@ -0,0 +1,3 @@
+ALTER TABLE employers ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active';
+ALTER TABLE employers ADD COLUMN start_date DATE DEFAULT NULL;
+ALTER TABLE employers ADD COLUMN end_date DATE DEFAULT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE salaries ADD COLUMN period VARCHAR(32);
+ALTER TABLE salaries ADD COLUMN period_start DATE;
+ALTER TABLE salaries ADD COLUMN period_end DATE;
+ALTER TABLE salaries ADD COLUMN schedule VARCHAR(16);
+ALTER TABLE salaries ADD COLUMN forced BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS salaries_period_unique ON salaries (period) WHERE forced = FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE payments ADD COLUMN category VARCHAR(16) NOT NULL DEFAULT 'salary';
+ALTER TABLE payments ADD COLUMN memo TEXT DEFAULT NULL;
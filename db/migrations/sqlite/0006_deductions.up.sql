@@ -0,0 +1,14 @@
+ALTER TABLE payments ADD COLUMN gross_amount INT;
+ALTER TABLE payments ADD COLUMN deduction_amount INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS deductions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id INT NOT NULL,
+    name TEXT NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    amount INT NOT NULL,
+    treasury_addr TEXT DEFAULT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(employee_id) REFERENCES employers(id)
+);
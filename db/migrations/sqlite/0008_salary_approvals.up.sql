@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS salary_approvals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    salary_id INT NOT NULL,
+    approver TEXT NOT NULL,
+    manifest_hash TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(salary_id, approver, manifest_hash),
+    FOREIGN KEY(salary_id) REFERENCES salaries(id)
+);
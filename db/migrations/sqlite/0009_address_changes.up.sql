@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS address_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id INT NOT NULL,
+    old_addr TEXT NOT NULL,
+    new_addr TEXT NOT NULL,
+    requested_by TEXT NOT NULL,
+    reason TEXT DEFAULT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    signature TEXT DEFAULT NULL,
+    requested_at TIMESTAMP NOT NULL,
+    effective_at TIMESTAMP NOT NULL,
+    verified_at TIMESTAMP DEFAULT NULL,
+    applied_at TIMESTAMP DEFAULT NULL,
+    FOREIGN KEY(employee_id) REFERENCES employers(id)
+);
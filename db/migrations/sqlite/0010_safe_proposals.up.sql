@@ -0,0 +1,14 @@
+ALTER TABLE payments ADD COLUMN safe_tx_hash TEXT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN tx_hash TEXT DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS safe_proposals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    salary_id INT NOT NULL,
+    safe TEXT NOT NULL,
+    safe_tx_hash TEXT NOT NULL UNIQUE,
+    nonce INT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    tx_hash TEXT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(salary_id) REFERENCES salaries(id)
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS employers_audit_delete;
+DROP TRIGGER IF EXISTS employers_audit_update;
+DROP TRIGGER IF EXISTS employers_audit_insert;
+
+DROP TABLE IF EXISTS audit_log;
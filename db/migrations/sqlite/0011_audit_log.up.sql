@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    seq INT UNIQUE DEFAULT NULL,
+    at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor TEXT NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    entity VARCHAR(32) NOT NULL,
+    entity_id INT DEFAULT NULL,
+    details TEXT NOT NULL,
+    prev_hash TEXT DEFAULT NULL,
+    hash TEXT DEFAULT NULL
+);
+
+-- employees are edited with SQL, so their changes are recorded by triggers
+-- and sealed into the audit log by River's next change
+CREATE TRIGGER IF NOT EXISTS employers_audit_insert AFTER INSERT ON employers
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, details)
+    VALUES ('database', 'employee.insert', 'employee', NEW.id, json_object('new', json_object('name', NEW.name, 'addr', NEW.addr, 'amount_salary', NEW.amount_salary, 'status', NEW.status, 'start_date', NEW.start_date, 'end_date', NEW.end_date)));
+END;
+
+CREATE TRIGGER IF NOT EXISTS employers_audit_update AFTER UPDATE ON employers
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, details)
+    VALUES ('database', 'employee.update', 'employee', NEW.id, json_object('old', json_object('name', OLD.name, 'addr', OLD.addr, 'amount_salary', OLD.amount_salary, 'status', OLD.status, 'start_date', OLD.start_date, 'end_date', OLD.end_date), 'new', json_object('name', NEW.name, 'addr', NEW.addr, 'amount_salary', NEW.amount_salary, 'status', NEW.status, 'start_date', NEW.start_date, 'end_date', NEW.end_date)));
+END;
+
+CREATE TRIGGER IF NOT EXISTS employers_audit_delete AFTER DELETE ON employers
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, details)
+    VALUES ('database', 'employee.delete', 'employee', OLD.id, json_object('old', json_object('name', OLD.name, 'addr', OLD.addr, 'amount_salary', OLD.amount_salary, 'status', OLD.status, 'start_date', OLD.start_date, 'end_date', OLD.end_date)));
+END;
@@ -0,0 +1,2 @@
+ALTER TABLE payments DROP COLUMN signing_hash;
+ALTER TABLE payments DROP COLUMN nonce;
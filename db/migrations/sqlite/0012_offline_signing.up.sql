@@ -0,0 +1,2 @@
+ALTER TABLE payments ADD COLUMN nonce INT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN signing_hash TEXT DEFAULT NULL;
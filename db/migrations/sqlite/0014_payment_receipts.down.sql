@@ -0,0 +1,4 @@
+ALTER TABLE payments DROP COLUMN paid_at;
+ALTER TABLE payments DROP COLUMN gas_fee;
+ALTER TABLE payments DROP COLUMN gas_used;
+ALTER TABLE payments DROP COLUMN token;
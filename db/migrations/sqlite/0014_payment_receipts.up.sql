@@ -0,0 +1,6 @@
+-- what paying cost: the token sent, the gas of transactions River sent
+-- itself, and when the payment was done
+ALTER TABLE payments ADD COLUMN token TEXT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN gas_used INT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN gas_fee INT DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN paid_at TIMESTAMP DEFAULT NULL;
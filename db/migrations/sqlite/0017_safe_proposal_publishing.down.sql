@@ -0,0 +1,2 @@
+ALTER TABLE safe_proposals DROP COLUMN payload;
+ALTER TABLE safe_proposals DROP COLUMN block;
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS address_changes_encrypted_update;
+DROP TRIGGER IF EXISTS address_changes_encrypted_insert;
+DROP TRIGGER IF EXISTS payments_encrypted_update;
+DROP TRIGGER IF EXISTS payments_encrypted_insert;
+DROP TRIGGER IF EXISTS employers_encrypted_update;
+DROP TRIGGER IF EXISTS employers_encrypted_insert;
+DROP TABLE IF EXISTS field_encryption;
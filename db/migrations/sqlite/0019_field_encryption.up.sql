@@ -0,0 +1,45 @@
+-- set by "river db encrypt" once the names and addresses of employees, and
+-- their copies, are encrypted with the key key_check recognizes
+CREATE TABLE IF NOT EXISTS field_encryption (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    key_check TEXT NOT NULL,
+    encrypted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- encrypted databases refuse names and addresses in plain text, which
+-- River would pay to or show as they are
+CREATE TRIGGER IF NOT EXISTS employers_encrypted_insert BEFORE INSERT ON employers
+WHEN EXISTS (SELECT 1 FROM field_encryption) AND (NEW.name NOT LIKE 'enc1:%' OR NEW.addr NOT LIKE 'enc1:%')
+BEGIN
+    SELECT RAISE(ABORT, 'employee names and addresses are encrypted, add employees with river employee add');
+END;
+
+CREATE TRIGGER IF NOT EXISTS employers_encrypted_update BEFORE UPDATE OF name, addr ON employers
+WHEN EXISTS (SELECT 1 FROM field_encryption) AND (NEW.name NOT LIKE 'enc1:%' OR NEW.addr NOT LIKE 'enc1:%')
+BEGIN
+    SELECT RAISE(ABORT, 'employee names and addresses are encrypted, rename employees with river employee rename');
+END;
+
+CREATE TRIGGER IF NOT EXISTS payments_encrypted_insert BEFORE INSERT ON payments
+WHEN EXISTS (SELECT 1 FROM field_encryption) AND NEW.addr NOT LIKE 'enc1:%'
+BEGIN
+    SELECT RAISE(ABORT, 'payment addresses are encrypted');
+END;
+
+CREATE TRIGGER IF NOT EXISTS payments_encrypted_update BEFORE UPDATE OF addr ON payments
+WHEN EXISTS (SELECT 1 FROM field_encryption) AND NEW.addr NOT LIKE 'enc1:%'
+BEGIN
+    SELECT RAISE(ABORT, 'payment addresses are encrypted');
+END;
+
+CREATE TRIGGER IF NOT EXISTS address_changes_encrypted_insert BEFORE INSERT ON address_changes
+WHEN EXISTS (SELECT 1 FROM field_encryption) AND (NEW.old_addr NOT LIKE 'enc1:%' OR NEW.new_addr NOT LIKE 'enc1:%')
+BEGIN
+    SELECT RAISE(ABORT, 'employee addresses are encrypted, request changes with river employee address set');
+END;
+
+CREATE TRIGGER IF NOT EXISTS address_changes_encrypted_update BEFORE UPDATE OF old_addr, new_addr ON address_changes
+WHEN EXISTS (SELECT 1 FROM field_encryption) AND (NEW.old_addr NOT LIKE 'enc1:%' OR NEW.new_addr NOT LIKE 'enc1:%')
+BEGIN
+    SELECT RAISE(ABORT, 'employee addresses are encrypted');
+END;
@@ -0,0 +1 @@
+DROP TRIGGER IF EXISTS employers_address_guard;
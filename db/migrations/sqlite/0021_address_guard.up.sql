@@ -0,0 +1,10 @@
+-- employee addresses only change to the new address of an applied address
+-- change, so that changes made outside river employee address set do not
+-- skip their cooldown; encrypting the address in place is allowed
+CREATE TRIGGER IF NOT EXISTS employers_address_guard BEFORE UPDATE OF addr ON employers
+WHEN NEW.addr IS NOT OLD.addr
+    AND NOT (OLD.addr NOT LIKE 'enc1:%' AND NEW.addr LIKE 'enc1:%' AND NOT EXISTS (SELECT 1 FROM field_encryption))
+    AND NOT EXISTS (SELECT 1 FROM address_changes WHERE employee_id = NEW.id AND new_addr = NEW.addr AND status = 'applied')
+BEGIN
+    SELECT RAISE(ABORT, 'employee addresses change with river employee address set, after their cooldown');
+END;
@@ -0,0 +1 @@
+DROP TRIGGER IF EXISTS audit_log_sequence;
@@ -0,0 +1,12 @@
+-- entries are numbered as they are recorded, those of the employers
+-- triggers included, so that deleting one before River seals it leaves a
+-- gap in the sequence
+UPDATE audit_log SET seq = (SELECT COALESCE(MAX(seq), 0) FROM audit_log WHERE hash IS NOT NULL)
+    + (SELECT COUNT(*) FROM audit_log a WHERE a.hash IS NULL AND a.id <= audit_log.id)
+WHERE hash IS NULL;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_sequence AFTER INSERT ON audit_log
+WHEN NEW.seq IS NULL
+BEGIN
+    UPDATE audit_log SET seq = (SELECT COALESCE(MAX(seq), 0) + 1 FROM audit_log) WHERE id = NEW.id;
+END;
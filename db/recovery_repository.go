@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func NewRecoveryRepository(db *sql.DB, fields *Fields) repository.RecoveryRepository {
+	return &recoveryRepository{db: db, fields: fields}
+}
+
+type recoveryRepository struct {
+	db     *sql.DB
+	fields *Fields
+}
+
+func (s *recoveryRepository) Recover(ctx context.Context, payments []*repository.RecoveredPayment) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var salaryID int64
+	for _, p := range payments {
+		if p.Payment != nil {
+			err = settleRecovered(ctx, tx, p)
+		} else {
+			if salaryID == 0 {
+				salaryID, err = insertRecoveryRun(ctx, tx)
+				if err != nil {
+					return 0, err
+				}
+			}
+			err = insertRecovered(ctx, tx, s.fields, salaryID, p)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return salaryID, nil
+}
+
+// settleRecovered moves an unfinished payment to done, claiming it for
+// processing first if it was never claimed
+func settleRecovered(ctx context.Context, tx *sql.Tx, p *repository.RecoveredPayment) error {
+	status, version := repository.PaymentStatus(p.Payment.Status), p.Payment.Version
+	if status == repository.CreatedStatus {
+		err := paymentStatuses.set(ctx, tx, p.Payment.ID, status, version, repository.ProcessingStatus)
+
+		if err != nil {
+			return err
+		}
+
+		status, version = repository.ProcessingStatus, version+1
+	}
+
+	err := paymentStatuses.set(ctx, tx, p.Payment.ID, status, version, repository.DoneStatus)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payments SET tx_hash = $1, payer = $2, token = $3, paid_at = $4 WHERE id = $5`,
+		p.Transfer.TxHash, p.Transfer.From, p.Transfer.Token, p.PaidAt, p.Payment.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return appendAudit(ctx, tx, "payment.recover", "payment", p.Payment.ID, recoveredDetails(p))
+}
+
+// insertRecoveryRun creates the payroll run recovered payments no run
+// accounts for are recorded in
+func insertRecoveryRun(ctx context.Context, tx *sql.Tx) (int64, error) {
+	var salaryID int64
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO salaries (status, schedule) VALUES ($1, $2) RETURNING id;
+	`, repository.DoneStatus, repository.AdHocSchedule).Scan(&salaryID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = appendAudit(ctx, tx, "salary.create", "salary", salaryID, map[string]interface{}{
+		"status":    repository.DoneStatus,
+		"schedule":  repository.AdHocSchedule,
+		"recovered": true,
+	})
+
+	return salaryID, err
+}
+
+// insertRecovered records a done payment found on chain, encrypting its
+// address with fields
+func insertRecovered(ctx context.Context, tx *sql.Tx, fields *Fields, salaryID int64, p *repository.RecoveredPayment) error {
+	addr, err := fields.seal("addr", p.Addr)
+	if err != nil {
+		return err
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments (salary_id, employee_id, amount, gross_amount, deduction_amount, status, addr, category, memo,
+			tx_hash, payer, token, paid_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id;
+	`, salaryID, p.EmployeeID, p.Amount, p.Amount, 0, repository.DoneStatus, addr, repository.SalaryCategory, p.Memo,
+		p.Transfer.TxHash, p.Transfer.From, p.Transfer.Token, p.PaidAt).Scan(&id)
+
+	if err != nil {
+		return err
+	}
+
+	return appendAudit(ctx, tx, "payment.recover", "payment", id, recoveredDetails(p))
+}
+
+// recoveredDetails describes a recovered payment in the audit log
+func recoveredDetails(p *repository.RecoveredPayment) map[string]interface{} {
+	return map[string]interface{}{
+		"status":      repository.DoneStatus,
+		"employee_id": p.EmployeeID,
+		"amount":      p.Amount,
+		"tx_hash":     p.Transfer.TxHash,
+		"payer":       p.Transfer.From,
+		"token":       p.Transfer.Token,
+		"paid_at":     p.PaidAt,
+	}
+}
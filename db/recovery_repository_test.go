@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestRecoveryRepository_Recover(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+	recovery := NewRecoveryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000), ('bob', '0x02', 2000)`)
+	require.NoError(t, err)
+
+	october := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: october, Schedule: string(payroll.Monthly)}))
+	payments, err := repo.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+
+	// bob's payment was claimed before the database was lost
+	require.NoError(t, repo.ClaimPayment(ctx, payments[1], repository.ProcessingStatus))
+
+	paidAt := time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC)
+	transfer := func(hash string) entity.Transfer {
+		return entity.Transfer{TxHash: hash, From: "0xsigner", Token: "0xtoken"}
+	}
+	salaryID, err := recovery.Recover(ctx, []*repository.RecoveredPayment{
+		{Payment: payments[0], EmployeeID: 1, Amount: 1000, Transfer: transfer("0xr1"), PaidAt: paidAt},
+		{Payment: payments[1], EmployeeID: 2, Amount: 2000, Transfer: transfer("0xr2"), PaidAt: paidAt},
+		{EmployeeID: 1, Addr: "0x01", Amount: 500, Memo: "recovered from block 7", Transfer: transfer("0xr3"), PaidAt: paidAt},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), salaryID)
+
+	done, err := repo.ListPaymentsByStatus(ctx, repository.DoneStatus)
+	require.NoError(t, err)
+	require.Len(t, done, 3)
+	assert.Equal(t, "0xr1", done[0].TxHash)
+	assert.Equal(t, "0xsigner", done[1].Payer)
+	require.NotNil(t, done[1].PaidAt)
+	assert.True(t, paidAt.Equal(*done[1].PaidAt))
+
+	assert.Equal(t, int64(2), done[2].SalaryID)
+	assert.Equal(t, int64(500), done[2].GrossAmount)
+	assert.Equal(t, "0xtoken", done[2].Token)
+	assert.Equal(t, "recovered from block 7", done[2].Memo)
+
+	run, err := repo.GetSalary(ctx, salaryID)
+	require.NoError(t, err)
+	assert.Equal(t, string(repository.DoneStatus), run.Status)
+
+	// a payment that changed since it was read is not settled twice
+	_, err = recovery.Recover(ctx, []*repository.RecoveredPayment{
+		{Payment: payments[0], EmployeeID: 1, Amount: 1000, Transfer: transfer("0xr1"), PaidAt: paidAt},
+	})
+	assert.ErrorIs(t, err, repository.ErrStatusConflict)
+
+	// nothing needs a run of its own
+	salaryID, err = recovery.Recover(ctx, nil)
+	require.NoError(t, err)
+	assert.Zero(t, salaryID)
+}
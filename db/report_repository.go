@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *sql.DB, fields *Fields) repository.ReportRepository {
+	return &reportRepository{db: db, fields: fields}
+}
+
+type reportRepository struct {
+	db     *sql.DB
+	fields *Fields
+}
+
+// selectRecords selects paymentColumns of payments, followed by the name of
+// their employee and the period of their run. Ad hoc runs are named after
+// their schedule; queued payments have no run yet.
+const selectRecords = `SELECT ` + paymentColumns + `,
+		COALESCE((SELECT name FROM employers WHERE employers.id = payments.employee_id), ''),
+		COALESCE((SELECT COALESCE(period, schedule) FROM salaries WHERE salaries.id = payments.salary_id), '')
+	FROM payments`
+
+// runDate dates payroll runs s by the start of their period, or their
+// creation for ad hoc runs
+const runDate = `COALESCE(s.period_start, s.created_at)`
+
+func (r *reportRepository) EmployeeHistory(ctx context.Context, employeeID int64, from, to time.Time) ([]*repository.PaymentRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		`+selectRecords+`
+		WHERE employee_id = $1 AND COALESCE(paid_at, created_at) >= $2 AND COALESCE(paid_at, created_at) < $3
+		ORDER BY id`, employeeID, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.openRecords(scanRecords(rows))
+}
+
+func (r *reportRepository) Paid(ctx context.Context, from, to time.Time) ([]*repository.PaymentRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		`+selectRecords+`
+		WHERE status = $1 AND COALESCE(paid_at, created_at) >= $2 AND COALESCE(paid_at, created_at) < $3
+		ORDER BY id`, repository.DoneStatus, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.openRecords(scanRecords(rows))
+}
+
+func (r *reportRepository) RunPayments(ctx context.Context, from, to time.Time) ([]*repository.PaymentRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		`+selectRecords+`
+		WHERE salary_id IN (SELECT id FROM salaries s WHERE `+runDate+` >= $1 AND `+runDate+` < $2)
+		ORDER BY salary_id, employee_id, id`, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.openRecords(scanRecords(rows))
+}
+
+func (r *reportRepository) Outstanding(ctx context.Context) ([]*repository.PaymentRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		`+selectRecords+`
+		WHERE status NOT IN ($1, $2)
+		ORDER BY id`, repository.DoneStatus, repository.RejectedStatus)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.openRecords(scanRecords(rows))
+}
+
+func (r *reportRepository) PeriodTotals(ctx context.Context, from, to time.Time) ([]*repository.PeriodTotal, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.id, COALESCE(s.period, s.schedule, ''), COALESCE(p.token, ''), COUNT(*),
+			SUM(COALESCE(p.gross_amount, p.amount)), SUM(p.deduction_amount), SUM(p.amount)
+		FROM payments p
+		JOIN salaries s ON s.id = p.salary_id
+		WHERE p.status = $1 AND `+runDate+` >= $2 AND `+runDate+` < $3
+		GROUP BY s.id, s.period, s.schedule, p.token
+		ORDER BY s.id, p.token`, repository.DoneStatus, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	totals := make([]*repository.PeriodTotal, 0)
+	for rows.Next() {
+		total := new(repository.PeriodTotal)
+		err = rows.Scan(&total.SalaryID, &total.Period, &total.Token, &total.Payments,
+			&total.Gross, &total.Deductions, &total.Net)
+		if err != nil {
+			return nil, err
+		}
+
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+func (r *reportRepository) GasByRun(ctx context.Context, from, to time.Time) ([]*repository.RunGas, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.id, COALESCE(s.period, s.schedule, ''), COUNT(*), SUM(p.gas_used), SUM(p.gas_fee)
+		FROM payments p
+		JOIN salaries s ON s.id = p.salary_id
+		WHERE p.gas_used IS NOT NULL AND `+runDate+` >= $1 AND `+runDate+` < $2
+		GROUP BY s.id, s.period, s.schedule
+		ORDER BY s.id`, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	runs := make([]*repository.RunGas, 0)
+	for rows.Next() {
+		run := new(repository.RunGas)
+		err = rows.Scan(&run.SalaryID, &run.Period, &run.Transactions, &run.GasUsed, &run.GasFee)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// openRecords decrypts the addresses and employee names of records scanned
+// by scanRecords
+func (r *reportRepository) openRecords(records []*repository.PaymentRecord, err error) ([]*repository.PaymentRecord, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		if record.Payment.Addr, err = r.fields.open("addr", record.Payment.Addr); err != nil {
+			return nil, &repository.RowError{Table: "payments", ID: record.Payment.ID, Err: err}
+		}
+		if record.Employee, err = r.fields.open("name", record.Employee); err != nil {
+			return nil, &repository.RowError{Table: "employers", ID: record.Payment.EmployeeID, Err: err}
+		}
+	}
+	return records, nil
+}
+
+// scanRecords reads and closes rows of selectRecords, failing if any of them
+// cannot be read
+func scanRecords(rows *sql.Rows) ([]*repository.PaymentRecord, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	records := make([]*repository.PaymentRecord, 0)
+	var malformed malformedRows
+	for rows.Next() {
+		record := new(repository.PaymentRecord)
+		payment, err := scanPayment(rows, &record.Employee, &record.Period)
+		if err != nil {
+			malformed.add(err)
+			continue
+		}
+
+		record.Payment = payment
+		records = append(records, record)
+	}
+
+	if err := malformed.err(rows); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
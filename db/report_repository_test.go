@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestReportRepository(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+	reports := NewReportRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000), ('bob', '0x02', 2000)`)
+	require.NoError(t, err)
+
+	october := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: october, Schedule: string(payroll.Monthly)}))
+
+	// alice is paid, bob is held for review and a bonus is queued
+	markPaid(t, repo, 1, 1)
+	require.NoError(t, repo.UpdatePaymentStatusToNeedsReview(ctx, 2, "over limit"))
+	_, err = repo.AddPayment(ctx, repository.PaymentItem{EmployeeID: 1, Amount: 50, Category: repository.BonusCategory})
+	require.NoError(t, err)
+
+	all, end := time.Time{}, time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC)
+	history, err := reports.EmployeeHistory(ctx, 1, all, end)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "alice", history[0].Employee)
+	assert.Equal(t, "2026-10", history[0].Period)
+	assert.Equal(t, "0xt1", history[0].Payment.TxHash)
+	assert.NotNil(t, history[0].Payment.PaidAt)
+	assert.Empty(t, history[1].Period)
+
+	history, err = reports.EmployeeHistory(ctx, 1, end, end.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	totals, err := reports.PeriodTotals(ctx, october.Start, october.End.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, repository.PeriodTotal{
+		SalaryID: 1, Period: "2026-10", Token: "0xtoken", Payments: 1, Gross: 1000, Deductions: 0, Net: 1000,
+	}, *totals[0])
+
+	totals, err = reports.PeriodTotals(ctx, october.End.AddDate(0, 0, 1), end)
+	require.NoError(t, err)
+	assert.Empty(t, totals)
+
+	gas, err := reports.GasByRun(ctx, all, end)
+	require.NoError(t, err)
+	require.Len(t, gas, 1)
+	assert.Equal(t, repository.RunGas{
+		SalaryID: 1, Period: "2026-10", Transactions: 1, GasUsed: 50_000, GasFee: 1_000_000,
+	}, *gas[0])
+
+	paid, err := reports.Paid(ctx, all, end)
+	require.NoError(t, err)
+	require.Len(t, paid, 1)
+	assert.Equal(t, int64(1), paid[0].Payment.ID)
+	assert.Equal(t, "0xsigner", paid[0].Payment.Payer)
+
+	paid, err = reports.Paid(ctx, end, end.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	assert.Empty(t, paid)
+
+	run, err := reports.RunPayments(ctx, october.Start, october.End.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	// the queued bonus is no part of the run
+	require.Len(t, run, 2)
+	assert.Equal(t, int64(1000), run[0].Payment.Rate)
+	assert.Equal(t, int64(2000), run[1].Payment.Rate)
+
+	outstanding, err := reports.Outstanding(ctx)
+	require.NoError(t, err)
+	require.Len(t, outstanding, 2)
+	assert.Equal(t, "bob", outstanding[0].Employee)
+	assert.Equal(t, string(repository.NeedsReviewStatus), outstanding[0].Payment.Status)
+	assert.Equal(t, "over limit", outstanding[0].Payment.Error)
+	assert.Equal(t, int64(0), outstanding[1].Payment.SalaryID)
+}
@@ -3,39 +3,189 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
 	"gitlab.midas.dev/back/river/internal/repository"
 )
 
-func NewSalaryRepository(db *sql.DB) repository.SalaryRepository {
-	return &salaryRepositorySQLite{db: db}
+func NewSalaryRepository(db *sql.DB, fields *Fields) repository.SalaryRepository {
+	return &salaryRepository{db: db, fields: fields, owner: claimOwner(), now: time.Now}
 }
 
-type salaryRepositorySQLite struct {
-	db *sql.DB
+type salaryRepository struct {
+	db     *sql.DB
+	fields *Fields
+	// owner names the claims of this repository's process
+	owner string
+	now   func() time.Time
 }
 
-func (s *salaryRepositorySQLite) UpdatePaymentStatusToProcessing(ctx context.Context, id int64) error {
-	return s.updatePaymentStatus(ctx, id, repository.ProcessingStatus)
+// ClaimPayment moves a payment to status if it is still in the status and at
+// the version it was read with, updating both, and the payment is not being
+// processed by another process. Its salary must not be claimed by another
+// process either; the claim on it is renewed in the same transaction.
+func (s *salaryRepository) ClaimPayment(ctx context.Context, payment *entity.Payment, status repository.PaymentStatus) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := s.now()
+	err = s.holdSalary(ctx, tx, payment.ID, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = paymentStatuses.claim(ctx, tx, payment.ID, repository.PaymentStatus(payment.Status), payment.Version, status, s.owner, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "payment.status", "payment", payment.ID, map[string]interface{}{"status": status})
+
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	payment.Status = string(status)
+	payment.Version++
+
+	return nil
 }
 
-func (s *salaryRepositorySQLite) UpdatePaymentStatusToDone(ctx context.Context, id int64) error {
-	return s.updatePaymentStatus(ctx, id, repository.DoneStatus)
+// ClaimPaymentSend claims a payment for processing like ClaimPayment and
+// records the transaction signed to send it in the same transaction, before
+// it is broadcast, so that a payment interrupted while sent is only ever
+// resumed with that transaction
+func (s *salaryRepository) ClaimPaymentSend(ctx context.Context, payment *entity.Payment, signed *entity.SignedTransfer) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := s.now()
+	err = s.holdSalary(ctx, tx, payment.ID, now)
+
+	if err != nil {
+		return err
+	}
+
+	err = paymentStatuses.claim(ctx, tx, payment.ID, repository.PaymentStatus(payment.Status), payment.Version,
+		repository.ProcessingStatus, s.owner, now)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payments SET tx_hash = $1, payer = $2, nonce = $3, signed_tx = $4 WHERE id = $5`,
+		signed.TxHash, signed.From, signed.Nonce, signed.Raw, payment.ID)
+
+	if err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "payment.status", "payment", payment.ID, map[string]interface{}{
+		"status":  repository.ProcessingStatus,
+		"tx_hash": signed.TxHash,
+		"payer":   signed.From,
+		"nonce":   signed.Nonce,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	payment.Status = string(repository.ProcessingStatus)
+	payment.Version++
+	payment.TxHash = signed.TxHash
+	payment.Payer = signed.From
+	payment.Nonce = signed.Nonce
+	payment.SignedTx = signed.Raw
+
+	return nil
 }
 
-func (s *salaryRepositorySQLite) updatePaymentStatus(ctx context.Context, id int64, status repository.PaymentStatus) error {
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE main.payments SET status = $1 WHERE id = $2`, status, id)
+// CompletePayment moves a payment to done like ClaimPayment, recording the
+// transaction that sent it and what it cost
+func (s *salaryRepository) CompletePayment(ctx context.Context, payment *entity.Payment, transfer *entity.Transfer) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = paymentStatuses.set(ctx, tx, payment.ID, repository.PaymentStatus(payment.Status), payment.Version, repository.DoneStatus)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payments SET tx_hash = $1, payer = $2, token = $3, gas_used = $4, gas_fee = $5, paid_at = CURRENT_TIMESTAMP
+		WHERE id = $6`, transfer.TxHash, transfer.From, transfer.Token, transfer.GasUsed, transfer.GasFee, payment.ID)
+
+	if err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "payment.status", "payment", payment.ID, map[string]interface{}{
+		"status":   repository.DoneStatus,
+		"tx_hash":  transfer.TxHash,
+		"payer":    transfer.From,
+		"token":    transfer.Token,
+		"gas_used": transfer.GasUsed,
+		"gas_fee":  transfer.GasFee,
+	})
 
 	if err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	payment.Status = string(repository.DoneStatus)
+	payment.Version++
+	payment.TxHash = transfer.TxHash
+	payment.Payer = transfer.From
+	payment.Token = transfer.Token
+	payment.GasUsed = transfer.GasUsed
+	payment.GasFee = transfer.GasFee
+
 	return nil
 }
 
-func (s *salaryRepositorySQLite) Create(ctx context.Context) error {
+func (s *salaryRepository) Create(ctx context.Context, params repository.CreateSalaryParams) error {
 	tx, err := s.db.Begin()
 
 	if err != nil {
@@ -46,8 +196,27 @@ func (s *salaryRepositorySQLite) Create(ctx context.Context) error {
 		_ = tx.Rollback()
 	}()
 
+	period := params.Period
+	label := payroll.Label(period)
+
+	if !params.Force {
+		var count int
+		err = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM salaries WHERE period_start <= $1 AND period_end >= $2
+		`, period.End, period.Start).Scan(&count)
+
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("%w: %s", repository.ErrPeriodAlreadyPaid, label)
+		}
+	}
+
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO salaries (status) VALUES ($1) RETURNING id;
+		INSERT INTO salaries (status, period, period_start, period_end, schedule, forced)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
 	`)
 
 	if err != nil {
@@ -58,17 +227,116 @@ func (s *salaryRepositorySQLite) Create(ctx context.Context) error {
 		_ = stmt.Close()
 	}()
 
+	status := repository.CreatedStatus
+	if params.Draft {
+		status = repository.DraftStatus
+	}
+
 	var salaryID int
-	err = stmt.QueryRowContext(ctx, repository.CreatedStatus).Scan(&salaryID)
+	err = stmt.QueryRowContext(ctx, status, label, period.Start, period.End, params.Schedule, params.Force).
+		Scan(&salaryID)
+
+	if err != nil {
+		return err
+	}
+
+	now := params.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	pendingAddresses, err := applyDueAddressChanges(ctx, tx, now, params.RequireVerifiedAddress)
+
+	if err != nil {
+		return err
+	}
+
+	emps, err := listEmployees(ctx, tx)
+
+	if err != nil {
+		return err
+	}
+
+	deductions, err := listActiveDeductions(ctx, tx)
+
+	if err != nil {
+		return err
+	}
+
+	paymentStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO payments (salary_id, employee_id, amount, gross_amount, deduction_amount, status, addr, category, memo, error, rate)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+	`)
 
 	if err != nil {
 		return err
 	}
 
+	defer func() {
+		_ = paymentStmt.Close()
+	}()
+
+	for _, emp := range emps {
+		gross, ok := payroll.Prorate(emp, period)
+		if !ok {
+			continue
+		}
+
+		net, withheld := payroll.ApplyDeductions(gross, deductions[emp.ID])
+
+		// Salaries of employees changing address are paid to the old address
+		// unless they are held until the change takes effect
+		status, holdReason := repository.CreatedStatus, sql.NullString{}
+		if change, ok := pendingAddresses[emp.ID]; ok && params.HoldPendingAddress {
+			status = repository.NeedsReviewStatus
+			holdReason = sql.NullString{String: addressHoldReason(change), Valid: true}
+		}
+
+		// Nothing is sent when deductions take the whole salary
+		if net > 0 {
+			_, err = paymentStmt.ExecContext(ctx, salaryID, emp.ID, net, gross, gross-net, status,
+				emp.Addr, repository.SalaryCategory, nil, holdReason, emp.SalaryAmount)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, w := range withheld {
+			if w.Deduction.TreasuryAddr == "" {
+				continue
+			}
+
+			// addresses of payments are encrypted alike, those of employees
+			// are copied as stored
+			treasury, err := s.fields.seal("addr", w.Deduction.TreasuryAddr)
+			if err != nil {
+				return err
+			}
+
+			_, err = paymentStmt.ExecContext(ctx, salaryID, emp.ID, w.Amount, w.Amount, 0, repository.CreatedStatus,
+				treasury, repository.WithholdingCategory, w.Deduction.Name, nil, nil)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// One-off payments queued for the next run are paid with this one
 	_, err = tx.ExecContext(ctx, `
-		INSERT INTO payments (salary_id, employee_id, amount, status, addr) 
-		SELECT $1, id, amount_salary, $2, addr FROM employers;
-	`, salaryID, repository.CreatedStatus)
+		UPDATE payments SET salary_id = $1 WHERE salary_id IS NULL`, salaryID)
+
+	if err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "salary.create", "salary", int64(salaryID), map[string]interface{}{
+		"status":   status,
+		"period":   label,
+		"schedule": params.Schedule,
+		"forced":   params.Force,
+	})
 
 	if err != nil {
 		return err
@@ -81,67 +349,878 @@ func (s *salaryRepositorySQLite) Create(ctx context.Context) error {
 	return nil
 }
 
-func (s *salaryRepositorySQLite) UpdateStatusToProcessing(ctx context.Context, id int64) error {
-	return s.updateStatus(ctx, id, repository.ProcessingStatus)
-}
+// ClaimSalary moves a salary to status if it is still in the status and at
+// the version it was read with, updating both, and the salary is not being
+// processed by another process
+func (s *salaryRepository) ClaimSalary(ctx context.Context, salary *entity.Salary, status repository.PaymentStatus) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = salaryStatuses.claim(ctx, tx, salary.ID, repository.PaymentStatus(salary.Status), salary.Version, status, s.owner, s.now())
+
+	if err != nil {
+		return err
+	}
 
-func (s *salaryRepositorySQLite) updateStatus(ctx context.Context, id int64, status repository.PaymentStatus) error {
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE salaries SET status = $1 WHERE id = $2`, status, id)
+	err = appendAudit(ctx, tx, "salary.status", "salary", salary.ID, map[string]interface{}{"status": status})
 
 	if err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	salary.Status = string(status)
+	salary.Version++
+
 	return nil
 }
 
-func (s *salaryRepositorySQLite) UpdateStatusToDone(ctx context.Context, id int64) error {
+// holdSalary renews this process's claim on the salary of a payment, and
+// returns ErrStatusConflict if another process holds a live claim on it
+func (s *salaryRepository) holdSalary(ctx context.Context, tx *sql.Tx, paymentID int64, now time.Time) error {
+	now = now.UTC().Truncate(time.Second)
+
+	var held int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM salaries
+		WHERE id = (SELECT salary_id FROM payments WHERE id = $1)
+			AND status = $2 AND claimed_by != $3 AND claim_expires_at >= $4`,
+		paymentID, repository.ProcessingStatus, s.owner, now).Scan(&held)
+
+	if err != nil {
+		return err
+	}
+
+	if held > 0 {
+		return fmt.Errorf("%w: the salary of payment %d is processed by another process", repository.ErrStatusConflict, paymentID)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE salaries SET claim_expires_at = $1
+		WHERE id = (SELECT salary_id FROM payments WHERE id = $2) AND status = $3 AND claimed_by = $4`,
+		now.Add(claimLease), paymentID, repository.ProcessingStatus, s.owner)
+
+	return err
+}
+
+// updateStatus moves a salary from its current status to status
+func (s *salaryRepository) updateStatus(ctx context.Context, id int64, status repository.PaymentStatus) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	from, version, err := salaryStatuses.current(ctx, tx, id)
+
+	if err != nil {
+		return err
+	}
+
+	err = salaryStatuses.set(ctx, tx, id, from, version, status)
+
+	if err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "salary.status", "salary", id, map[string]interface{}{"status": status})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *salaryRepository) UpdateStatusToDone(ctx context.Context, id int64) error {
 	return s.updateStatus(ctx, id, repository.DoneStatus)
 }
 
-func (s *salaryRepositorySQLite) ListByStatus(ctx context.Context, status repository.PaymentStatus) ([]*entity.Salary, error) {
+func (s *salaryRepository) UpdateStatusToDraft(ctx context.Context, id int64) error {
+	return s.updateStatus(ctx, id, repository.DraftStatus)
+}
+
+func (s *salaryRepository) UpdateStatusToApproved(ctx context.Context, id int64) error {
+	return s.updateStatus(ctx, id, repository.ApprovedStatus)
+}
+
+func (s *salaryRepository) GetSalary(ctx context.Context, id int64) (*entity.Salary, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, status, created_at FROM salaries WHERE status = $1`, status)
+		SELECT `+salaryColumns+` FROM salaries WHERE id = $1`, id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	salaries := make([]*entity.Salary, 0)
-
-	for rows.Next() {
-		salary := new(entity.Salary)
+	defer func() {
+		_ = rows.Close()
+	}()
 
-		err = rows.Scan(&salary.ID, &salary.Status, &salary.CreateAt)
-		if err != nil {
-			continue
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
 		}
+		return nil, fmt.Errorf("%w: %d", repository.ErrSalaryNotFound, id)
+	}
 
-		salaries = append(salaries, salary)
+	return scanSalary(rows)
+}
+
+func (s *salaryRepository) AddApproval(ctx context.Context, approval *entity.Approval) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO salary_approvals (salary_id, approver, manifest_hash, signature)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (salary_id, approver, manifest_hash) DO NOTHING`,
+		approval.SalaryID, approval.Approver, approval.ManifestHash, approval.Signature)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// repeated approvals are not changes
+	if n == 0 {
+		return nil
 	}
 
-	return salaries, err
+	err = appendAudit(ctx, tx, "salary.approve", "salary", approval.SalaryID, map[string]interface{}{
+		"approver":      approval.Approver,
+		"manifest_hash": approval.ManifestHash,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *salaryRepositorySQLite) ListPaymentsBySalaryID(ctx context.Context, salaryID int64) ([]*entity.Payment, error) {
+func (s *salaryRepository) ListApprovals(ctx context.Context, salaryID int64) ([]*entity.Approval, error) {
 	rows, err := s.db.QueryContext(ctx, `
-	SELECT id, employee_id, salary_id, amount, addr FROM payments WHERE salary_id = $1 AND status != $2
-	`, salaryID, repository.DoneStatus)
+		SELECT id, salary_id, approver, manifest_hash, signature, created_at
+		FROM salary_approvals WHERE salary_id = $1 ORDER BY id`, salaryID)
 
 	if err != nil {
 		return nil, err
 	}
-	payments := make([]*entity.Payment, 0)
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	approvals := make([]*entity.Approval, 0)
 	for rows.Next() {
-		payment := new(entity.Payment)
-		err = rows.Scan(&payment.ID, &payment.EmployeeID, &payment.SalaryID, &payment.Amount, &payment.Addr)
+		approval := new(entity.Approval)
+		err = rows.Scan(&approval.ID, &approval.SalaryID, &approval.Approver, &approval.ManifestHash,
+			&approval.Signature, &approval.CreateAt)
 		if err != nil {
-			continue
+			return nil, err
 		}
 
-		payments = append(payments, payment)
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, rows.Err()
+}
+
+func (s *salaryRepository) ListByStatus(ctx context.Context, status repository.PaymentStatus) ([]*entity.Salary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+salaryColumns+` FROM salaries WHERE status = $1`, status)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	salaries := make([]*entity.Salary, 0)
+	var malformed malformedRows
+	for rows.Next() {
+		salary, err := scanSalary(rows)
+		if err != nil {
+			malformed.add(err)
+			continue
+		}
+
+		salaries = append(salaries, salary)
+	}
+
+	if err := malformed.err(rows); err != nil {
+		return nil, err
+	}
+
+	return salaries, nil
+}
+
+func (s *salaryRepository) ListPaymentsBySalaryID(ctx context.Context, salaryID int64) ([]*entity.Payment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT `+paymentColumns+`
+	FROM payments WHERE salary_id = $1 AND status NOT IN ($2, $3) ORDER BY id
+	`, salaryID, repository.DoneStatus, repository.RejectedStatus)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.openPayments(scanPayments(rows))
+}
+
+func (s *salaryRepository) AddPayment(ctx context.Context, item repository.PaymentItem) (int64, error) {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var salaryID sql.NullInt64
+	if item.SalaryID != 0 {
+		var status string
+		err = tx.QueryRowContext(ctx, `
+			SELECT status FROM salaries WHERE id = $1`, item.SalaryID).Scan(&status)
+
+		if err == sql.ErrNoRows || status == string(repository.DoneStatus) {
+			return 0, fmt.Errorf("%w: %d", repository.ErrSalaryNotFound, item.SalaryID)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		salaryID = sql.NullInt64{Int64: item.SalaryID, Valid: true}
+	}
+
+	id, err := insertPaymentItem(ctx, tx, salaryID, item)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = appendAudit(ctx, tx, "payment.create", "payment", id, paymentItemDetails(item))
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *salaryRepository) CreateAdHoc(ctx context.Context, items []repository.PaymentItem, draft bool) (int64, error) {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	status := repository.CreatedStatus
+	if draft {
+		status = repository.DraftStatus
+	}
+
+	var salaryID int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO salaries (status, schedule) VALUES ($1, $2) RETURNING id;
+	`, status, repository.AdHocSchedule).Scan(&salaryID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = appendAudit(ctx, tx, "salary.create", "salary", salaryID, map[string]interface{}{
+		"status":   status,
+		"schedule": repository.AdHocSchedule,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		id, err := insertPaymentItem(ctx, tx, sql.NullInt64{Int64: salaryID, Valid: true}, item)
+
+		if err != nil {
+			return 0, err
+		}
+
+		err = appendAudit(ctx, tx, "payment.create", "payment", id, paymentItemDetails(item))
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return salaryID, nil
+}
+
+func (s *salaryRepository) UpdatePaymentStatusToNeedsReview(ctx context.Context, id int64, reason string) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	from, version, err := paymentStatuses.current(ctx, tx, id)
+
+	if err != nil {
+		return err
+	}
+
+	err = paymentStatuses.set(ctx, tx, id, from, version, repository.NeedsReviewStatus)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payments SET error = $1 WHERE id = $2`, reason, id)
+
+	if err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "payment.status", "payment", id, map[string]interface{}{
+		"status": repository.NeedsReviewStatus,
+		"reason": reason,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *salaryRepository) ReviewPayment(ctx context.Context, id int64, approve bool) error {
+	status := repository.RejectedStatus
+	if approve {
+		status = repository.CreatedStatus
+	}
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE payments SET status = $1, reviewed = $2, version = version + 1 WHERE id = $3 AND status = $4`,
+		status, approve, id, repository.NeedsReviewStatus)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %d", repository.ErrPaymentNotFound, id)
+	}
+
+	err = appendAudit(ctx, tx, "payment.review", "payment", id, map[string]interface{}{
+		"status":   status,
+		"approved": approve,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *salaryRepository) ListPaymentsByStatus(ctx context.Context, status repository.PaymentStatus) ([]*entity.Payment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT `+paymentColumns+`
+	FROM payments WHERE status = $1 ORDER BY id
+	`, status)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.openPayments(scanPayments(rows))
+}
+
+func (s *salaryRepository) SpendingHistory(ctx context.Context, salaryID int64) (*repository.SpendingHistory, error) {
+	history := &repository.SpendingHistory{
+		EmployeePeriod: make(map[int64]int64),
+		PreviousSalary: make(map[int64]int64),
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM payments WHERE salary_id = $1 AND status = $2
+	`, salaryID, repository.DoneStatus).Scan(&history.RunSent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Paid to each employee by this run and any other run for an overlapping period
+	err = sumByEmployee(ctx, s.db, history.EmployeePeriod, `
+		SELECT p.employee_id, SUM(p.amount)
+		FROM payments p
+		JOIN salaries s ON s.id = p.salary_id
+		JOIN salaries cur ON cur.id = $1
+		WHERE p.status = $2 AND p.category != $3
+			AND (s.id = cur.id OR (s.period_start <= cur.period_end AND s.period_end >= cur.period_start))
+		GROUP BY p.employee_id
+	`, salaryID, repository.DoneStatus, repository.WithholdingCategory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = sumByEmployee(ctx, s.db, history.PreviousSalary, `
+		SELECT employee_id, COALESCE(gross_amount, amount)
+		FROM payments
+		WHERE id IN (
+			SELECT MAX(id) FROM payments
+			WHERE salary_id < $1 AND status = $2 AND category = $3
+			GROUP BY employee_id
+		)
+	`, salaryID, repository.DoneStatus, repository.SalaryCategory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (s *salaryRepository) AddSafeProposal(ctx context.Context, proposal *entity.SafeProposal, paymentIDs []int64) (int64, error) {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO safe_proposals (salary_id, safe, safe_tx_hash, nonce, status, block, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')) RETURNING id`,
+		proposal.SalaryID, proposal.Safe, proposal.SafeTxHash, proposal.Nonce, repository.PendingSafeProposal,
+		proposal.Block, proposal.Payload).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, paymentID := range paymentIDs {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, safe_tx_hash = $2, payer = $3, token = NULLIF($4, ''), version = version + 1
+			WHERE id = $5 AND status IN ($6, $7)`,
+			repository.ProposedStatus, proposal.SafeTxHash, proposal.Safe, proposal.Token, paymentID,
+			repository.CreatedStatus, repository.ProcessingStatus)
+
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		if n == 0 {
+			return 0, fmt.Errorf("%w: payment %d can no longer be proposed", repository.ErrStatusConflict, paymentID)
+		}
+	}
+
+	err = appendAudit(ctx, tx, "safe_proposal.create", "safe_proposal", id, map[string]interface{}{
+		"salary_id":    proposal.SalaryID,
+		"safe":         proposal.Safe,
+		"safe_tx_hash": proposal.SafeTxHash,
+		"nonce":        proposal.Nonce,
+		"payments":     paymentIDs,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *salaryRepository) ListSafeProposals(ctx context.Context, status repository.SafeProposalStatus) ([]*entity.SafeProposal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, salary_id, safe, safe_tx_hash, nonce, status, COALESCE(tx_hash, ''), COALESCE(block, 0),
+			COALESCE(payload, ''), created_at
+		FROM safe_proposals WHERE status = $1 ORDER BY id`, status)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	proposals := make([]*entity.SafeProposal, 0)
+	for rows.Next() {
+		proposal := new(entity.SafeProposal)
+		err = rows.Scan(&proposal.ID, &proposal.SalaryID, &proposal.Safe, &proposal.SafeTxHash,
+			&proposal.Nonce, &proposal.Status, &proposal.TxHash, &proposal.Block, &proposal.Payload, &proposal.CreateAt)
+		if err != nil {
+			return nil, err
+		}
+
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, rows.Err()
+}
+
+func (s *salaryRepository) ResolveSafeProposal(ctx context.Context, id int64, status repository.SafeProposalStatus, txHash string) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var safeTxHash string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE safe_proposals SET status = $1, tx_hash = NULLIF($2, '')
+		WHERE id = $3 AND status = $4 RETURNING safe_tx_hash`,
+		status, txHash, id, repository.PendingSafeProposal).Scan(&safeTxHash)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", repository.ErrSafeProposalNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if status == repository.ExecutedSafeProposal {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, tx_hash = NULLIF($2, ''), paid_at = CURRENT_TIMESTAMP, version = version + 1
+			WHERE safe_tx_hash = $3 AND status = $4`,
+			repository.DoneStatus, txHash, safeTxHash, repository.ProposedStatus)
+	} else {
+		// the payments are proposed again by the next repay
+		_, err = tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, safe_tx_hash = NULL, version = version + 1
+			WHERE safe_tx_hash = $2 AND status = $3`,
+			repository.CreatedStatus, safeTxHash, repository.ProposedStatus)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = appendAudit(ctx, tx, "safe_proposal.resolve", "safe_proposal", id, map[string]interface{}{
+		"status":  status,
+		"tx_hash": txHash,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *salaryRepository) ExportPayments(ctx context.Context, exports []repository.PaymentExport) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, export := range exports {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, nonce = $2, signing_hash = $3, token = NULLIF($4, ''), payer = NULLIF($5, ''),
+				version = version + 1
+			WHERE id = $6 AND status IN ($7, $8)`,
+			repository.ExportedStatus, export.Nonce, export.SigningHash, export.Token, export.From, export.PaymentID,
+			repository.CreatedStatus, repository.ProcessingStatus)
+
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return fmt.Errorf("%w: %d", repository.ErrPaymentNotFound, export.PaymentID)
+		}
+
+		err = appendAudit(ctx, tx, "payment.export", "payment", export.PaymentID, map[string]interface{}{
+			"status":       repository.ExportedStatus,
+			"nonce":        export.Nonce,
+			"signing_hash": export.SigningHash,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *salaryRepository) ResolveExport(ctx context.Context, id int64, signingHash, txHash string, paid bool) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var res sql.Result
+	status := repository.DoneStatus
+	if paid {
+		res, err = tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, tx_hash = $2, paid_at = CURRENT_TIMESTAMP, version = version + 1
+			WHERE id = $3 AND status = $4 AND signing_hash = $5`,
+			status, txHash, id, repository.ExportedStatus, signingHash)
+	} else {
+		// the payment is exported again by the next repay
+		status = repository.CreatedStatus
+		res, err = tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, nonce = NULL, signing_hash = NULL, version = version + 1
+			WHERE id = $2 AND status = $3 AND signing_hash = $4`,
+			status, id, repository.ExportedStatus, signingHash)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %d", repository.ErrExportNotFound, id)
+	}
+
+	err = appendAudit(ctx, tx, "payment.status", "payment", id, map[string]interface{}{
+		"status":  status,
+		"tx_hash": txHash,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sumByEmployee reads (employee_id, amount) rows into amounts
+func sumByEmployee(ctx context.Context, q queryer, amounts map[int64]int64, query string, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var employeeID, amount int64
+		if err := rows.Scan(&employeeID, &amount); err != nil {
+			return err
+		}
+		amounts[employeeID] = amount
+	}
+
+	return rows.Err()
+}
+
+// paymentItemDetails describes a one-off payment in the audit log
+func paymentItemDetails(item repository.PaymentItem) map[string]interface{} {
+	return map[string]interface{}{
+		"employee_id": item.EmployeeID,
+		"amount":      item.Amount,
+		"category":    item.Category,
+		"memo":        item.Memo,
+	}
+}
+
+// insertPaymentItem adds a one-off payment to the employee's current address
+func insertPaymentItem(ctx context.Context, tx *sql.Tx, salaryID sql.NullInt64, item repository.PaymentItem) (int64, error) {
+	var id int64
+	// Postgres takes parameters selected without a cast for text
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO payments (salary_id, employee_id, amount, gross_amount, status, addr, category, memo)
+		SELECT CAST($1 AS BIGINT), id, CAST($2 AS BIGINT), CAST($2 AS BIGINT), $3, addr, $4, $5 FROM employers WHERE id = $6
+		RETURNING id;
+	`, salaryID, item.Amount, repository.CreatedStatus, item.Category, item.Memo, item.EmployeeID).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: %d", repository.ErrEmployeeNotFound, item.EmployeeID)
+	}
+
+	return id, err
+}
+
+const salaryColumns = `id, status, COALESCE(period, ''), period_start, period_end, COALESCE(schedule, ''),
+		forced, created_at, version`
+
+func scanSalary(rows *sql.Rows) (*entity.Salary, error) {
+	salary := new(entity.Salary)
+
+	var periodStart, periodEnd sql.NullTime
+	err := rows.Scan(&salary.ID, &salary.Status, &salary.Period, &periodStart, &periodEnd,
+		&salary.Schedule, &salary.Forced, &salary.CreateAt, &salary.Version)
+	if err != nil {
+		return nil, &repository.RowError{Table: "salaries", ID: salary.ID, Err: err}
+	}
+
+	if periodStart.Valid {
+		salary.PeriodStart = &periodStart.Time
+	}
+	if periodEnd.Valid {
+		salary.PeriodEnd = &periodEnd.Time
+	}
+
+	return salary, nil
+}
+
+// paymentColumns are the columns scanPayment reads. Queued payments have no
+// salary yet.
+const paymentColumns = `id, employee_id, COALESCE(salary_id, 0), amount, COALESCE(gross_amount, amount),
+		deduction_amount, addr, status, category, COALESCE(memo, ''), COALESCE(error, ''), reviewed,
+		COALESCE(safe_tx_hash, ''), COALESCE(tx_hash, ''), COALESCE(nonce, 0), COALESCE(signing_hash, ''),
+		COALESCE(token, ''), COALESCE(payer, ''), COALESCE(gas_used, 0), COALESCE(gas_fee, 0), paid_at, COALESCE(rate, 0), created_at, version,
+		COALESCE(signed_tx, '')`
+
+// scanPayment reads a row of paymentColumns, followed by the columns scanned
+// into extra if any
+func scanPayment(rows *sql.Rows, extra ...interface{}) (*entity.Payment, error) {
+	payment := new(entity.Payment)
+
+	var paidAt sql.NullTime
+	dest := []interface{}{&payment.ID, &payment.EmployeeID, &payment.SalaryID, &payment.Amount, &payment.GrossAmount,
+		&payment.DeductionAmount, &payment.Addr, &payment.Status, &payment.Category, &payment.Memo, &payment.Error,
+		&payment.Reviewed, &payment.SafeTxHash, &payment.TxHash, &payment.Nonce, &payment.SigningHash,
+		&payment.Token, &payment.Payer, &payment.GasUsed, &payment.GasFee, &paidAt, &payment.Rate, &payment.CreateAt, &payment.Version,
+		&payment.SignedTx}
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, &repository.RowError{Table: "payments", ID: payment.ID, Err: err}
+	}
+
+	if paidAt.Valid {
+		payment.PaidAt = &paidAt.Time
+	}
+
+	return payment, nil
+}
+
+// openPayments decrypts the addresses of payments scanned by scanPayments
+func (s *salaryRepository) openPayments(payments []*entity.Payment, err error) ([]*entity.Payment, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fields.openPayments(payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// scanPayments reads and closes rows of paymentColumns, failing if any of
+// them cannot be read
+func scanPayments(rows *sql.Rows) ([]*entity.Payment, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	payments := make([]*entity.Payment, 0)
+	var malformed malformedRows
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			malformed.add(err)
+			continue
+		}
+
+		payments = append(payments, payment)
+	}
+
+	if err := malformed.err(rows); err != nil {
+		return nil, err
 	}
 
-	return payments, err
+	return payments, nil
 }
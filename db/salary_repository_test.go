@@ -1,12 +1,624 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
 )
 
+// newTestDB opens an in-memory SQLite database with the migrations applied,
+// or a schema of its own in the Postgres database at RIVER_TEST_DATABASE_URL
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	if url := os.Getenv("RIVER_TEST_DATABASE_URL"); url != "" {
+		return migrateTestDB(t, openPostgresTestDB(t, url))
+	}
+	return newSQLiteTestDB(t)
+}
+
+// newSQLiteTestDB opens an in-memory SQLite database with the migrations
+// applied, for tests storing what Postgres would refuse
+func newSQLiteTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	return migrateTestDB(t, openTestDB(t, ":memory:"))
+}
+
+func migrateTestDB(t *testing.T, dbDriver *sql.DB) *sql.DB {
+	t.Helper()
+
+	migrator, err := NewMigrator(dbDriver)
+	require.NoError(t, err)
+	_, err = migrator.Migrate(context.Background())
+	require.NoError(t, err)
+
+	return dbDriver
+}
+
+// testTransfer is the transfer markPaid records
+var testTransfer = &entity.Transfer{TxHash: "0xt1", From: "0xsigner", Token: "0xtoken", GasUsed: 50_000, GasFee: 1_000_000}
+
+var testSigned = &entity.SignedTransfer{TxHash: "0xt1", From: "0xsigner", Nonce: 7, Raw: "0x01"}
+
+// markPaid moves a payment through processing to done, as sending it does
+func markPaid(t *testing.T, repo repository.SalaryRepository, salaryID, paymentID int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	payments, err := repo.ListPaymentsBySalaryID(ctx, salaryID)
+	require.NoError(t, err)
+
+	for _, payment := range payments {
+		if payment.ID == paymentID {
+			require.NoError(t, repo.ClaimPayment(ctx, payment, repository.ProcessingStatus))
+			require.NoError(t, repo.CompletePayment(ctx, payment, testTransfer))
+			return
+		}
+	}
+	t.Fatalf("payment %d not found in salary %d", paymentID, salaryID)
+}
+
 func TestSalaryRepositoryDB(t *testing.T) {
 	// This is a placeholder test. In a real implementation, we would test the actual logic.
 	assert.True(t, true)
 }
+
+func TestSalaryRepository_CreateOnlyEligible(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+
+	_, err := dbDriver.Exec(`
+		INSERT INTO employers (name, addr, amount_salary, status, start_date, end_date) VALUES
+			('active', '0x01', 3100, 'active', NULL, NULL),
+			('on leave', '0x02', 3100, 'on_leave', NULL, NULL),
+			('joiner', '0x03', 3100, 'active', '2026-10-22', NULL),
+			('leaver', '0x04', 3100, 'terminated', NULL, '2026-10-10'),
+			('gone', '0x05', 3100, 'terminated', NULL, '2026-09-30')`)
+	require.NoError(t, err)
+
+	repo := NewSalaryRepository(dbDriver, nil)
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: period, Schedule: string(payroll.Monthly)}))
+
+	salaries, err := repo.ListByStatus(ctx, repository.CreatedStatus)
+	require.NoError(t, err)
+	require.Len(t, salaries, 1)
+
+	payments, err := repo.ListPaymentsBySalaryID(ctx, salaries[0].ID)
+	require.NoError(t, err)
+
+	amounts := make(map[string]int64)
+	for _, p := range payments {
+		amounts[p.Addr] = p.Amount
+	}
+	assert.Equal(t, map[string]int64{"0x01": 3100, "0x03": 1000, "0x04": 1000}, amounts)
+}
+
+func TestSalaryRepository_CreatePeriodOnce(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	month := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: month, Schedule: string(payroll.Monthly)}))
+
+	// the same month and any overlapping range are rejected
+	err := repo.Create(ctx, repository.CreateSalaryParams{Period: month, Schedule: string(payroll.Monthly)})
+	assert.True(t, errors.Is(err, repository.ErrPeriodAlreadyPaid))
+
+	half := payroll.SemiMonthly.PeriodAt(month.End)
+	err = repo.Create(ctx, repository.CreateSalaryParams{Period: half, Schedule: string(payroll.SemiMonthly)})
+	assert.True(t, errors.Is(err, repository.ErrPeriodAlreadyPaid))
+
+	// forcing pays it again, the next month is unaffected
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: month, Schedule: string(payroll.Monthly), Force: true}))
+	next := payroll.MonthPeriod(month.End.AddDate(0, 0, 1))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: next, Schedule: string(payroll.Monthly)}))
+
+	salaries, err := repo.ListByStatus(ctx, repository.CreatedStatus)
+	require.NoError(t, err)
+	require.Len(t, salaries, 3)
+	assert.Equal(t, "2026-10", salaries[0].Period)
+	assert.False(t, salaries[0].Forced)
+	assert.True(t, salaries[1].Forced)
+	assert.Equal(t, "2026-11", salaries[2].Period)
+	assert.Equal(t, "monthly", salaries[2].Schedule)
+}
+
+func TestSalaryRepository_PaymentItems(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+
+	// queued bonus is attached to the next payroll run
+	_, err = repo.AddPayment(ctx, repository.PaymentItem{
+		EmployeeID: 1, Amount: 50, Category: repository.BonusCategory, Memo: "Q3",
+	})
+	require.NoError(t, err)
+
+	_, err = repo.AddPayment(ctx, repository.PaymentItem{EmployeeID: 2, Amount: 50, Category: repository.BonusCategory})
+	assert.ErrorIs(t, err, repository.ErrEmployeeNotFound)
+
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: period, Schedule: string(payroll.Monthly)}))
+
+	payments, err := repo.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+	assert.Equal(t, string(repository.BonusCategory), payments[0].Category)
+	assert.Equal(t, "Q3", payments[0].Memo)
+	assert.Equal(t, "0x01", payments[0].Addr)
+	assert.Equal(t, string(repository.SalaryCategory), payments[1].Category)
+
+	// items can be attached to an unfinished run but not a paid one
+	_, err = repo.AddPayment(ctx, repository.PaymentItem{
+		SalaryID: 1, EmployeeID: 1, Amount: 20, Category: repository.ReimbursementCategory, Memo: "taxi",
+	})
+	require.NoError(t, err)
+
+	salary, err := repo.GetSalary(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.ClaimSalary(ctx, salary, repository.ProcessingStatus))
+	require.NoError(t, repo.UpdateStatusToDone(ctx, 1))
+	_, err = repo.AddPayment(ctx, repository.PaymentItem{
+		SalaryID: 1, EmployeeID: 1, Amount: 20, Category: repository.ReimbursementCategory,
+	})
+	assert.ErrorIs(t, err, repository.ErrSalaryNotFound)
+
+	// standalone items get a run of their own outside any period
+	salaryID, err := repo.CreateAdHoc(ctx, []repository.PaymentItem{
+		{EmployeeID: 1, Amount: 70, Category: repository.AdjustmentCategory, Memo: "fix"},
+	}, false)
+	require.NoError(t, err)
+
+	payments, err = repo.ListPaymentsBySalaryID(ctx, salaryID)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, int64(70), payments[0].Amount)
+
+	salaries, err := repo.ListByStatus(ctx, repository.CreatedStatus)
+	require.NoError(t, err)
+	require.Len(t, salaries, 1)
+	assert.Equal(t, repository.AdHocSchedule, salaries[0].Schedule)
+	assert.Nil(t, salaries[0].PeriodStart)
+}
+
+func TestSalaryRepository_CreateWithDeductions(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+	deductions := NewDeductionRepository(dbDriver)
+
+	_, err := dbDriver.Exec(`
+		INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 2000), ('bob', '0x02', 100)`)
+	require.NoError(t, err)
+
+	for _, d := range []*entity.Deduction{
+		{EmployeeID: 1, Name: "tax", Kind: string(repository.PercentDeduction), Amount: 1000, TreasuryAddr: "0xaa"},
+		{EmployeeID: 1, Name: "loan", Kind: string(repository.FixedDeduction), Amount: 300},
+		{EmployeeID: 2, Name: "loan", Kind: string(repository.FixedDeduction), Amount: 500},
+	} {
+		_, err = deductions.Add(ctx, d)
+		require.NoError(t, err)
+	}
+
+	_, err = deductions.Add(ctx, &entity.Deduction{EmployeeID: 3, Name: "x", Kind: string(repository.FixedDeduction), Amount: 1})
+	assert.ErrorIs(t, err, repository.ErrEmployeeNotFound)
+
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: period, Schedule: string(payroll.Monthly)}))
+
+	payments, err := repo.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+
+	// bob's loan takes his whole salary, so only alice is paid
+	require.Len(t, payments, 2)
+	assert.Equal(t, string(repository.SalaryCategory), payments[0].Category)
+	assert.Equal(t, int64(2000), payments[0].GrossAmount)
+	assert.Equal(t, int64(500), payments[0].DeductionAmount)
+	assert.Equal(t, int64(1500), payments[0].Amount)
+
+	assert.Equal(t, string(repository.WithholdingCategory), payments[1].Category)
+	assert.Equal(t, "0xaa", payments[1].Addr)
+	assert.Equal(t, int64(200), payments[1].Amount)
+	assert.Equal(t, "tax", payments[1].Memo)
+	assert.Equal(t, int64(1), payments[1].EmployeeID)
+
+	list, err := deductions.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.NoError(t, deductions.Deactivate(ctx, list[0].ID))
+
+	list, err = deductions.List(ctx)
+	require.NoError(t, err)
+	assert.False(t, list[0].Active)
+}
+
+func TestSalaryRepository_SpendingHistoryAndReview(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+
+	september := payroll.MonthPeriod(time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC))
+	october := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: september, Schedule: string(payroll.Monthly)}))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: october, Schedule: string(payroll.Monthly)}))
+
+	// september's salary was paid, october's bonus too
+	markPaid(t, repo, 1, 1)
+	bonusID, err := repo.AddPayment(ctx, repository.PaymentItem{SalaryID: 2, EmployeeID: 1, Amount: 300, Category: repository.BonusCategory})
+	require.NoError(t, err)
+	markPaid(t, repo, 2, bonusID)
+
+	history, err := repo.SpendingHistory(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), history.RunSent)
+	assert.Equal(t, map[int64]int64{1: 300}, history.EmployeePeriod)
+	assert.Equal(t, map[int64]int64{1: 1000}, history.PreviousSalary)
+
+	// october's salary is held, approved, and listed again
+	require.NoError(t, repo.UpdatePaymentStatusToNeedsReview(ctx, 2, "too much"))
+	held, err := repo.ListPaymentsByStatus(ctx, repository.NeedsReviewStatus)
+	require.NoError(t, err)
+	require.Len(t, held, 1)
+	assert.Equal(t, "too much", held[0].Error)
+
+	require.NoError(t, repo.ReviewPayment(ctx, 2, true))
+	assert.ErrorIs(t, repo.ReviewPayment(ctx, 2, false), repository.ErrPaymentNotFound)
+
+	payments, err := repo.ListPaymentsBySalaryID(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.True(t, payments[0].Reviewed)
+	assert.Equal(t, string(repository.CreatedStatus), payments[0].Status)
+}
+
+func TestSalaryRepository_Approvals(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	month := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: month, Schedule: string(payroll.Monthly), Draft: true}))
+
+	salary, err := repo.GetSalary(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, string(repository.DraftStatus), salary.Status)
+
+	_, err = repo.GetSalary(ctx, 2)
+	assert.ErrorIs(t, err, repository.ErrSalaryNotFound)
+
+	approval := &entity.Approval{SalaryID: 1, Approver: "0x01", ManifestHash: "0xaa", Signature: "0x1234"}
+	require.NoError(t, repo.AddApproval(ctx, approval))
+	// approving the same manifest again is a no-op
+	require.NoError(t, repo.AddApproval(ctx, approval))
+
+	approvals, err := repo.ListApprovals(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	assert.Equal(t, "0x01", approvals[0].Approver)
+
+	require.NoError(t, repo.UpdateStatusToApproved(ctx, 1))
+	salaries, err := repo.ListByStatus(ctx, repository.ApprovedStatus)
+	require.NoError(t, err)
+	assert.Len(t, salaries, 1)
+}
+
+func TestSalaryRepository_SafeProposals(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000), ('bob', '0x02', 2000)`)
+	require.NoError(t, err)
+
+	month := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: month, Schedule: string(payroll.Monthly)}))
+
+	// a first proposal is replaced, its payments are created again
+	replaced := &entity.SafeProposal{SalaryID: 1, Safe: "0x05af", SafeTxHash: "0xaa", Nonce: 3}
+	id, err := repo.AddSafeProposal(ctx, replaced, []int64{1, 2})
+	require.NoError(t, err)
+
+	proposed, err := repo.ListPaymentsByStatus(ctx, repository.ProposedStatus)
+	require.NoError(t, err)
+	require.Len(t, proposed, 2)
+	assert.Equal(t, "0xaa", proposed[0].SafeTxHash)
+
+	require.NoError(t, repo.ResolveSafeProposal(ctx, id, repository.ReplacedSafeProposal, ""))
+	assert.ErrorIs(t, repo.ResolveSafeProposal(ctx, id, repository.ExecutedSafeProposal, "0xe1"), repository.ErrSafeProposalNotFound)
+
+	created, err := repo.ListPaymentsByStatus(ctx, repository.CreatedStatus)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	assert.Empty(t, created[0].SafeTxHash)
+
+	// the second one is executed
+	id, err = repo.AddSafeProposal(ctx, &entity.SafeProposal{
+		SalaryID: 1, Safe: "0x05af", SafeTxHash: "0xbb", Nonce: 4, Token: "0xtoken", Block: 90, Payload: `{"nonce": 4}`,
+	}, []int64{1, 2})
+	require.NoError(t, err)
+
+	pending, err := repo.ListSafeProposals(ctx, repository.PendingSafeProposal)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, uint64(4), pending[0].Nonce)
+	assert.Equal(t, uint64(90), pending[0].Block)
+	assert.Equal(t, `{"nonce": 4}`, pending[0].Payload)
+
+	require.NoError(t, repo.ResolveSafeProposal(ctx, id, repository.ExecutedSafeProposal, "0xe1"))
+
+	done, err := repo.ListPaymentsByStatus(ctx, repository.DoneStatus)
+	require.NoError(t, err)
+	require.Len(t, done, 2)
+	assert.Equal(t, "0xe1", done[1].TxHash)
+	assert.Equal(t, "0xtoken", done[1].Token)
+	assert.Equal(t, "0x05af", done[1].Payer)
+	assert.NotNil(t, done[1].PaidAt)
+
+	remaining, err := repo.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestSalaryRepository_Exports(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000), ('bob', '0x02', 2000)`)
+	require.NoError(t, err)
+
+	month := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: month, Schedule: string(payroll.Monthly)}))
+
+	require.NoError(t, repo.ExportPayments(ctx, []repository.PaymentExport{
+		{PaymentID: 1, Nonce: 7, SigningHash: "0xaa", Token: "0xtoken", From: "0xoffline"},
+		{PaymentID: 2, Nonce: 8, SigningHash: "0xbb", Token: "0xtoken"},
+	}))
+	// an exported payment is not exported twice
+	assert.ErrorIs(t, repo.ExportPayments(ctx, []repository.PaymentExport{{PaymentID: 1, Nonce: 9, SigningHash: "0xcc"}}),
+		repository.ErrPaymentNotFound)
+
+	exported, err := repo.ListPaymentsByStatus(ctx, repository.ExportedStatus)
+	require.NoError(t, err)
+	require.Len(t, exported, 2)
+	assert.Equal(t, uint64(7), exported[0].Nonce)
+	assert.Equal(t, "0xaa", exported[0].SigningHash)
+
+	// only the exported transaction resolves its payment
+	assert.ErrorIs(t, repo.ResolveExport(ctx, 1, "0xbb", "0xe1", true), repository.ErrExportNotFound)
+
+	require.NoError(t, repo.ResolveExport(ctx, 1, "0xaa", "0xe1", true))
+	require.NoError(t, repo.ResolveExport(ctx, 2, "0xbb", "0xe2", false))
+	assert.ErrorIs(t, repo.ResolveExport(ctx, 2, "0xbb", "0xe2", true), repository.ErrExportNotFound)
+
+	done, err := repo.ListPaymentsByStatus(ctx, repository.DoneStatus)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.Equal(t, "0xe1", done[0].TxHash)
+	assert.Equal(t, "0xaa", done[0].SigningHash)
+	assert.Equal(t, "0xtoken", done[0].Token)
+	assert.Equal(t, "0xoffline", done[0].Payer)
+	assert.NotNil(t, done[0].PaidAt)
+
+	// a failed payment is exported again by the next repay
+	created, err := repo.ListPaymentsByStatus(ctx, repository.CreatedStatus)
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, int64(2), created[0].ID)
+	assert.Empty(t, created[0].SigningHash)
+}
+
+func TestSalaryRepository_Claims(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: period, Schedule: string(payroll.Monthly)}))
+
+	// two runs read the same salary, only the first claims it
+	first, err := repo.GetSalary(ctx, 1)
+	require.NoError(t, err)
+	second, err := repo.GetSalary(ctx, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.ClaimSalary(ctx, first, repository.ProcessingStatus))
+	assert.Equal(t, string(repository.ProcessingStatus), first.Status)
+	assert.Equal(t, int64(1), first.Version)
+	assert.ErrorIs(t, repo.ClaimSalary(ctx, second, repository.ProcessingStatus), repository.ErrStatusConflict)
+
+	// a salary in processing is claimed again by repay, once
+	second, err = repo.GetSalary(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.ClaimSalary(ctx, second, repository.ProcessingStatus))
+	assert.ErrorIs(t, repo.ClaimSalary(ctx, first, repository.ProcessingStatus), repository.ErrStatusConflict)
+
+	// so is a payment
+	payments, err := repo.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	again, err := repo.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.ClaimPaymentSend(ctx, payments[0], testSigned))
+	assert.ErrorIs(t, repo.ClaimPayment(ctx, again[0], repository.ProcessingStatus), repository.ErrStatusConflict)
+
+	// the transaction is recorded with the claim, before it is sent
+	sent, err := repo.ListPaymentsByStatus(ctx, repository.ProcessingStatus)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "0xt1", sent[0].TxHash)
+	assert.Equal(t, "0xsigner", sent[0].Payer)
+	assert.Equal(t, uint64(7), sent[0].Nonce)
+	assert.Equal(t, "0x01", sent[0].SignedTx)
+
+	require.NoError(t, repo.CompletePayment(ctx, payments[0], testTransfer))
+	assert.Equal(t, string(repository.DoneStatus), payments[0].Status)
+
+	done, err := repo.ListPaymentsByStatus(ctx, repository.DoneStatus)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.Equal(t, "0xt1", done[0].TxHash)
+	assert.Equal(t, "0xtoken", done[0].Token)
+	assert.Equal(t, "0xsigner", done[0].Payer)
+	assert.Equal(t, int64(50_000), done[0].GasUsed)
+	assert.Equal(t, int64(1_000_000), done[0].GasFee)
+	assert.NotNil(t, done[0].PaidAt)
+
+	// changes outside the status graph are refused
+	assert.ErrorIs(t, repo.ClaimPayment(ctx, payments[0], repository.ProcessingStatus), repository.ErrInvalidTransition)
+	_, err = repo.AddSafeProposal(ctx, &entity.SafeProposal{SalaryID: 1, Safe: "0xsafe", SafeTxHash: "0x01"}, []int64{payments[0].ID})
+	assert.ErrorIs(t, err, repository.ErrStatusConflict)
+
+	require.NoError(t, repo.UpdateStatusToDone(ctx, 1))
+	assert.ErrorIs(t, repo.UpdateStatusToApproved(ctx, 1), repository.ErrInvalidTransition)
+	assert.ErrorIs(t, repo.UpdateStatusToDone(ctx, 2), repository.ErrSalaryNotFound)
+}
+
+func TestSalaryRepository_ClaimLeases(t *testing.T) {
+	dbDriver := newTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	first := &salaryRepository{db: dbDriver, owner: "first", now: clock}
+	second := &salaryRepository{db: dbDriver, owner: "second", now: clock}
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000)`)
+	require.NoError(t, err)
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, first.Create(ctx, repository.CreateSalaryParams{Period: period, Schedule: string(payroll.Monthly)}))
+
+	// two processes race to claim the same payment, only one wins
+	repos := []*salaryRepository{first, second}
+	errs := make([]error, len(repos))
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		payments, err := repo.ListPaymentsBySalaryID(ctx, 1)
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func(i int, repo *salaryRepository, payment *entity.Payment) {
+			defer wg.Done()
+			errs[i] = repo.ClaimPaymentSend(ctx, payment, testSigned)
+		}(i, repo, payments[0])
+	}
+	wg.Wait()
+
+	winner, loser := first, second
+	if errs[0] != nil {
+		winner, loser = second, first
+		errs[0], errs[1] = errs[1], errs[0]
+	}
+	require.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], repository.ErrStatusConflict)
+
+	// the loser cannot claim the payment in processing again while the lease
+	// of the winner is live, even reading it afresh
+	payments, err := loser.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, loser.ClaimPayment(ctx, payments[0], repository.ProcessingStatus), repository.ErrStatusConflict)
+
+	// the winner can, as repay does after an interruption
+	payments, err = winner.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, winner.ClaimPayment(ctx, payments[0], repository.ProcessingStatus))
+
+	// the loser can once the lease expired
+	now = now.Add(claimLease + time.Second)
+	payments, err = loser.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, loser.ClaimPayment(ctx, payments[0], repository.ProcessingStatus))
+
+	// a payment in processing without a recorded transaction is not claimed
+	// again, even by its owner, and is held for review instead
+	_, err = dbDriver.Exec(`UPDATE payments SET signed_tx = NULL`)
+	require.NoError(t, err)
+	payments, err = loser.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, loser.ClaimPayment(ctx, payments[0], repository.ProcessingStatus), repository.ErrStatusConflict)
+	require.NoError(t, loser.UpdatePaymentStatusToNeedsReview(ctx, payments[0].ID, "interrupted"))
+	payments, err = loser.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, string(repository.NeedsReviewStatus), payments[0].Status)
+	_, err = dbDriver.Exec(`UPDATE payments SET status = 'processing', signed_tx = '0x01'`)
+	require.NoError(t, err)
+
+	// the same holds for salaries, and a salary claimed by another process
+	// keeps its payments from being claimed
+	salary, err := first.GetSalary(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, first.ClaimSalary(ctx, salary, repository.ProcessingStatus))
+	salary, err = second.GetSalary(ctx, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, second.ClaimSalary(ctx, salary, repository.ProcessingStatus), repository.ErrStatusConflict)
+
+	now = now.Add(claimLease + time.Second)
+	payments, err = first.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, first.ClaimPayment(ctx, payments[0], repository.ProcessingStatus))
+
+	// claiming the payment renewed the lease on the salary
+	now = now.Add(time.Minute)
+	payments, err = second.ListPaymentsBySalaryID(ctx, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, second.ClaimPayment(ctx, payments[0], repository.ProcessingStatus), repository.ErrStatusConflict)
+	salary, err = second.GetSalary(ctx, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, second.ClaimSalary(ctx, salary, repository.ProcessingStatus), repository.ErrStatusConflict)
+}
+
+func TestSalaryRepository_MalformedRows(t *testing.T) {
+	dbDriver := newSQLiteTestDB(t)
+	ctx := context.Background()
+	repo := NewSalaryRepository(dbDriver, nil)
+
+	_, err := dbDriver.Exec(`INSERT INTO employers (name, addr, amount_salary) VALUES ('alice', '0x01', 1000), ('bob', '0x02', 2000)`)
+	require.NoError(t, err)
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, repo.Create(ctx, repository.CreateSalaryParams{Period: period, Schedule: string(payroll.Monthly)}))
+
+	// a bad payment fails the run instead of being left out of it
+	_, err = dbDriver.Exec(`UPDATE payments SET amount = 'lots' WHERE id = 2`)
+	require.NoError(t, err)
+
+	_, err = repo.ListPaymentsBySalaryID(ctx, 1)
+	var scanErr *repository.ScanError
+	require.ErrorAs(t, err, &scanErr)
+	require.Len(t, scanErr.Rows, 1)
+	assert.Equal(t, "payments", scanErr.Rows[0].Table)
+	assert.Equal(t, int64(2), scanErr.Rows[0].ID)
+	assert.ErrorIs(t, err, repository.ErrMalformedRows)
+
+	_, err = repo.ListPaymentsByStatus(ctx, repository.CreatedStatus)
+	assert.ErrorIs(t, err, repository.ErrMalformedRows)
+
+	// so does a bad salary
+	_, err = dbDriver.Exec(`UPDATE salaries SET forced = 'maybe' WHERE id = 1`)
+	require.NoError(t, err)
+
+	_, err = repo.ListByStatus(ctx, repository.CreatedStatus)
+	assert.ErrorIs(t, err, repository.ErrMalformedRows)
+	_, err = repo.GetSalary(ctx, 1)
+	assert.ErrorIs(t, err, repository.ErrMalformedRows)
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"database/sql"
+
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// malformedRows collects the rows of a query that could not be read, so that
+// the query fails listing all of them rather than leaving them out
+type malformedRows []*repository.RowError
+
+// add records the error of a row, as returned by a scan function
+func (m *malformedRows) add(err error) {
+	rowErr, ok := err.(*repository.RowError)
+	if !ok {
+		rowErr = &repository.RowError{Err: err}
+	}
+	*m = append(*m, rowErr)
+}
+
+// err returns the error of rows, or a *repository.ScanError listing the
+// malformed rows
+func (m malformedRows) err(rows *sql.Rows) error {
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(m) > 0 {
+		return &repository.ScanError{Rows: m}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// statusTable is a table whose rows move through a status graph. Every
+// status change increments the row's version, so that a change made with a
+// compare-and-set on both fails if the row changed since it was read.
+type statusTable struct {
+	name     string
+	kind     string
+	check    func(from, to repository.PaymentStatus) error
+	notFound error
+	// attempt is the column recording the attempt to process a row, which
+	// a row in processing needs to be claimed again, if any
+	attempt string
+}
+
+// claimLease is how long a claim for processing keeps other River processes
+// from claiming a salary or payment again. A process stalled for longer
+// loses its claims; claiming a payment renews the claim on its salary.
+const claimLease = time.Hour
+
+var (
+	salaryStatuses  = statusTable{"salaries", "salary", repository.CheckSalaryTransition, repository.ErrSalaryNotFound, ""}
+	paymentStatuses = statusTable{"payments", "payment", repository.CheckPaymentTransition, repository.ErrPaymentNotFound, "signed_tx"}
+)
+
+// current returns the status and version of row id
+func (t statusTable) current(ctx context.Context, tx *sql.Tx, id int64) (repository.PaymentStatus, int64, error) {
+	var status sql.NullString
+	var version int64
+	err := tx.QueryRowContext(ctx, `SELECT status, version FROM `+t.name+` WHERE id = $1`, id).Scan(&status, &version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, fmt.Errorf("%w: %d", t.notFound, id)
+	}
+
+	return repository.PaymentStatus(status.String), version, err
+}
+
+// set moves row id from status from to status to, if the status graph allows
+// it and the row is still at version
+func (t statusTable) set(ctx context.Context, tx *sql.Tx, id int64, from repository.PaymentStatus, version int64, to repository.PaymentStatus) error {
+	if err := t.check(from, to); err != nil {
+		return fmt.Errorf("%s %d: %w", t.kind, id, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE `+t.name+` SET status = $1, version = version + 1
+		WHERE id = $2 AND status = $3 AND version = $4`, to, id, from, version)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %s %d is no longer %s", repository.ErrStatusConflict, t.kind, id, from)
+	}
+
+	return nil
+}
+
+// claim moves row id like set, recording owner as processing it until the
+// lease expires. A row in processing is only claimed again by its owner, or
+// once the lease of its owner expired, so that concurrent runs never process
+// the same row. In tables recording attempts, it is only claimed again once
+// its attempt was recorded, so that it is resumed rather than processed
+// again. Timestamps are stored in UTC with second precision so that they
+// compare correctly as text in SQLite.
+func (t statusTable) claim(ctx context.Context, tx *sql.Tx, id int64, from repository.PaymentStatus, version int64, to repository.PaymentStatus, owner string, now time.Time) error {
+	if err := t.check(from, to); err != nil {
+		return fmt.Errorf("%s %d: %w", t.kind, id, err)
+	}
+
+	attempted := ``
+	if t.attempt != "" {
+		attempted = ` AND ` + t.attempt + ` IS NOT NULL`
+	}
+
+	now = now.UTC().Truncate(time.Second)
+	res, err := tx.ExecContext(ctx, `
+		UPDATE `+t.name+` SET status = $1, version = version + 1, claimed_by = $2, claim_expires_at = $3
+		WHERE id = $4 AND status = $5 AND version = $6
+			AND (status != $7 OR (claimed_by IS NULL OR claimed_by = $2 OR claim_expires_at < $8)`+attempted+`)`,
+		to, owner, now.Add(claimLease), id, from, version, repository.ProcessingStatus, now)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %s %d is no longer %s, or another process is processing it", repository.ErrStatusConflict, t.kind, id, from)
+	}
+
+	return nil
+}
+
+// claimOwner returns a name for the claims of this process, unique across
+// machines and restarts
+func claimOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano())
+}
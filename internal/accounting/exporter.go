@@ -0,0 +1,136 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownExporter is returned for ledger formats no exporter is registered for
+var ErrUnknownExporter = errors.New("unknown accounting format")
+
+// Exporter writes journal entries in the format of a ledger
+type Exporter interface {
+	Export(w io.Writer, entries []*Entry) error
+}
+
+// exporters are the registered exporters by format name
+var exporters = map[string]Exporter{
+	"csv":  CSVExporter{},
+	"json": JSONExporter{},
+}
+
+// Register makes an exporter available under a format name, replacing any
+// registered under it. It is meant to be called from init functions.
+func Register(format string, exporter Exporter) {
+	exporters[strings.ToLower(format)] = exporter
+}
+
+// Lookup returns the exporter registered under a format name
+func Lookup(format string) (Exporter, error) {
+	exporter, ok := exporters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownExporter, format, strings.Join(Formats(), ", "))
+	}
+	return exporter, nil
+}
+
+// Formats returns the names of the registered exporters
+func Formats() []string {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// dateLayout is how entries are dated in exports
+const dateLayout = "2006-01-02"
+
+// CSVExporter writes one row per journal line, repeating the date,
+// reference and description of its entry
+type CSVExporter struct{}
+
+func (CSVExporter) Export(w io.Writer, entries []*Entry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"date", "reference", "description", "account", "currency", "debit", "credit"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		for _, line := range entry.Lines {
+			err := cw.Write([]string{
+				entry.Date.UTC().Format(dateLayout), entry.Reference, entry.Description,
+				line.Account, line.Currency.Code, amount(line.Currency, line.Debit), amount(line.Currency, line.Credit),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// JSONExporter writes a generic double-entry journal:
+//
+//	{"entries": [{"date": ..., "reference": ..., "description": ...,
+//	  "lines": [{"account": ..., "currency": ..., "debit": "1.500000"}]}]}
+type JSONExporter struct{}
+
+type jsonJournal struct {
+	Entries []jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+	Date        string     `json:"date"`
+	Reference   string     `json:"reference"`
+	Description string     `json:"description"`
+	Lines       []jsonLine `json:"lines"`
+}
+
+type jsonLine struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Debit    string `json:"debit,omitempty"`
+	Credit   string `json:"credit,omitempty"`
+}
+
+func (JSONExporter) Export(w io.Writer, entries []*Entry) error {
+	journal := jsonJournal{Entries: make([]jsonEntry, 0, len(entries))}
+	for _, entry := range entries {
+		e := jsonEntry{
+			Date:        entry.Date.UTC().Format(dateLayout),
+			Reference:   entry.Reference,
+			Description: entry.Description,
+		}
+		for _, line := range entry.Lines {
+			e.Lines = append(e.Lines, jsonLine{
+				Account:  line.Account,
+				Currency: line.Currency.Code,
+				Debit:    amount(line.Currency, line.Debit),
+				Credit:   amount(line.Currency, line.Credit),
+			})
+		}
+		journal.Entries = append(journal.Entries, e)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(journal)
+}
+
+// amount formats a debit or credit, empty if zero
+func amount(currency Currency, v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return currency.Format(v)
+}
@@ -0,0 +1,75 @@
+package accounting
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntries() []*Entry {
+	return []*Entry{{
+		Date:        time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC),
+		Reference:   "0xt1",
+		Description: "salary 2026-10 to alice, payment 1",
+		Lines: []Line{
+			{Account: "Salaries", Currency: usdcCurrency, Debit: 1_500_000},
+			{Account: "Wallet 0xsigner", Currency: usdcCurrency, Credit: 1_500_000},
+		},
+	}}
+}
+
+func TestCSVExporter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, CSVExporter{}.Export(&buf, testEntries()))
+
+	assert.Equal(t, ""+
+		"date,reference,description,account,currency,debit,credit\n"+
+		"2026-10-31,0xt1,\"salary 2026-10 to alice, payment 1\",Salaries,USDC,1.500000,\n"+
+		"2026-10-31,0xt1,\"salary 2026-10 to alice, payment 1\",Wallet 0xsigner,USDC,,1.500000\n", buf.String())
+}
+
+func TestJSONExporter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, JSONExporter{}.Export(&buf, testEntries()))
+
+	assert.JSONEq(t, `{"entries": [{
+		"date": "2026-10-31",
+		"reference": "0xt1",
+		"description": "salary 2026-10 to alice, payment 1",
+		"lines": [
+			{"account": "Salaries", "currency": "USDC", "debit": "1.500000"},
+			{"account": "Wallet 0xsigner", "currency": "USDC", "credit": "1.500000"}
+		]
+	}]}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, JSONExporter{}.Export(&buf, nil))
+	assert.JSONEq(t, `{"entries": []}`, buf.String())
+}
+
+type entryCounter struct{}
+
+func (entryCounter) Export(w io.Writer, entries []*Entry) error {
+	_, err := w.Write([]byte{byte('0' + len(entries))})
+	return err
+}
+
+func TestRegister(t *testing.T) {
+	_, err := Lookup("xero")
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+
+	Register("Xero", entryCounter{})
+	defer delete(exporters, "xero")
+
+	exporter, err := Lookup("xero")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"csv", "json", "xero"}, Formats())
+
+	var buf bytes.Buffer
+	require.NoError(t, exporter.Export(&buf, testEntries()))
+	assert.Equal(t, "1", buf.String())
+}
@@ -0,0 +1,196 @@
+package accounting
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// Currency is what the amounts of a journal line are counted in. Amounts are
+// in its smallest unit, e.g. wei for ETH.
+type Currency struct {
+	Code     string
+	Decimals int
+}
+
+// Format returns amount in the currency's units, e.g. 1.500000 for 1500000
+// with 6 decimals
+func (c Currency) Format(amount int64) string {
+	if c.Decimals <= 0 {
+		return fmt.Sprint(amount)
+	}
+
+	rat := new(big.Rat).SetFrac(big.NewInt(amount), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.Decimals)), nil))
+	return rat.FloatString(c.Decimals)
+}
+
+// Accounts names the ledger accounts payments are booked to
+type Accounts struct {
+	Salaries       string `json:"salaries"`
+	Bonuses        string `json:"bonuses"`
+	Reimbursements string `json:"reimbursements"`
+	Adjustments    string `json:"adjustments"`
+	// Withholdings holds the deductions withheld from salaries until they
+	// are paid to the treasury
+	Withholdings string `json:"withholdings"`
+	GasFees      string `json:"gas_fees"`
+	// Wallet is the account of a paying wallet; %s is replaced by its address
+	Wallet string `json:"wallet"`
+}
+
+// DefaultAccounts returns the accounts used unless overridden
+func DefaultAccounts() Accounts {
+	return Accounts{
+		Salaries:       "Salaries",
+		Bonuses:        "Bonuses",
+		Reimbursements: "Reimbursements",
+		Adjustments:    "Salary Adjustments",
+		Withholdings:   "Withholdings Payable",
+		GasFees:        "Gas Fees",
+		Wallet:         "Wallet %s",
+	}
+}
+
+// LoadAccounts reads a JSON object of account names from path, e.g.
+// {"salaries": "477"}, keeping the defaults of the accounts it leaves out
+func LoadAccounts(path string) (Accounts, error) {
+	accounts := DefaultAccounts()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return accounts, err
+	}
+
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return accounts, fmt.Errorf("invalid accounts file %s: %w", path, err)
+	}
+	return accounts, nil
+}
+
+// wallet returns the account of the wallet at addr
+func (a Accounts) wallet(addr string) string {
+	if addr == "" {
+		addr = "unknown"
+	}
+	if !strings.Contains(a.Wallet, "%s") {
+		return a.Wallet
+	}
+	return fmt.Sprintf(a.Wallet, addr)
+}
+
+// expense returns the account a payment of category is booked to
+func (a Accounts) expense(category repository.PaymentCategory) string {
+	switch category {
+	case repository.BonusCategory:
+		return a.Bonuses
+	case repository.ReimbursementCategory:
+		return a.Reimbursements
+	case repository.AdjustmentCategory:
+		return a.Adjustments
+	case repository.WithholdingCategory:
+		return a.Withholdings
+	}
+	return a.Salaries
+}
+
+// Line debits or credits an account
+type Line struct {
+	Account  string
+	Currency Currency
+	Debit    int64
+	Credit   int64
+}
+
+// Entry is a journal entry. Its lines balance in every currency.
+type Entry struct {
+	Date time.Time
+	// Reference is the transaction that sent the payment
+	Reference   string
+	Description string
+	Lines       []Line
+}
+
+// Journal books paid payments as journal entries
+type Journal struct {
+	Accounts Accounts
+	// Tokens are the currencies of the tokens payments are sent in, by
+	// contract address; unknown tokens are counted in their smallest unit
+	Tokens map[string]Currency
+	// DefaultToken is the token of payments sent before River recorded it
+	DefaultToken string
+	// Native is the currency gas is paid in
+	Native  Currency
+	Entries []*Entry
+}
+
+// Book adds the entry of a paid payment: its amount is an expense paid from
+// the wallet that sent it, less the deductions withheld, and its gas if River
+// sent it is a fee paid from the same wallet
+func (j *Journal) Book(record *repository.PaymentRecord) {
+	p := record.Payment
+	token := j.Currency(p.Token)
+	wallet := j.Accounts.wallet(p.Payer)
+
+	entry := &Entry{
+		Reference:   p.TxHash,
+		Description: fmt.Sprintf("%s %s to %s, payment %d", p.Category, record.Period, record.Employee, p.ID),
+	}
+	if entry.Reference == "" {
+		entry.Reference = fmt.Sprintf("payment %d", p.ID)
+	}
+	switch {
+	case p.PaidAt != nil:
+		entry.Date = *p.PaidAt
+	case p.CreateAt != nil:
+		entry.Date = *p.CreateAt
+	}
+
+	entry.Lines = append(entry.Lines, Line{
+		Account:  j.Accounts.expense(repository.PaymentCategory(p.Category)),
+		Currency: token,
+		Debit:    p.GrossAmount,
+	}, Line{
+		Account:  wallet,
+		Currency: token,
+		Credit:   p.Amount,
+	})
+	if p.DeductionAmount > 0 {
+		entry.Lines = append(entry.Lines, Line{
+			Account:  j.Accounts.Withholdings,
+			Currency: token,
+			Credit:   p.DeductionAmount,
+		})
+	}
+
+	if p.GasFee > 0 {
+		entry.Lines = append(entry.Lines, Line{
+			Account:  j.Accounts.GasFees,
+			Currency: j.Native,
+			Debit:    p.GasFee,
+		}, Line{
+			Account:  wallet,
+			Currency: j.Native,
+			Credit:   p.GasFee,
+		})
+	}
+
+	j.Entries = append(j.Entries, entry)
+}
+
+// Currency returns the currency of the token at addr
+func (j *Journal) Currency(addr string) Currency {
+	if addr == "" {
+		addr = j.DefaultToken
+	}
+	for contract, currency := range j.Tokens {
+		if strings.EqualFold(contract, addr) {
+			return currency
+		}
+	}
+	return Currency{Code: addr}
+}
@@ -0,0 +1,105 @@
+package accounting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+const usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+
+var (
+	usdcCurrency = Currency{Code: "USDC", Decimals: 6}
+	ethCurrency  = Currency{Code: "ETH", Decimals: 18}
+)
+
+func testJournal() *Journal {
+	return &Journal{
+		Accounts:     DefaultAccounts(),
+		Tokens:       map[string]Currency{usdc: usdcCurrency},
+		DefaultToken: usdc,
+		Native:       ethCurrency,
+	}
+}
+
+func TestCurrency_Format(t *testing.T) {
+	assert.Equal(t, "1.500000", usdcCurrency.Format(1_500_000))
+	assert.Equal(t, "0.000021000000000000", ethCurrency.Format(21_000_000_000_000))
+	assert.Equal(t, "-0.010000", usdcCurrency.Format(-10_000))
+	assert.Equal(t, "42", Currency{Code: "0xtoken"}.Format(42))
+}
+
+func TestJournal_Book(t *testing.T) {
+	paidAt := time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC)
+	j := testJournal()
+
+	// a salary sent by River, with a deduction withheld
+	j.Book(&repository.PaymentRecord{
+		Employee: "alice",
+		Period:   "2026-10",
+		Payment: &entity.Payment{
+			ID: 1, Category: "salary", GrossAmount: 1_000_000_000, DeductionAmount: 100_000_000, Amount: 900_000_000,
+			Token: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Payer: "0xsigner", TxHash: "0xt1",
+			GasFee: 21_000_000_000_000, PaidAt: &paidAt,
+		},
+	})
+	// a reimbursement paid before tokens and payers were recorded
+	j.Book(&repository.PaymentRecord{
+		Employee: "bob",
+		Period:   "2026-10",
+		Payment:  &entity.Payment{ID: 2, Category: "reimbursement", GrossAmount: 50, Amount: 50, CreateAt: &paidAt},
+	})
+
+	require.Len(t, j.Entries, 2)
+
+	salary := j.Entries[0]
+	assert.Equal(t, paidAt, salary.Date)
+	assert.Equal(t, "0xt1", salary.Reference)
+	assert.Equal(t, "salary 2026-10 to alice, payment 1", salary.Description)
+	assert.Equal(t, []Line{
+		{Account: "Salaries", Currency: usdcCurrency, Debit: 1_000_000_000},
+		{Account: "Wallet 0xsigner", Currency: usdcCurrency, Credit: 900_000_000},
+		{Account: "Withholdings Payable", Currency: usdcCurrency, Credit: 100_000_000},
+		{Account: "Gas Fees", Currency: ethCurrency, Debit: 21_000_000_000_000},
+		{Account: "Wallet 0xsigner", Currency: ethCurrency, Credit: 21_000_000_000_000},
+	}, salary.Lines)
+
+	reimbursement := j.Entries[1]
+	assert.Equal(t, "payment 2", reimbursement.Reference)
+	assert.Equal(t, []Line{
+		{Account: "Reimbursements", Currency: usdcCurrency, Debit: 50},
+		{Account: "Wallet unknown", Currency: usdcCurrency, Credit: 50},
+	}, reimbursement.Lines)
+
+	// every entry balances in every currency
+	for _, entry := range j.Entries {
+		balance := make(map[string]int64)
+		for _, line := range entry.Lines {
+			balance[line.Currency.Code] += line.Debit - line.Credit
+		}
+		for code, b := range balance {
+			assert.Zero(t, b, "%s of %s", code, entry.Reference)
+		}
+	}
+}
+
+func TestLoadAccounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"salaries": "477", "wallet": "610"}`), 0o600))
+
+	accounts, err := LoadAccounts(path)
+	require.NoError(t, err)
+	assert.Equal(t, "477", accounts.Salaries)
+	assert.Equal(t, "Gas Fees", accounts.GasFees)
+	assert.Equal(t, "610", accounts.wallet("0xsigner"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"salaries": 477}`), 0o600))
+	_, err = LoadAccounts(path)
+	assert.Error(t, err)
+}
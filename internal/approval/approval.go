@@ -0,0 +1,155 @@
+// Package approval implements the multi-person approval of payroll runs.
+// Approvers sign the keccak256 hash of a run's manifest with EIP-191
+// personal_sign; a run is approved once enough distinct configured approvers
+// signed its current manifest.
+package approval
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// manifestVersion is the first line of every manifest
+const manifestVersion = "river payroll manifest v1"
+
+var (
+	// ErrNotApproved is returned for payroll runs without enough valid approvals
+	ErrNotApproved = errors.New("payroll run is not approved")
+	// ErrUnknownApprover is returned for signatures of accounts that are not approvers
+	ErrUnknownApprover = errors.New("signature is not from a configured approver")
+)
+
+// Policy lists who may approve payroll runs and how many must
+type Policy struct {
+	Approvers []common.Address
+	Required  int
+}
+
+// NewPolicy parses the approver addresses. Required defaults to two, or to
+// every approver when there are fewer.
+func NewPolicy(approvers []string, required int) (Policy, error) {
+	policy := Policy{Required: required}
+
+	seen := make(map[common.Address]bool)
+	for _, addr := range approvers {
+		addr = strings.TrimSpace(addr)
+		if !common.IsHexAddress(addr) {
+			return Policy{}, fmt.Errorf("invalid approver address %q", addr)
+		}
+
+		a := common.HexToAddress(addr)
+		if !seen[a] {
+			seen[a] = true
+			policy.Approvers = append(policy.Approvers, a)
+		}
+	}
+
+	if len(policy.Approvers) == 0 {
+		if required > 0 {
+			return Policy{}, errors.New("approvals required but no approvers configured")
+		}
+		return policy, nil
+	}
+
+	if policy.Required == 0 {
+		policy.Required = 2
+		if len(policy.Approvers) < 2 {
+			policy.Required = len(policy.Approvers)
+		}
+	}
+
+	if policy.Required < 0 || policy.Required > len(policy.Approvers) {
+		return Policy{}, fmt.Errorf("cannot require %d approvals from %d approvers", policy.Required, len(policy.Approvers))
+	}
+
+	return policy, nil
+}
+
+// Enabled reports whether payroll runs need approval
+func (p Policy) Enabled() bool {
+	return p.Required > 0
+}
+
+// Manifest describes what a payroll run pays, one payment per line, in a
+// form approvers can read and reproduce
+func Manifest(salary *entity.Salary, payments []*entity.Payment) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, manifestVersion)
+	fmt.Fprintf(&b, "salary %d period %s schedule %s\n", salary.ID, salary.Period, salary.Schedule)
+
+	for _, p := range payments {
+		addr := p.Addr
+		if common.IsHexAddress(addr) {
+			addr = common.HexToAddress(addr).Hex()
+		}
+		fmt.Fprintf(&b, "payment %d employee %d to %s amount %d category %s\n",
+			p.ID, p.EmployeeID, addr, p.Amount, p.Category)
+	}
+
+	return b.String()
+}
+
+// Hash returns the hash approvers sign
+func Hash(manifest string) common.Hash {
+	return crypto.Keccak256Hash([]byte(manifest))
+}
+
+// Recover returns the account that signed hash with personal_sign
+func Recover(hash common.Hash, signature []byte) (common.Address, error) {
+	return signer.RecoverText(hash.Bytes(), signature)
+}
+
+// Approver returns the approver that signed hash
+func (p Policy) Approver(hash common.Hash, signature []byte) (common.Address, error) {
+	addr, err := Recover(hash, signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	if !p.isApprover(addr) {
+		return common.Address{}, fmt.Errorf("%w: %s", ErrUnknownApprover, addr.Hex())
+	}
+
+	return addr, nil
+}
+
+// Count returns the number of distinct approvers with a valid signature of hash
+func (p Policy) Count(hash common.Hash, approvals []*entity.Approval) int {
+	approved := make(map[common.Address]bool)
+
+	for _, a := range approvals {
+		if common.HexToHash(a.ManifestHash) != hash {
+			continue
+		}
+
+		sig, err := hexutil.Decode(a.Signature)
+		if err != nil {
+			continue
+		}
+
+		addr, err := p.Approver(hash, sig)
+		if err != nil || addr != common.HexToAddress(a.Approver) {
+			continue
+		}
+
+		approved[addr] = true
+	}
+
+	return len(approved)
+}
+
+func (p Policy) isApprover(addr common.Address) bool {
+	for _, a := range p.Approvers {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package approval
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+)
+
+func sign(t *testing.T, hexKey string, hash common.Hash) (common.Address, []byte) {
+	t.Helper()
+
+	key, err := crypto.HexToECDSA(hexKey)
+	require.NoError(t, err)
+
+	sig, err := crypto.Sign(accounts.TextHash(hash.Bytes()), key)
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] += 27
+
+	return crypto.PubkeyToAddress(key.PublicKey), sig
+}
+
+func TestNewPolicy(t *testing.T) {
+	a := "0x00000000000000000000000000000000000000aa"
+	b := "0x00000000000000000000000000000000000000bb"
+
+	policy, err := NewPolicy(nil, 0)
+	require.NoError(t, err)
+	assert.False(t, policy.Enabled())
+
+	policy, err = NewPolicy([]string{a, b, a}, 0)
+	require.NoError(t, err)
+	assert.Len(t, policy.Approvers, 2)
+	assert.Equal(t, 2, policy.Required)
+
+	policy, err = NewPolicy([]string{a}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, policy.Required)
+
+	_, err = NewPolicy([]string{a}, 2)
+	assert.Error(t, err)
+
+	_, err = NewPolicy(nil, 1)
+	assert.Error(t, err)
+
+	_, err = NewPolicy([]string{"0xnope"}, 1)
+	assert.Error(t, err)
+}
+
+func TestPolicy_Count(t *testing.T) {
+	salary := &entity.Salary{ID: 3, Period: "2026-10", Schedule: "monthly"}
+	payments := []*entity.Payment{
+		{ID: 10, EmployeeID: 1, Addr: "0x00000000000000000000000000000000000000aa", Amount: 1000, Category: "salary"},
+	}
+	manifest := Manifest(salary, payments)
+	assert.Contains(t, manifest, "payment 10 employee 1 to 0x00000000000000000000000000000000000000AA amount 1000 category salary")
+	hash := Hash(manifest)
+
+	alice, aliceSig := sign(t, "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291", hash)
+	bob, bobSig := sign(t, "8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a", hash)
+	_, mallorySig := sign(t, "49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee", hash)
+
+	policy := Policy{Approvers: []common.Address{alice, bob}, Required: 2}
+
+	addr, err := policy.Approver(hash, aliceSig)
+	require.NoError(t, err)
+	assert.Equal(t, alice, addr)
+
+	_, err = policy.Approver(hash, mallorySig)
+	assert.ErrorIs(t, err, ErrUnknownApprover)
+
+	approval := func(addr common.Address, sig []byte, hash common.Hash) *entity.Approval {
+		return &entity.Approval{Approver: addr.Hex(), ManifestHash: hash.Hex(), Signature: hexutil.Encode(sig)}
+	}
+
+	// the same approver twice counts once
+	assert.Equal(t, 1, policy.Count(hash, []*entity.Approval{approval(alice, aliceSig, hash), approval(alice, aliceSig, hash)}))
+	assert.Equal(t, 2, policy.Count(hash, []*entity.Approval{approval(alice, aliceSig, hash), approval(bob, bobSig, hash)}))
+
+	// a changed manifest invalidates earlier approvals
+	payments[0].Amount = 1000000
+	changed := Hash(Manifest(salary, payments))
+	assert.Equal(t, 0, policy.Count(changed, []*entity.Approval{approval(alice, aliceSig, hash), approval(bob, bobSig, hash)}))
+
+	// a signature claimed by another approver does not count
+	assert.Equal(t, 0, policy.Count(hash, []*entity.Approval{approval(bob, aliceSig, hash)}))
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+)
+
+// DefaultActor is recorded for changes made without an actor in the context
+const DefaultActor = "river"
+
+// ErrTampered is returned when the audit log does not match its hash chain
+var ErrTampered = errors.New("audit log tampered")
+
+type actorKey struct{}
+
+// WithActor returns a context recording changes made with it as done by actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns who the changes made with ctx are recorded as done by
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return DefaultActor
+}
+
+// Hash returns the hash sealing an entry after the entry with hash prev. It
+// covers every field of the entry but its row ID.
+func Hash(prev string, e *entity.AuditEntry) string {
+	fields, _ := json.Marshal([]interface{}{
+		e.Seq,
+		prev,
+		e.At.UTC().Format(time.RFC3339),
+		e.Actor,
+		e.Action,
+		e.Entity,
+		e.EntityID,
+		e.Details,
+	})
+
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:])
+}
+
+// Report is the outcome of verifying the audit log
+type Report struct {
+	// Sealed entries are part of the hash chain
+	Sealed int
+	// Unsealed entries were recorded by the database and are sealed by the next change
+	Unsealed int
+	// Head is the last sealed entry, to compare with an anchor kept elsewhere
+	Head *entity.AuditEntry
+	// Problems describe where the chain is broken
+	Problems []string
+}
+
+// Err returns ErrTampered with the problems found, if any
+func (r *Report) Err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrTampered, strings.Join(r.Problems, "; "))
+}
+
+// Verify checks the chain of the entries ordered by seq. Entries are
+// numbered as they are recorded, sealed or not, so deleted ones leave a gap
+// in the sequence, and edited ones no longer match their hash. Deleting the
+// last entries is only detected against an anchor.
+func Verify(entries []*entity.AuditEntry) *Report {
+	report := &Report{}
+
+	var last int64
+	var prev *entity.AuditEntry
+	for _, e := range entries {
+		switch {
+		case e.Seq == 0:
+			report.problem("entry %d has no sequence number", e.ID)
+		case last == 0 && e.Seq != 1:
+			report.problem("entries 1 to %d are missing", e.Seq-1)
+		case last != 0 && e.Seq != last+1:
+			report.problem("entries %d to %d are missing or out of order", last+1, e.Seq-1)
+		}
+		if e.Seq != 0 {
+			last = e.Seq
+		}
+
+		if e.Hash == "" {
+			report.Unsealed++
+			continue
+		}
+		if report.Unsealed > 0 {
+			report.problem("entry %d sealed after unsealed entries", e.Seq)
+		}
+		report.Sealed++
+
+		var prevHash string
+		if prev != nil {
+			prevHash = prev.Hash
+		}
+		if e.PrevHash != prevHash {
+			report.problem("entry %d does not follow entry %d", e.Seq, e.Seq-1)
+		}
+
+		if Hash(e.PrevHash, e) != e.Hash {
+			report.problem("entry %d was edited", e.Seq)
+		}
+
+		prev = e
+	}
+
+	report.Head = prev
+	return report
+}
+
+// Anchor checks that the sealed entry seq still has the given hash, which
+// detects the deletion of the entries that followed it
+func (r *Report) Anchor(entries []*entity.AuditEntry, seq int64, hash string) {
+	for _, e := range entries {
+		if e.Hash != "" && e.Seq == seq {
+			if e.Hash != hash {
+				r.problem("entry %d does not match the anchor", seq)
+			}
+			return
+		}
+	}
+	r.problem("anchored entry %d is missing", seq)
+}
+
+func (r *Report) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
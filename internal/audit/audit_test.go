@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+)
+
+// chain returns n sealed entries
+func chain(n int) []*entity.AuditEntry {
+	at := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	var entries []*entity.AuditEntry
+	var prev string
+	for i := 1; i <= n; i++ {
+		e := &entity.AuditEntry{
+			ID:       int64(i),
+			Seq:      int64(i),
+			At:       at.Add(time.Duration(i) * time.Minute),
+			Actor:    "alice@host",
+			Action:   "payment.status",
+			Entity:   "payment",
+			EntityID: int64(i),
+			Details:  `{"status":"done"}`,
+			PrevHash: prev,
+		}
+		e.Hash = Hash(prev, e)
+		prev = e.Hash
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestActor(t *testing.T) {
+	assert.Equal(t, DefaultActor, Actor(context.Background()))
+	assert.Equal(t, "alice@host", Actor(WithActor(context.Background(), "alice@host")))
+}
+
+func TestVerify(t *testing.T) {
+	entries := chain(4)
+	entries = append(entries, &entity.AuditEntry{ID: 5, Seq: 5, Action: "employee.update"})
+
+	report := Verify(entries)
+	require.NoError(t, report.Err())
+	assert.Equal(t, 4, report.Sealed)
+	assert.Equal(t, 1, report.Unsealed)
+	assert.Equal(t, entries[3], report.Head)
+
+	report.Anchor(entries, 2, entries[1].Hash)
+	assert.NoError(t, report.Err())
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func([]*entity.AuditEntry) []*entity.AuditEntry
+		problem string
+	}{
+		{
+			name: "edited",
+			tamper: func(entries []*entity.AuditEntry) []*entity.AuditEntry {
+				entries[1].Details = `{"status":"created"}`
+				return entries
+			},
+			problem: "entry 2 was edited",
+		},
+		{
+			name: "edited and rehashed",
+			tamper: func(entries []*entity.AuditEntry) []*entity.AuditEntry {
+				entries[1].Actor = "mallory@host"
+				entries[1].Hash = Hash(entries[1].PrevHash, entries[1])
+				return entries
+			},
+			problem: "entry 3 does not follow entry 2",
+		},
+		{
+			name: "first deleted",
+			tamper: func(entries []*entity.AuditEntry) []*entity.AuditEntry {
+				return entries[1:]
+			},
+			problem: "entries 1 to 1 are missing",
+		},
+		{
+			name: "middle deleted",
+			tamper: func(entries []*entity.AuditEntry) []*entity.AuditEntry {
+				return append(entries[:1], entries[2:]...)
+			},
+			problem: "entries 2 to 2 are missing or out of order",
+		},
+		{
+			name: "unsealed deleted",
+			tamper: func(entries []*entity.AuditEntry) []*entity.AuditEntry {
+				return append(entries, &entity.AuditEntry{ID: 6, Seq: 6, Action: "employee.update"})
+			},
+			problem: "entries 5 to 5 are missing or out of order",
+		},
+		{
+			name: "unsealed to be sealed again",
+			tamper: func(entries []*entity.AuditEntry) []*entity.AuditEntry {
+				entries[1].Seq, entries[1].PrevHash, entries[1].Hash = 0, "", ""
+				return entries
+			},
+			problem: "entry 3 sealed after unsealed entries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Verify(tt.tamper(chain(4)))
+			assert.ErrorIs(t, report.Err(), ErrTampered)
+			assert.Contains(t, report.Problems, tt.problem)
+		})
+	}
+}
+
+func TestReport_Anchor(t *testing.T) {
+	entries := chain(4)
+	anchor := entries[3]
+
+	// without its last entries the chain is still valid
+	report := Verify(entries[:2])
+	require.NoError(t, report.Err())
+
+	report.Anchor(entries[:2], anchor.Seq, anchor.Hash)
+	assert.ErrorIs(t, report.Err(), ErrTampered)
+	assert.Equal(t, []string{"anchored entry 4 is missing"}, report.Problems)
+
+	report = Verify(entries)
+	report.Anchor(entries, anchor.Seq, "0x")
+	assert.Equal(t, []string{"entry 4 does not match the anchor"}, report.Problems)
+}
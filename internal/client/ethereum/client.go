@@ -32,4 +32,19 @@ type Client interface {
 
 	// EstimateGas tries to estimate the gas needed to execute a specific transaction
 	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+
+	// CodeAt returns the contract code of the given account, empty for externally owned accounts
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+
+	// FilterLogs returns the logs matching a filter query
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+
+	// NonceAt returns the nonce of an account at a block, the latest if blockNumber is nil
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+
+	// HeaderByNumber returns the header of a block, the latest if number is nil
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+
+	// BlockByNumber returns a block with its transactions, the latest if number is nil
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
 }
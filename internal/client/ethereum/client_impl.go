@@ -55,3 +55,28 @@ func (c *ClientImpl) CallContract(ctx context.Context, call ethereum.CallMsg, bl
 func (c *ClientImpl) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
 	return c.client.EstimateGas(ctx, call)
 }
+
+// CodeAt returns the contract code of the given account, empty for externally owned accounts
+func (c *ClientImpl) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return c.client.CodeAt(ctx, account, blockNumber)
+}
+
+// FilterLogs returns the logs matching a filter query
+func (c *ClientImpl) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return c.client.FilterLogs(ctx, q)
+}
+
+// NonceAt returns the nonce of an account at a block, the latest if blockNumber is nil
+func (c *ClientImpl) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return c.client.NonceAt(ctx, account, blockNumber)
+}
+
+// HeaderByNumber returns the header of a block, the latest if number is nil
+func (c *ClientImpl) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.client.HeaderByNumber(ctx, number)
+}
+
+// BlockByNumber returns a block with its transactions, the latest if number is nil
+func (c *ClientImpl) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return c.client.BlockByNumber(ctx, number)
+}
@@ -18,6 +18,11 @@ type MockClient struct {
 	TransactionReceiptFn func(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
 	CallContractFn       func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
 	EstimateGasFn        func(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	CodeAtFn             func(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	FilterLogsFn         func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	NonceAtFn            func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	HeaderByNumberFn     func(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockByNumberFn      func(ctx context.Context, number *big.Int) (*types.Block, error)
 }
 
 func (m *MockClient) NetworkID(ctx context.Context) (*big.Int, error) {
@@ -68,3 +73,38 @@ func (m *MockClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (ui
 	}
 	return 100000, nil
 }
+
+func (m *MockClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if m.CodeAtFn != nil {
+		return m.CodeAtFn(ctx, account, blockNumber)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if m.FilterLogsFn != nil {
+		return m.FilterLogsFn(ctx, q)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	if m.NonceAtFn != nil {
+		return m.NonceAtFn(ctx, account, blockNumber)
+	}
+	return 0, nil
+}
+
+func (m *MockClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if m.HeaderByNumberFn != nil {
+		return m.HeaderByNumberFn(ctx, number)
+	}
+	return &types.Header{Number: number}, nil
+}
+
+func (m *MockClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if m.BlockByNumberFn != nil {
+		return m.BlockByNumberFn(ctx, number)
+	}
+	return types.NewBlockWithHeader(&types.Header{Number: number}), nil
+}
@@ -48,6 +48,31 @@ func TestMockClient(t *testing.T) {
 		gas, err := mock.EstimateGas(context.Background(), ethereum.CallMsg{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint64(100000), gas)
+
+		// Test CodeAt
+		code, err := mock.CodeAt(context.Background(), common.Address{}, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, code)
+
+		// Test FilterLogs
+		logs, err := mock.FilterLogs(context.Background(), ethereum.FilterQuery{})
+		assert.NoError(t, err)
+		assert.Empty(t, logs)
+
+		// Test NonceAt
+		nonce, err = mock.NonceAt(context.Background(), common.Address{}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), nonce)
+
+		// Test HeaderByNumber
+		header, err := mock.HeaderByNumber(context.Background(), big.NewInt(7))
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(7), header.Number)
+
+		// Test BlockByNumber
+		block, err := mock.BlockByNumber(context.Background(), big.NewInt(7))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), block.NumberU64())
 	})
 
 	t.Run("custom behavior", func(t *testing.T) {
@@ -86,6 +111,21 @@ func TestMockClient(t *testing.T) {
 			EstimateGasFn: func(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
 				return 200000, nil
 			},
+			CodeAtFn: func(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+				return []byte{0x60}, nil
+			},
+			FilterLogsFn: func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+				return []types.Log{{BlockNumber: 7}}, nil
+			},
+			NonceAtFn: func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+				return 9, nil
+			},
+			HeaderByNumberFn: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+				return nil, expectedErr
+			},
+			BlockByNumberFn: func(ctx context.Context, number *big.Int) (*types.Block, error) {
+				return nil, expectedErr
+			},
 		}
 
 		// Test NetworkID
@@ -121,5 +161,28 @@ func TestMockClient(t *testing.T) {
 		gas, err := mock.EstimateGas(context.Background(), ethereum.CallMsg{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint64(200000), gas)
+
+		// Test CodeAt
+		code, err := mock.CodeAt(context.Background(), common.Address{}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x60}, code)
+
+		// Test FilterLogs
+		logs, err := mock.FilterLogs(context.Background(), ethereum.FilterQuery{})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.Log{{BlockNumber: 7}}, logs)
+
+		// Test NonceAt
+		nonce, err = mock.NonceAt(context.Background(), common.Address{}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(9), nonce)
+
+		// Test HeaderByNumber
+		_, err = mock.HeaderByNumber(context.Background(), nil)
+		assert.Equal(t, expectedErr, err)
+
+		// Test BlockByNumber
+		_, err = mock.BlockByNumber(context.Background(), nil)
+		assert.Equal(t, expectedErr, err)
 	})
 }
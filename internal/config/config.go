@@ -1,10 +1,18 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/viper"
+	"gitlab.midas.dev/back/river/internal/approval"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/safe"
 )
 
 // Config holds the application configuration
@@ -12,12 +20,124 @@ type Config struct {
 	Node         string   `mapstructure:"NODE"`
 	PrivateKeys  []string `mapstructure:"PRIVATE_KEYS"`
 	DatabasePath string   `mapstructure:"DATABASE_PATH"`
+	PaySchedule  string   `mapstructure:"PAY_SCHEDULE"`
+
+	// DatabaseURL is a postgres:// URL storing the data in Postgres instead
+	// of the SQLite database at DatabasePath
+	DatabaseURL string `mapstructure:"DATABASE_URL"`
+
+	// DatabaseKeyHex is a hex AES-256 key, or DatabaseKeyFile a file holding
+	// one, that backups and snapshots of the SQLite database are encrypted
+	// with, and employee names and addresses once "db encrypt" ran
+	DatabaseKeyHex  string `mapstructure:"DATABASE_KEY"`
+	DatabaseKeyFile string `mapstructure:"DATABASE_KEY_FILE"`
+
+	// AuditAnchorFile receives the head of the audit log after every
+	// command, which audit verify checks the log against. Keep it where
+	// those who can edit the database cannot.
+	AuditAnchorFile string `mapstructure:"AUDIT_ANCHOR_FILE"`
+
+	// SnapshotDir receives a backup of the SQLite database before every
+	// payroll run, keeping the last SnapshotKeep; zero disables them.
+	// Snapshots hold employee names and addresses, so they are only taken
+	// encrypted with the database key, unless SnapshotPlaintext allows them in
+	// plain text.
+	SnapshotDir       string `mapstructure:"SNAPSHOT_DIR"`
+	SnapshotKeep      int    `mapstructure:"SNAPSHOT_KEEP"`
+	SnapshotPlaintext bool   `mapstructure:"SNAPSHOT_PLAINTEXT"`
+
+	// KeystoreDir holds encrypted key files used instead of or in addition to PrivateKeys
+	KeystoreDir          string   `mapstructure:"KEYSTORE_DIR"`
+	KeystoreAccounts     []string `mapstructure:"KEYSTORE_ACCOUNTS"`
+	KeystorePassword     string   `mapstructure:"KEYSTORE_PASSWORD"`
+	KeystorePasswordFile string   `mapstructure:"KEYSTORE_PASSWORD_FILE"`
+
+	// RemoteSignerURL is a Clef or Web3Signer JSON-RPC endpoint signing for RemoteSignerAccounts
+	RemoteSignerURL      string        `mapstructure:"REMOTE_SIGNER_URL"`
+	RemoteSignerAPI      string        `mapstructure:"REMOTE_SIGNER_API"`
+	RemoteSignerAccounts []string      `mapstructure:"REMOTE_SIGNER_ACCOUNTS"`
+	RemoteSignerTimeout  time.Duration `mapstructure:"REMOTE_SIGNER_TIMEOUT"`
+	RemoteSignerCAFile   string        `mapstructure:"REMOTE_SIGNER_CA_FILE"`
+	RemoteSignerCertFile string        `mapstructure:"REMOTE_SIGNER_CERT_FILE"`
+	RemoteSignerKeyFile  string        `mapstructure:"REMOTE_SIGNER_KEY_FILE"`
+
+	// Spending limits in the token's smallest unit, and in percent for
+	// MaxChangePercent; zero disables a limit
+	MaxPayment        int64 `mapstructure:"MAX_PAYMENT"`
+	MaxRunTotal       int64 `mapstructure:"MAX_RUN_TOTAL"`
+	MaxEmployeePeriod int64 `mapstructure:"MAX_EMPLOYEE_PERIOD"`
+	MaxChangePercent  int64 `mapstructure:"MAX_CHANGE_PERCENT"`
+
+	// Approvers sign payroll runs before they are paid; ApprovalsRequired
+	// defaults to two, or every approver when there are fewer
+	Approvers         []string `mapstructure:"APPROVERS"`
+	ApprovalsRequired int      `mapstructure:"APPROVALS_REQUIRED"`
+
+	// AddressChangeCooldown delays employee address changes; with
+	// AddressChangeVerify they also need a signature from the new address,
+	// and with AddressChangeHold salaries are held rather than paid to the
+	// old address until the change takes effect
+	AddressChangeCooldown time.Duration `mapstructure:"ADDRESS_CHANGE_COOLDOWN"`
+	AddressChangeVerify   bool          `mapstructure:"ADDRESS_CHANGE_VERIFY"`
+	AddressChangeHold     bool          `mapstructure:"ADDRESS_CHANGE_HOLD"`
+
+	// SafeAddress switches payments to proposals of MultiSend transactions of
+	// this Safe, signed by SafeOwner (defaults to the first signer). They are
+	// posted to SafeServiceURL, or exported as JSON to SafeExportDir without it.
+	SafeAddress          string `mapstructure:"SAFE_ADDRESS"`
+	SafeOwner            string `mapstructure:"SAFE_OWNER"`
+	SafeServiceURL       string `mapstructure:"SAFE_SERVICE_URL"`
+	SafeExportDir        string `mapstructure:"SAFE_EXPORT_DIR"`
+	SafeMultiSendAddress string `mapstructure:"SAFE_MULTISEND_ADDRESS"`
+
+	// OfflineAccount is the account of a key kept on an offline machine. With
+	// it, pay --export-unsigned writes the payments' transactions unsigned to
+	// OfflineExportDir, to be signed with river sign and sent with river broadcast.
+	OfflineAccount   string `mapstructure:"OFFLINE_ACCOUNT"`
+	OfflineExportDir string `mapstructure:"OFFLINE_EXPORT_DIR"`
+
+	// ExplorerURL is the block explorer payslips link transactions to
+	ExplorerURL string `mapstructure:"EXPLORER_URL"`
+
+	// DaemonSchedule is a cron expression or @period
+	DaemonSchedule  string        `mapstructure:"DAEMON_SCHEDULE"`
+	RepayBackoff    time.Duration `mapstructure:"REPAY_BACKOFF"`
+	RepayBackoffMax time.Duration `mapstructure:"REPAY_BACKOFF_MAX"`
 }
 
 // Load reads configuration from environment variables and config files
 func Load() (*Config, error) {
+	config, err := Read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate required fields
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Read reads configuration like Load but without validating it, for commands
+// that only need part of it
+func Read() (*Config, error) {
 	// Set default values
 	viper.SetDefault("DATABASE_PATH", "./main.db")
+	viper.SetDefault("PAY_SCHEDULE", string(payroll.Monthly))
+	viper.SetDefault("SNAPSHOT_DIR", "./snapshots")
+	viper.SetDefault("SNAPSHOT_KEEP", 10)
+	viper.SetDefault("DAEMON_SCHEDULE", "@period")
+	viper.SetDefault("REPAY_BACKOFF", "5m")
+	viper.SetDefault("REPAY_BACKOFF_MAX", "6h")
+	viper.SetDefault("ADDRESS_CHANGE_COOLDOWN", "72h")
+	viper.SetDefault("REMOTE_SIGNER_API", "clef")
+	viper.SetDefault("REMOTE_SIGNER_TIMEOUT", "2m")
+	viper.SetDefault("SAFE_EXPORT_DIR", ".")
+	viper.SetDefault("SAFE_MULTISEND_ADDRESS", safe.DefaultMultiSendAddress)
+	viper.SetDefault("OFFLINE_EXPORT_DIR", ".")
+	viper.SetDefault("EXPLORER_URL", "https://etherscan.io")
 
 	// Try to read from main.env file
 	viper.SetConfigFile("main.env")
@@ -51,17 +171,75 @@ func Load() (*Config, error) {
 	if err := viper.BindEnv("DATABASE_PATH"); err != nil {
 		return nil, fmt.Errorf("error binding DATABASE_PATH env: %w", err)
 	}
+	if err := viper.BindEnv("DATABASE_URL"); err != nil {
+		return nil, fmt.Errorf("error binding DATABASE_URL env: %w", err)
+	}
+	for _, key := range []string{"DATABASE_KEY", "DATABASE_KEY_FILE", "SNAPSHOT_DIR", "SNAPSHOT_KEEP", "SNAPSHOT_PLAINTEXT", "AUDIT_ANCHOR_FILE"} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	if err := viper.BindEnv("PAY_SCHEDULE"); err != nil {
+		return nil, fmt.Errorf("error binding PAY_SCHEDULE env: %w", err)
+	}
+	for _, key := range []string{"KEYSTORE_DIR", "KEYSTORE_ACCOUNTS", "KEYSTORE_PASSWORD", "KEYSTORE_PASSWORD_FILE"} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	for _, key := range []string{
+		"REMOTE_SIGNER_URL", "REMOTE_SIGNER_API", "REMOTE_SIGNER_ACCOUNTS", "REMOTE_SIGNER_TIMEOUT",
+		"REMOTE_SIGNER_CA_FILE", "REMOTE_SIGNER_CERT_FILE", "REMOTE_SIGNER_KEY_FILE",
+	} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	for _, key := range []string{"ADDRESS_CHANGE_COOLDOWN", "ADDRESS_CHANGE_VERIFY", "ADDRESS_CHANGE_HOLD"} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	for _, key := range []string{"APPROVERS", "APPROVALS_REQUIRED"} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	for _, key := range []string{"MAX_PAYMENT", "MAX_RUN_TOTAL", "MAX_EMPLOYEE_PERIOD", "MAX_CHANGE_PERCENT"} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	for _, key := range []string{
+		"SAFE_ADDRESS", "SAFE_OWNER", "SAFE_SERVICE_URL", "SAFE_EXPORT_DIR", "SAFE_MULTISEND_ADDRESS",
+	} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	for _, key := range []string{"OFFLINE_ACCOUNT", "OFFLINE_EXPORT_DIR"} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("error binding %s env: %w", key, err)
+		}
+	}
+	if err := viper.BindEnv("EXPLORER_URL"); err != nil {
+		return nil, fmt.Errorf("error binding EXPLORER_URL env: %w", err)
+	}
+	if err := viper.BindEnv("DAEMON_SCHEDULE"); err != nil {
+		return nil, fmt.Errorf("error binding DAEMON_SCHEDULE env: %w", err)
+	}
+	if err := viper.BindEnv("REPAY_BACKOFF"); err != nil {
+		return nil, fmt.Errorf("error binding REPAY_BACKOFF env: %w", err)
+	}
+	if err := viper.BindEnv("REPAY_BACKOFF_MAX"); err != nil {
+		return nil, fmt.Errorf("error binding REPAY_BACKOFF_MAX env: %w", err)
+	}
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
-	// Validate required fields
-	if err := config.validate(); err != nil {
-		return nil, err
-	}
-
 	return &config, nil
 }
 
@@ -71,13 +249,142 @@ func (c *Config) validate() error {
 		return fmt.Errorf("NODE is required")
 	}
 
-	if len(c.PrivateKeys) == 0 {
-		return fmt.Errorf("PRIVATE_KEYS is required")
+	// keys signing offline are not configured on the machine running River
+	if len(c.PrivateKeys) == 0 && c.KeystoreDir == "" && c.RemoteSignerURL == "" && c.OfflineAccount == "" {
+		return fmt.Errorf("PRIVATE_KEYS, KEYSTORE_DIR, REMOTE_SIGNER_URL or OFFLINE_ACCOUNT is required")
 	}
 
-	if c.DatabasePath == "" {
+	if c.RemoteSignerURL != "" && len(c.RemoteSignerAccounts) == 0 {
+		return fmt.Errorf("REMOTE_SIGNER_ACCOUNTS is required with REMOTE_SIGNER_URL")
+	}
+
+	if c.DatabasePath == "" && c.DatabaseURL == "" {
 		return fmt.Errorf("DATABASE_PATH is required")
 	}
 
+	if c.DatabaseURL != "" {
+		u, err := url.Parse(c.DatabaseURL)
+		if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			return fmt.Errorf("DATABASE_URL must be a postgres:// URL")
+		}
+	}
+
+	if c.DatabaseKeyHex != "" && c.DatabaseKeyFile != "" {
+		return fmt.Errorf("set DATABASE_KEY or DATABASE_KEY_FILE, not both")
+	}
+	if _, err := c.DatabaseKey(); err != nil {
+		return err
+	}
+
+	if c.SnapshotKeep < 0 {
+		return fmt.Errorf("SNAPSHOT_KEEP must not be negative")
+	}
+
+	if c.PaySchedule != "" {
+		if _, err := payroll.ParseSchedule(c.PaySchedule); err != nil {
+			return fmt.Errorf("PAY_SCHEDULE: %w", err)
+		}
+	}
+
+	if c.MaxPayment < 0 || c.MaxRunTotal < 0 || c.MaxEmployeePeriod < 0 || c.MaxChangePercent < 0 {
+		return fmt.Errorf("spending limits must not be negative")
+	}
+
+	if c.AddressChangeCooldown < 0 {
+		return fmt.Errorf("ADDRESS_CHANGE_COOLDOWN must not be negative")
+	}
+
+	if _, err := approval.NewPolicy(c.Approvers, c.ApprovalsRequired); err != nil {
+		return fmt.Errorf("APPROVERS: %w", err)
+	}
+
+	for key, addr := range map[string]string{
+		"SAFE_ADDRESS":           c.SafeAddress,
+		"SAFE_OWNER":             c.SafeOwner,
+		"SAFE_MULTISEND_ADDRESS": c.SafeMultiSendAddress,
+		"OFFLINE_ACCOUNT":        c.OfflineAccount,
+	} {
+		if addr != "" && !common.IsHexAddress(addr) {
+			return fmt.Errorf("%s: invalid address %q", key, addr)
+		}
+	}
+
+	if c.RepayBackoff < 0 || c.RepayBackoffMax < c.RepayBackoff {
+		return fmt.Errorf("REPAY_BACKOFF must be positive and not exceed REPAY_BACKOFF_MAX")
+	}
+
 	return nil
 }
+
+// AuditDetails returns the settings recorded in the audit log when they
+// change. Keys and passphrases are left out, and URLs are reduced to their
+// host as they often carry API keys.
+func (c *Config) AuditDetails() map[string]interface{} {
+	return map[string]interface{}{
+		"node":                    urlHost(c.Node),
+		"private_keys":            len(c.PrivateKeys),
+		"database_path":           c.DatabasePath,
+		"database_url":            urlHost(c.DatabaseURL),
+		"database_key":            c.DatabaseKeyHex != "" || c.DatabaseKeyFile != "",
+		"audit_anchor_file":       c.AuditAnchorFile,
+		"snapshot_dir":            c.SnapshotDir,
+		"snapshot_keep":           c.SnapshotKeep,
+		"snapshot_plaintext":      c.SnapshotPlaintext,
+		"pay_schedule":            c.PaySchedule,
+		"keystore_dir":            c.KeystoreDir,
+		"keystore_accounts":       c.KeystoreAccounts,
+		"remote_signer_url":       urlHost(c.RemoteSignerURL),
+		"remote_signer_api":       c.RemoteSignerAPI,
+		"remote_signer_accounts":  c.RemoteSignerAccounts,
+		"max_payment":             c.MaxPayment,
+		"max_run_total":           c.MaxRunTotal,
+		"max_employee_period":     c.MaxEmployeePeriod,
+		"max_change_percent":      c.MaxChangePercent,
+		"approvers":               c.Approvers,
+		"approvals_required":      c.ApprovalsRequired,
+		"address_change_cooldown": c.AddressChangeCooldown.String(),
+		"address_change_verify":   c.AddressChangeVerify,
+		"address_change_hold":     c.AddressChangeHold,
+		"safe_address":            c.SafeAddress,
+		"safe_owner":              c.SafeOwner,
+		"safe_service_url":        urlHost(c.SafeServiceURL),
+		"safe_multisend_address":  c.SafeMultiSendAddress,
+		"offline_account":         c.OfflineAccount,
+		"offline_export_dir":      c.OfflineExportDir,
+		"explorer_url":            c.ExplorerURL,
+		"daemon_schedule":         c.DaemonSchedule,
+		"repay_backoff":           c.RepayBackoff.String(),
+		"repay_backoff_max":       c.RepayBackoffMax.String(),
+	}
+}
+
+// DatabaseKey returns the key read from DATABASE_KEY or DATABASE_KEY_FILE,
+// or nil without either
+func (c *Config) DatabaseKey() ([]byte, error) {
+	raw, name := c.DatabaseKeyHex, "DATABASE_KEY"
+	if c.DatabaseKeyFile != "" {
+		data, err := os.ReadFile(c.DatabaseKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("DATABASE_KEY_FILE: %w", err)
+		}
+		raw, name = string(data), "DATABASE_KEY_FILE"
+	}
+	if raw == "" {
+		return nil, nil
+	}
+
+	key, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(raw), "0x"))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must be 64 hex characters, e.g. from openssl rand -hex 32", name)
+	}
+	return key, nil
+}
+
+// urlHost returns the host of a URL, or nothing if it has none
+func urlHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
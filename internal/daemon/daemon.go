@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gitlab.midas.dev/back/river/internal/payroll"
+)
+
+// PeriodSpec is the schedule spec that runs payroll once per pay period
+const PeriodSpec = "@period"
+
+// Schedule returns the next activation time after the given time
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// Runner executes payroll and repay runs
+type Runner interface {
+	// Pay runs payroll for the current period
+	Pay(ctx context.Context) error
+	// Repay retries unfinished payments and returns the number of salaries
+	// still pending afterwards
+	Repay(ctx context.Context) (int, error)
+}
+
+// Backoff configures the delay between repay attempts
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// next returns the delay before the given attempt, starting at zero
+func (b Backoff) next(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 0; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+// Daemon runs payroll on a schedule and retries failed payments with backoff
+type Daemon struct {
+	schedule Schedule
+	runner   Runner
+	backoff  Backoff
+	now      func() time.Time
+}
+
+// New creates a new daemon
+func New(schedule Schedule, runner Runner, backoff Backoff) *Daemon {
+	return &Daemon{
+		schedule: schedule,
+		runner:   runner,
+		backoff:  backoff,
+		now:      time.Now,
+	}
+}
+
+// ParseSchedule parses a standard five field cron expression or PeriodSpec,
+// which runs payroll at the start of the last day of each period of the pay
+// schedule. An empty spec is the same as PeriodSpec.
+func ParseSchedule(spec string, paySchedule payroll.Schedule) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == PeriodSpec {
+		return periodSchedule{schedule: paySchedule}, nil
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid daemon schedule %q: %w", spec, err)
+	}
+	return schedule, nil
+}
+
+// periodSchedule activates at midnight UTC of the last day of every period
+type periodSchedule struct {
+	schedule payroll.Schedule
+}
+
+func (p periodSchedule) Next(t time.Time) time.Time {
+	period := p.schedule.PeriodAt(t)
+	if period.End.After(t) {
+		return period.End
+	}
+	return p.schedule.PeriodAt(period.End.AddDate(0, 0, 1)).End
+}
+
+// Run blocks until ctx is cancelled. Runs in progress are stopped between
+// payments.
+func (d *Daemon) Run(ctx context.Context) error {
+	var (
+		nextRepay time.Time
+		attempt   int
+	)
+
+	// Pick up payments left unfinished by a previous run right away
+	pending, err := d.runner.Repay(ctx)
+	if err != nil {
+		log.Printf("repay error: %v", err)
+	}
+	if err != nil || pending > 0 {
+		nextRepay = d.now().Add(d.backoff.next(attempt))
+		attempt++
+	}
+
+	nextPay := d.schedule.Next(d.now())
+	log.Printf("next payroll run at %s", nextPay.Format(time.RFC3339))
+
+	for {
+		next := nextPay
+		repay := !nextRepay.IsZero() && nextRepay.Before(nextPay)
+		if repay {
+			next = nextRepay
+		}
+
+		timer := time.NewTimer(next.Sub(d.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("daemon stopped")
+			return nil
+		case <-timer.C:
+		}
+
+		if repay {
+			pending, err = d.runner.Repay(ctx)
+		} else {
+			err = d.runner.Pay(ctx)
+			if err == nil {
+				pending, err = d.runner.Repay(ctx)
+			}
+			nextPay = d.schedule.Next(d.now())
+			log.Printf("next payroll run at %s", nextPay.Format(time.RFC3339))
+		}
+
+		if ctx.Err() != nil {
+			log.Println("daemon stopped")
+			return nil
+		}
+
+		if err != nil {
+			log.Printf("run error: %v", err)
+		}
+
+		if err != nil || pending > 0 {
+			delay := d.backoff.next(attempt)
+			attempt++
+			nextRepay = d.now().Add(delay)
+			log.Printf("%d salaries pending, repay in %s", pending, delay)
+		} else {
+			nextRepay = time.Time{}
+			attempt = 0
+		}
+	}
+}
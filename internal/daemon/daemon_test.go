@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/payroll"
+)
+
+func TestParseSchedule(t *testing.T) {
+	at := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		schedule payroll.Schedule
+		next     time.Time
+	}{
+		{spec: "", schedule: payroll.Monthly, next: time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)},
+		{spec: PeriodSpec, schedule: payroll.SemiMonthly, next: time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)},
+		{spec: PeriodSpec, schedule: payroll.Weekly, next: time.Date(2026, time.October, 25, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 9 28 * *", schedule: payroll.Monthly, next: time.Date(2026, time.October, 28, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec, tt.schedule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.next, schedule.Next(at).UTC())
+		})
+	}
+
+	// on the last day itself the next period is scheduled
+	schedule, err := ParseSchedule(PeriodSpec, payroll.Monthly)
+	require.NoError(t, err)
+	last := time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, time.November, 30, 0, 0, 0, 0, time.UTC), schedule.Next(last))
+
+	_, err = ParseSchedule("every day", payroll.Monthly)
+	assert.Error(t, err)
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: time.Minute, Max: 5 * time.Minute}
+
+	assert.Equal(t, time.Minute, b.next(0))
+	assert.Equal(t, 2*time.Minute, b.next(1))
+	assert.Equal(t, 4*time.Minute, b.next(2))
+	assert.Equal(t, 5*time.Minute, b.next(3))
+	assert.Equal(t, 5*time.Minute, b.next(30))
+}
+
+type everyTick struct {
+	interval time.Duration
+}
+
+func (e everyTick) Next(t time.Time) time.Time {
+	return t.Add(e.interval)
+}
+
+type fakeRunner struct {
+	pays    int
+	repays  int
+	pending []int
+	cancel  context.CancelFunc
+}
+
+func (f *fakeRunner) Pay(ctx context.Context) error {
+	f.pays++
+	return nil
+}
+
+func (f *fakeRunner) Repay(ctx context.Context) (int, error) {
+	f.repays++
+	if len(f.pending) == 0 {
+		f.cancel()
+		return 0, nil
+	}
+	pending := f.pending[0]
+	f.pending = f.pending[1:]
+	return pending, nil
+}
+
+func TestDaemonRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// pending on start, still pending after the first retry, then done
+	runner := &fakeRunner{pending: []int{1, 1}, cancel: cancel}
+	d := New(everyTick{interval: time.Hour}, runner, Backoff{Initial: time.Millisecond, Max: time.Millisecond})
+
+	require.NoError(t, d.Run(ctx))
+	assert.Equal(t, 3, runner.repays)
+	assert.Equal(t, 0, runner.pays)
+}
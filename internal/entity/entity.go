@@ -5,9 +5,16 @@ import (
 )
 
 type Salary struct {
-	ID       int64
-	Status   string
-	CreateAt *time.Time
+	ID          int64
+	Status      string
+	Period      string
+	PeriodStart *time.Time
+	PeriodEnd   *time.Time
+	Schedule    string
+	Forced      bool
+	CreateAt    *time.Time
+	// Version is incremented by every status change
+	Version int64
 }
 
 type Employee struct {
@@ -15,15 +22,160 @@ type Employee struct {
 	Name         string
 	SalaryAmount int
 	Addr         string
+	Status       string
+	StartDate    *time.Time
+	EndDate      *time.Time
 }
 
 type Payment struct {
 	ID         int64
 	EmployeeID int64
 	SalaryID   int64
-	Amount     int64
-	Addr       string
+	// Amount is the net amount sent, GrossAmount minus DeductionAmount
+	Amount          int64
+	GrossAmount     int64
+	DeductionAmount int64
+	Addr            string
+	Status          string
+	Category        string
+	Memo            string
+	Error           string
+	// Reviewed payments were released by a reviewer and skip spending limits
+	Reviewed bool
+	// SafeTxHash is the Safe transaction the payment was proposed in
+	SafeTxHash string
+	// TxHash is the transaction that sent the payment
+	TxHash string
+	// Nonce and SigningHash identify the unsigned transaction the payment
+	// was exported as for offline signing. Payments River sends itself keep
+	// the nonce of their transaction.
+	Nonce       uint64
+	SigningHash string
+	// SignedTx is the raw transaction River signed to send the payment, hex
+	// encoded, recorded before it is broadcast so that it is sent again
+	// rather than paid twice
+	SignedTx string
+	// Token is the token contract the payment was sent in
+	Token string
+	// Payer is the wallet that sent the payment
+	Payer string
+	// GasUsed and GasFee, in wei, are what the transaction cost when River
+	// sent it itself
+	GasUsed int64
+	GasFee  int64
+	PaidAt  *time.Time
+	// Rate is the employee's salary for a full period, which the gross
+	// amount of a salary payment was prorated from
+	Rate     int64
+	CreateAt *time.Time
+	// Version is incremented by every status change
+	Version int64
+}
+
+// SignedTransfer is a transaction sending a payment, signed but not mined yet
+type SignedTransfer struct {
+	TxHash string
+	From   string
+	Nonce  uint64
+	// Raw is the hex encoded transaction
+	Raw string
+}
+
+// Transfer is a mined transaction sending a payment
+type Transfer struct {
+	TxHash  string
+	From    string
+	Token   string
+	GasUsed int64
+	// GasFee is the fee paid for the transaction in wei
+	GasFee int64
+}
+
+// Deduction is withheld from an employee's gross salary on every run
+type Deduction struct {
+	ID         int64
+	EmployeeID int64
+	Name       string
+	Kind       string
+	// Amount is in the token's smallest unit for fixed deductions and in
+	// basis points of the gross salary for percentage deductions
+	Amount int64
+	// TreasuryAddr receives the withheld amount; empty keeps it in the wallet
+	TreasuryAddr string
+	Active       bool
+	CreateAt     *time.Time
+}
+
+// Period is a pay period; both Start and End are inclusive calendar days.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Approval is an approver's signature of a payroll run's manifest hash
+type Approval struct {
+	ID           int64
+	SalaryID     int64
+	Approver     string
+	ManifestHash string
+	Signature    string
+	CreateAt     *time.Time
+}
+
+// AddressChange is a requested change of an employee's payout address. It
+// takes effect after a cooldown and, if required, once the new address
+// signed for it.
+type AddressChange struct {
+	ID          int64
+	EmployeeID  int64
+	OldAddr     string
+	NewAddr     string
+	RequestedBy string
+	Reason      string
+	Status      string
+	Signature   string
+	RequestedAt time.Time
+	EffectiveAt time.Time
+	VerifiedAt  *time.Time
+	AppliedAt   *time.Time
+}
+
+// SafeProposal is a payroll run's payments proposed to the owners of a Safe
+// as a single MultiSend transaction
+type SafeProposal struct {
+	ID         int64
+	SalaryID   int64
+	Safe       string
+	SafeTxHash string
+	Nonce      uint64
 	Status     string
-	Error      string
-	CreateAt   *time.Time
+	// Token is the token contract the payments are sent in, recorded on
+	// the payments when the proposal is added
+	Token string
+	// TxHash is the transaction that executed the proposal
+	TxHash string
+	// Block is the latest block when the proposal was made, from which its
+	// execution is looked for on chain
+	Block uint64
+	// Payload is the signed proposal as posted to the transaction service or
+	// exported, published again until the service knows it
+	Payload  string
+	CreateAt *time.Time
+}
+
+// AuditEntry records a change in the append-only audit log. Sealed entries
+// are chained by hash: Hash covers the entry and PrevHash, the hash of the
+// entry before it.
+type AuditEntry struct {
+	ID       int64
+	Seq      int64
+	At       time.Time
+	Actor    string
+	Action   string
+	Entity   string
+	EntityID int64
+	// Details is a JSON object describing the change
+	Details  string
+	PrevHash string
+	Hash     string
 }
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gitlab.midas.dev/back/river/internal/accounting"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/service/payment"
+)
+
+// AccountingOptions holds the flags of the export accounting command
+type AccountingOptions struct {
+	// Period is the accounting period, as parsed by payroll.ParsePeriod
+	Period string
+	// Format names a registered accounting.Exporter
+	Format string
+	// Accounts is a JSON file of account names overriding the defaults
+	Accounts string
+}
+
+// ExportAccounting executes the export accounting command, writing the
+// journal entries of the payments paid in the period
+func (h *Handler) ExportAccounting(ctx context.Context, w io.Writer, opts AccountingOptions) error {
+	if opts.Period == "" {
+		return errors.New("pass the accounting period with --period")
+	}
+	period, err := payroll.ParsePeriod(opts.Period)
+	if err != nil {
+		return err
+	}
+
+	exporter, err := accounting.Lookup(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	accounts := accounting.DefaultAccounts()
+	if opts.Accounts != "" {
+		accounts, err = accounting.LoadAccounts(opts.Accounts)
+		if err != nil {
+			return err
+		}
+	}
+
+	records, err := h.reports.Paid(ctx, period.Start, period.End.AddDate(0, 0, 1))
+	if err != nil {
+		return fmt.Errorf("failed to read paid payments: %w", err)
+	}
+
+	journal := newJournal(accounts)
+	for _, record := range records {
+		journal.Book(record)
+	}
+
+	return exporter.Export(w, journal.Entries)
+}
+
+// newJournal returns a journal counting payments in the tokens River sends
+func newJournal(accounts accounting.Accounts) *accounting.Journal {
+	return &accounting.Journal{
+		Accounts: accounts,
+		Tokens: map[string]accounting.Currency{
+			payment.USDCContractAddress: {Code: "USDC", Decimals: 6},
+		},
+		// River paid in USDC only before it recorded the token of payments
+		DefaultToken: payment.USDCContractAddress,
+		Native:       accounting.Currency{Code: "ETH", Decimals: 18},
+	}
+}
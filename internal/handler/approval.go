@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// ApproveOptions holds the flags of the salary approve command
+type ApproveOptions struct {
+	// Signature is a personal_sign signature of the manifest hash made elsewhere
+	Signature string
+	// Signer signs the manifest hash with a local key instead
+	Signer signer.MessageSigner
+}
+
+// ShowSalary executes the salary show command, printing the manifest
+// approvers sign
+func (h *Handler) ShowSalary(ctx context.Context, id int64, w io.Writer) error {
+	m, err := h.salaryService.Manifest(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to show salary: %w", err)
+	}
+
+	fmt.Fprint(w, m.Text)
+	fmt.Fprintf(w, "\nstatus: %s\nmanifest hash: %s\napprovals: %d of %d\n",
+		m.Salary.Status, m.Hash.Hex(), m.Approvals, m.Required)
+	return nil
+}
+
+// ApproveSalary executes the salary approve command
+func (h *Handler) ApproveSalary(ctx context.Context, id int64, opts ApproveOptions) error {
+	var signature []byte
+	switch {
+	case opts.Signer != nil:
+		m, err := h.salaryService.Manifest(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to approve salary: %w", err)
+		}
+
+		signature, err = opts.Signer.SignText(ctx, m.Hash.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to sign manifest: %w", err)
+		}
+	case opts.Signature != "":
+		var err error
+		signature, err = hexutil.Decode(opts.Signature)
+		if err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+	default:
+		return errors.New("pass a signature or an account to sign with")
+	}
+
+	m, err := h.salaryService.Approve(ctx, id, signature)
+	if err != nil {
+		return fmt.Errorf("failed to approve salary: %w", err)
+	}
+
+	fmt.Printf("salary %d has %d of %d approvals, status %s\n", id, m.Approvals, m.Required, m.Salary.Status)
+	return nil
+}
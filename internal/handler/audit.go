@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gitlab.midas.dev/back/river/internal/audit"
+)
+
+// VerifyAudit executes the audit verify command. The anchor is the seq:hash
+// of a sealed entry noted earlier, read from AUDIT_ANCHOR_FILE if not given:
+// without one, a log rewritten from its first entry would verify, so none
+// is an error.
+func (h *Handler) VerifyAudit(ctx context.Context, w io.Writer, anchor string) error {
+	if anchor == "" {
+		stored, err := h.readAnchor()
+		if err != nil {
+			return err
+		}
+		anchor = stored
+	}
+	if anchor == "" {
+		return errors.New("no anchor to verify the audit log against: set AUDIT_ANCHOR_FILE, which River writes after every command, or pass --anchor")
+	}
+
+	anchorSeq, anchorHash, err := parseAnchor(anchor)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.audit.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the audit log: %w", err)
+	}
+
+	report := audit.Verify(entries)
+	report.Anchor(entries, anchorSeq, anchorHash)
+
+	fmt.Fprintf(w, "%d sealed entries, %d awaiting seal\n", report.Sealed, report.Unsealed)
+	if report.Head != nil {
+		fmt.Fprintf(w, "head: %d:%s\n", report.Head.Seq, report.Head.Hash)
+	}
+	for _, problem := range report.Problems {
+		fmt.Fprintf(w, "problem: %s\n", problem)
+	}
+
+	return report.Err()
+}
+
+// AnchorAudit writes the head of the audit log to AUDIT_ANCHOR_FILE, if set.
+// The log is checked against the anchor written before first, so that the
+// anchor never moves past a tampered log.
+func (h *Handler) AnchorAudit(ctx context.Context) error {
+	path := h.config.AuditAnchorFile
+	if path == "" {
+		return nil
+	}
+
+	anchor, err := h.readAnchor()
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.audit.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the audit log: %w", err)
+	}
+
+	report := audit.Verify(entries)
+	if anchor != "" {
+		seq, hash, err := parseAnchor(anchor)
+		if err != nil {
+			return err
+		}
+		report.Anchor(entries, seq, hash)
+	}
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("%s left as it is: %w", path, err)
+	}
+	if report.Head == nil {
+		return nil
+	}
+
+	// written aside and renamed, so that the anchor is never left half written
+	tmp := path + ".tmp"
+	head := fmt.Sprintf("%d:%s\n", report.Head.Seq, report.Head.Hash)
+	if err := os.WriteFile(tmp, []byte(head), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readAnchor returns the anchor in AUDIT_ANCHOR_FILE, or nothing if it is
+// not set or not written yet
+func (h *Handler) readAnchor() (string, error) {
+	if h.config.AuditAnchorFile == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(h.config.AuditAnchorFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("AUDIT_ANCHOR_FILE: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// parseAnchor parses the seq:hash of an audit log entry
+func parseAnchor(anchor string) (int64, string, error) {
+	seq, hash, ok := strings.Cut(anchor, ":")
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if !ok || err != nil || n <= 0 || hash == "" {
+		return 0, "", fmt.Errorf("invalid anchor %q, expected seq:hash", anchor)
+	}
+	return n, hash, nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"gitlab.midas.dev/back/river/db"
+)
+
+// snapshot backs up the SQLite database to SNAPSHOT_DIR before a payroll
+// run changes it, so that a run gone wrong can be undone with db restore.
+// Snapshots are encrypted, and skipped without DATABASE_KEY unless
+// SNAPSHOT_PLAINTEXT allows them in plain text. Postgres databases are
+// backed up with their own tools.
+func (h *Handler) snapshot(ctx context.Context) error {
+	if h.db == nil || h.config.SnapshotKeep == 0 {
+		return nil
+	}
+
+	key, err := h.config.DatabaseKey()
+	if err != nil {
+		return err
+	}
+	if key == nil && !h.config.SnapshotPlaintext {
+		log.Println("no snapshot of the database taken: set DATABASE_KEY to encrypt snapshots, or SNAPSHOT_PLAINTEXT=true to take them in plain text")
+		return nil
+	}
+	path, err := db.Snapshot(ctx, h.db, h.config.SnapshotDir, key, h.config.SnapshotKeep)
+	if errors.Is(err, db.ErrBackupUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to snapshot the database: %w", err)
+	}
+
+	log.Printf("snapshot of the database written to %s", path)
+	return nil
+}
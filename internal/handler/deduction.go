@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// DeductionOptions holds the flags of the deduction add command
+type DeductionOptions struct {
+	EmployeeID int64
+	Name       string
+	// Fixed is an amount in the token's smallest unit
+	Fixed int64
+	// Percent of the gross salary, e.g. 12.5
+	Percent  float64
+	Treasury string
+}
+
+// AddDeduction executes the deduction add command
+func (h *Handler) AddDeduction(ctx context.Context, opts DeductionOptions) error {
+	deduction, err := newDeduction(opts)
+	if err != nil {
+		return err
+	}
+
+	id, err := h.deductions.Add(ctx, deduction)
+	if err != nil {
+		return fmt.Errorf("failed to add deduction: %w", err)
+	}
+
+	fmt.Printf("deduction %d added\n", id)
+	return nil
+}
+
+// ListDeductions executes the deduction list command
+func (h *Handler) ListDeductions(ctx context.Context, w io.Writer) error {
+	deductions, err := h.deductions.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list deductions: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEMPLOYEE\tNAME\tAMOUNT\tTREASURY\tACTIVE")
+	for _, d := range deductions {
+		amount := fmt.Sprintf("%d", d.Amount)
+		if d.Kind == string(repository.PercentDeduction) {
+			amount = fmt.Sprintf("%.2f%%", float64(d.Amount)/100)
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%t\n", d.ID, d.EmployeeID, d.Name, amount, d.TreasuryAddr, d.Active)
+	}
+	return tw.Flush()
+}
+
+// RemoveDeduction executes the deduction remove command. Deductions are
+// deactivated rather than deleted so past payments stay explainable.
+func (h *Handler) RemoveDeduction(ctx context.Context, id int64) error {
+	if err := h.deductions.Deactivate(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove deduction: %w", err)
+	}
+	return nil
+}
+
+func newDeduction(opts DeductionOptions) (*entity.Deduction, error) {
+	deduction := &entity.Deduction{
+		EmployeeID: opts.EmployeeID,
+		Name:       opts.Name,
+	}
+
+	switch {
+	case opts.Fixed > 0 && opts.Percent == 0:
+		deduction.Kind = string(repository.FixedDeduction)
+		deduction.Amount = opts.Fixed
+	case opts.Percent > 0 && opts.Percent <= 100 && opts.Fixed == 0:
+		deduction.Kind = string(repository.PercentDeduction)
+		deduction.Amount = int64(math.Round(opts.Percent * 100))
+	default:
+		return nil, fmt.Errorf("a deduction needs either a positive fixed amount or a percentage up to 100")
+	}
+
+	if opts.Name == "" {
+		return nil, fmt.Errorf("a deduction needs a name")
+	}
+
+	if opts.Treasury != "" {
+		if !common.IsHexAddress(opts.Treasury) {
+			return nil, fmt.Errorf("invalid treasury address %q", opts.Treasury)
+		}
+		deduction.TreasuryAddr = common.HexToAddress(opts.Treasury).Hex()
+	}
+
+	return deduction, nil
+}
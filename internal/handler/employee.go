@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/recipient"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// EmployeeOptions holds the flags of the employee add command
+type EmployeeOptions struct {
+	Name    string
+	Address string
+	Salary  int
+	// StartDate is the day the employee starts, YYYY-MM-DD, if not at once
+	StartDate string
+}
+
+// AddEmployee executes the employee add command. Names and addresses are
+// encrypted if the database is, so employees of an encrypted database can
+// only be added this way.
+func (h *Handler) AddEmployee(ctx context.Context, opts EmployeeOptions) error {
+	name := strings.TrimSpace(opts.Name)
+	if name == "" {
+		return fmt.Errorf("the name of the employee is required")
+	}
+
+	addr, err := recipient.Validate(opts.Address)
+	if err != nil {
+		return err
+	}
+
+	if opts.Salary < 0 {
+		return fmt.Errorf("the salary cannot be negative")
+	}
+
+	emp := &entity.Employee{Name: name, Addr: addr.Hex(), SalaryAmount: opts.Salary}
+	if opts.StartDate != "" {
+		start, err := time.Parse("2006-01-02", opts.StartDate)
+		if err != nil {
+			return fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", opts.StartDate)
+		}
+		emp.StartDate = &start
+	}
+
+	id, err := h.employees.Add(ctx, emp)
+	if err != nil {
+		return fmt.Errorf("failed to add employee: %w", err)
+	}
+
+	fmt.Printf("added employee %d\n", id)
+	return nil
+}
+
+// RenameEmployee executes the employee rename command
+func (h *Handler) RenameEmployee(ctx context.Context, id int64, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("the name of the employee is required")
+	}
+
+	if err := h.employees.Rename(ctx, id, name); err != nil {
+		return fmt.Errorf("failed to rename employee: %w", err)
+	}
+	return nil
+}
+
+// AddressChangeOptions holds the flags of the employee address set command
+type AddressChangeOptions struct {
+	EmployeeID  int64
+	Address     string
+	Reason      string
+	RequestedBy string
+}
+
+// RequestAddressChange executes the employee address set command. The change
+// takes effect after the configured cooldown, and once verified if required.
+func (h *Handler) RequestAddressChange(ctx context.Context, opts AddressChangeOptions) error {
+	addr, err := recipient.Validate(opts.Address)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(opts.RequestedBy) == "" {
+		return fmt.Errorf("the requester of an address change is required")
+	}
+
+	now := time.Now()
+	change := &entity.AddressChange{
+		EmployeeID:  opts.EmployeeID,
+		NewAddr:     addr.Hex(),
+		RequestedBy: opts.RequestedBy,
+		Reason:      opts.Reason,
+		RequestedAt: now,
+		EffectiveAt: now.Add(h.config.AddressChangeCooldown),
+	}
+
+	id, err := h.addresses.Request(ctx, change)
+	if err != nil {
+		return fmt.Errorf("failed to request address change: %w", err)
+	}
+	change.ID = id
+
+	fmt.Printf("address change %d for employee %d takes effect after %s\n",
+		id, opts.EmployeeID, change.EffectiveAt.UTC().Format(time.RFC3339))
+
+	if h.config.AddressChangeVerify {
+		fmt.Printf("it must be verified with a personal_sign signature from %s of:\n%s\n",
+			change.NewAddr, addressChangeMessage(change))
+	}
+	return nil
+}
+
+// VerifyAddressChange executes the employee address verify command
+func (h *Handler) VerifyAddressChange(ctx context.Context, id int64, signature string) error {
+	change, err := h.addresses.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to verify address change: %w", err)
+	}
+
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	addr, err := signer.RecoverText([]byte(addressChangeMessage(change)), sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	if addr != common.HexToAddress(change.NewAddr) {
+		return fmt.Errorf("signature is from %s, not the new address %s", addr.Hex(), change.NewAddr)
+	}
+
+	if err := h.addresses.Verify(ctx, id, signature, time.Now()); err != nil {
+		return fmt.Errorf("failed to verify address change: %w", err)
+	}
+
+	fmt.Printf("address change %d verified\n", id)
+	return nil
+}
+
+// CancelAddressChange executes the employee address cancel command
+func (h *Handler) CancelAddressChange(ctx context.Context, id int64) error {
+	if err := h.addresses.Cancel(ctx, id); err != nil {
+		return fmt.Errorf("failed to cancel address change: %w", err)
+	}
+	return nil
+}
+
+// ListAddressChanges executes the employee address list command
+func (h *Handler) ListAddressChanges(ctx context.Context, w io.Writer) error {
+	changes, err := h.addresses.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list address changes: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEMPLOYEE\tOLD\tNEW\tSTATUS\tVERIFIED\tREQUESTED BY\tREQUESTED\tEFFECTIVE\tREASON")
+	for _, c := range changes {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s\n", c.ID, c.EmployeeID, c.OldAddr, c.NewAddr,
+			c.Status, c.VerifiedAt != nil, c.RequestedBy, c.RequestedAt.UTC().Format(time.RFC3339),
+			c.EffectiveAt.UTC().Format(time.RFC3339), c.Reason)
+	}
+	return tw.Flush()
+}
+
+// addressChangeMessage is the message the new address signs to verify a change
+func addressChangeMessage(change *entity.AddressChange) string {
+	return fmt.Sprintf("River address change %d: pay employee %d at %s", change.ID, change.EmployeeID, change.NewAddr)
+}
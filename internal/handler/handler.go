@@ -3,40 +3,321 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
 
+	"gitlab.midas.dev/back/river/internal/approval"
 	"gitlab.midas.dev/back/river/internal/config"
+	"gitlab.midas.dev/back/river/internal/daemon"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
 	"gitlab.midas.dev/back/river/internal/service/salary"
 )
 
+const (
+	// payrollLock is held while salaries are being paid
+	payrollLock = "payroll"
+	lockTTL     = 2 * time.Minute
+)
+
+// ErrLocked is returned when another River instance is paying
+var ErrLocked = errors.New("another River instance is paying salaries")
+
 // Handler handles CLI command execution
 type Handler struct {
 	db            *sql.DB
 	salaryService *salary.Service
+	locks         repository.LockRepository
+	deductions    repository.DeductionRepository
+	addresses     repository.AddressChangeRepository
+	audit         repository.AuditRepository
+	reports       repository.ReportRepository
+	employees     repository.EmployeeRepository
+	recovery      repository.RecoveryRepository
 	config        *config.Config
+	owner         string
+}
+
+// Repositories groups the repositories commands use directly
+type Repositories struct {
+	Locks          repository.LockRepository
+	Deductions     repository.DeductionRepository
+	AddressChanges repository.AddressChangeRepository
+	Audit          repository.AuditRepository
+	Reports        repository.ReportRepository
+	Employees      repository.EmployeeRepository
+	Recovery       repository.RecoveryRepository
+}
+
+// PayOptions holds the flags of the pay command
+type PayOptions struct {
+	// Repay retries salaries left in processing status instead of creating a new one
+	Repay bool
+	// Period overrides the current period of the configured schedule
+	Period string
+	// Force pays a period even if it already has a payroll run
+	Force bool
+}
+
+// PaymentOptions holds the flags of the payment add command
+type PaymentOptions struct {
+	EmployeeID int64
+	Amount     int64
+	Category   string
+	Memo       string
+	// SalaryID attaches the payment to an unfinished payroll run
+	SalaryID int64
+	// Standalone pays the payment right away in a run of its own
+	Standalone bool
 }
 
 // New creates a new handler
-func New(db *sql.DB, salaryService *salary.Service, config *config.Config) *Handler {
+func New(db *sql.DB, salaryService *salary.Service, repos Repositories, config *config.Config) *Handler {
+	host, _ := os.Hostname()
+
 	return &Handler{
 		db:            db,
 		salaryService: salaryService,
+		locks:         repos.Locks,
+		deductions:    repos.Deductions,
+		addresses:     repos.AddressChanges,
+		audit:         repos.Audit,
+		reports:       repos.Reports,
+		employees:     repos.Employees,
+		recovery:      repos.Recovery,
 		config:        config,
+		owner:         fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano()),
 	}
 }
 
 // Pay executes the pay command
-func (h *Handler) Pay(ctx context.Context, isRepay bool) error {
-	var err error
-	if isRepay {
-		err = h.salaryService.Repay(ctx)
-	} else {
-		err = h.salaryService.Pay(ctx)
+func (h *Handler) Pay(ctx context.Context, opts PayOptions) error {
+	var params repository.CreateSalaryParams
+	if !opts.Repay {
+		var err error
+		params, err = h.salaryParams(opts, time.Now())
+		if err != nil {
+			return err
+		}
 	}
 
+	err := h.withLock(ctx, func(ctx context.Context) error {
+		if err := h.snapshot(ctx); err != nil {
+			return err
+		}
+
+		if opts.Repay {
+			// release salaries held for address changes that are now due
+			err := h.addresses.ApplyDue(ctx, time.Now(), h.config.AddressChangeVerify)
+			if err != nil {
+				return err
+			}
+			return h.salaryService.Repay(ctx)
+		}
+		return h.salaryService.Pay(ctx, params)
+	})
+
 	if err != nil {
 		return fmt.Errorf("failed to process salary payment: %w", err)
 	}
 
 	return nil
 }
+
+// AddPayment executes the payment add command
+func (h *Handler) AddPayment(ctx context.Context, opts PaymentOptions) error {
+	item := repository.PaymentItem{
+		SalaryID:   opts.SalaryID,
+		EmployeeID: opts.EmployeeID,
+		Amount:     opts.Amount,
+		Category:   repository.PaymentCategory(opts.Category),
+		Memo:       opts.Memo,
+	}
+
+	if opts.Standalone {
+		err := h.withLock(ctx, func(ctx context.Context) error {
+			return h.salaryService.PayAdHoc(ctx, []repository.PaymentItem{item})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to process payment: %w", err)
+		}
+		return nil
+	}
+
+	id, err := h.salaryService.AddPayment(ctx, item)
+	if err != nil {
+		return fmt.Errorf("failed to add payment: %w", err)
+	}
+
+	if opts.SalaryID != 0 {
+		fmt.Printf("payment %d added to salary %d\n", id, opts.SalaryID)
+	} else {
+		fmt.Printf("payment %d queued for the next payroll run\n", id)
+	}
+	return nil
+}
+
+// ListPaymentsForReview executes the payment review command without arguments
+func (h *Handler) ListPaymentsForReview(ctx context.Context, w io.Writer) error {
+	payments, err := h.salaryService.PaymentsForReview(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list payments: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSALARY\tEMPLOYEE\tAMOUNT\tCATEGORY\tREASON")
+	for _, p := range payments {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%s\n", p.ID, p.SalaryID, p.EmployeeID, p.Amount, p.Category, p.Error)
+	}
+	return tw.Flush()
+}
+
+// ReviewPayment executes the payment review command. Approved payments are
+// sent by the next repay.
+func (h *Handler) ReviewPayment(ctx context.Context, id int64, approve bool) error {
+	if err := h.salaryService.ReviewPayment(ctx, id, approve); err != nil {
+		return fmt.Errorf("failed to review payment: %w", err)
+	}
+
+	if approve {
+		fmt.Printf("payment %d approved, run `river repay` to send it\n", id)
+	} else {
+		fmt.Printf("payment %d rejected\n", id)
+	}
+	return nil
+}
+
+// Daemon runs payroll on the configured schedule until ctx is cancelled
+func (h *Handler) Daemon(ctx context.Context) error {
+	paySchedule, err := h.paySchedule()
+	if err != nil {
+		return err
+	}
+
+	schedule, err := daemon.ParseSchedule(h.config.DaemonSchedule, paySchedule)
+	if err != nil {
+		return err
+	}
+
+	d := daemon.New(schedule, daemonRunner{h}, daemon.Backoff{
+		Initial: h.config.RepayBackoff,
+		Max:     h.config.RepayBackoffMax,
+	})
+	return d.Run(ctx)
+}
+
+// daemonRunner adapts the handler to the daemon
+type daemonRunner struct {
+	h *Handler
+}
+
+func (r daemonRunner) Pay(ctx context.Context) error {
+	err := r.h.Pay(ctx, PayOptions{})
+	r.anchor(ctx)
+	if errors.Is(err, repository.ErrPeriodAlreadyPaid) || errors.Is(err, approval.ErrNotApproved) {
+		log.Println(err)
+		return nil
+	}
+	return err
+}
+
+func (r daemonRunner) Repay(ctx context.Context) (int, error) {
+	err := r.h.Pay(ctx, PayOptions{Repay: true})
+	r.anchor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return r.h.salaryService.Pending(ctx)
+}
+
+// anchor keeps the head of the audit log outside the database after each
+// run, as commands do when they end
+func (r daemonRunner) anchor(ctx context.Context) {
+	if err := r.h.AnchorAudit(ctx); err != nil {
+		log.Printf("audit anchor error: %v", err)
+	}
+}
+
+// withLock runs fn while holding the payroll lock, renewing it until fn returns
+func (h *Handler) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	ok, err := h.locks.Acquire(ctx, payrollLock, h.owner, lockTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLocked
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(lockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if _, err := h.locks.Acquire(context.Background(), payrollLock, h.owner, lockTTL); err != nil {
+					log.Printf("renew lock error: %v", err)
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		close(done)
+
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := h.locks.Release(releaseCtx, payrollLock, h.owner); err != nil {
+			log.Printf("release lock error: %v", err)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// paySchedule returns the configured pay schedule
+func (h *Handler) paySchedule() (payroll.Schedule, error) {
+	if h.config.PaySchedule == "" {
+		return payroll.Monthly, nil
+	}
+	return payroll.ParseSchedule(h.config.PaySchedule)
+}
+
+// salaryParams resolves the period to pay from the flags and the configured schedule
+func (h *Handler) salaryParams(opts PayOptions, now time.Time) (repository.CreateSalaryParams, error) {
+	schedule, err := h.paySchedule()
+	if err != nil {
+		return repository.CreateSalaryParams{}, err
+	}
+
+	params := repository.CreateSalaryParams{
+		Period:                 schedule.PeriodAt(now),
+		Schedule:               string(schedule),
+		Force:                  opts.Force,
+		Now:                    now,
+		RequireVerifiedAddress: h.config.AddressChangeVerify,
+		HoldPendingAddress:     h.config.AddressChangeHold,
+	}
+
+	if opts.Period != "" {
+		period, err := payroll.ParsePeriod(opts.Period)
+		if err != nil {
+			return repository.CreateSalaryParams{}, err
+		}
+
+		if payroll.Label(schedule.PeriodAt(period.Start)) != payroll.Label(period) {
+			params.Schedule = string(payroll.Custom)
+		}
+		params.Period = period
+	}
+
+	return params, nil
+}
@@ -1,12 +1,503 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/db"
+	"gitlab.midas.dev/back/river/internal/accounting"
+	"gitlab.midas.dev/back/river/internal/audit"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/config"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/reconcile"
+	"gitlab.midas.dev/back/river/internal/report"
+	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/service/payment"
+	"gitlab.midas.dev/back/river/internal/signer"
 )
 
 func TestHandlerCreation(t *testing.T) {
 	// This is a placeholder test. In a real implementation, we would test the actual logic.
 	assert.True(t, true)
 }
+
+func TestHandler_SalaryParams(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule string
+		opts     PayOptions
+		label    string
+		sched    payroll.Schedule
+	}{
+		{name: "default schedule", label: "2026-10", sched: payroll.Monthly},
+		{name: "semi-monthly", schedule: "semi-monthly", label: "2026-10-16..2026-10-31", sched: payroll.SemiMonthly},
+		{name: "explicit period", opts: PayOptions{Period: "2026-09"}, label: "2026-09", sched: payroll.Monthly},
+		{
+			name:  "explicit range",
+			opts:  PayOptions{Period: "2026-10-01..2026-10-14", Force: true},
+			label: "2026-10-01..2026-10-14",
+			sched: payroll.Custom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(nil, nil, Repositories{}, &config.Config{PaySchedule: tt.schedule})
+
+			params, err := h.salaryParams(tt.opts, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.label, payroll.Label(params.Period))
+			assert.Equal(t, string(tt.sched), params.Schedule)
+			assert.Equal(t, tt.opts.Force, params.Force)
+		})
+	}
+}
+
+func TestNewDeduction(t *testing.T) {
+	d, err := newDeduction(DeductionOptions{EmployeeID: 1, Name: "tax", Percent: 12.5})
+	require.NoError(t, err)
+	assert.Equal(t, "percent", d.Kind)
+	assert.Equal(t, int64(1250), d.Amount)
+
+	d, err = newDeduction(DeductionOptions{
+		EmployeeID: 1, Name: "loan", Fixed: 300, Treasury: "0x00000000000000000000000000000000000000aa",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "fixed", d.Kind)
+	assert.Equal(t, "0x00000000000000000000000000000000000000AA", d.TreasuryAddr)
+
+	for _, opts := range []DeductionOptions{
+		{EmployeeID: 1, Name: "both", Fixed: 1, Percent: 1},
+		{EmployeeID: 1, Name: "none"},
+		{EmployeeID: 1, Name: "too much", Percent: 120},
+		{EmployeeID: 1, Fixed: 1},
+		{EmployeeID: 1, Name: "bad treasury", Fixed: 1, Treasury: "treasury"},
+	} {
+		_, err = newDeduction(opts)
+		assert.Error(t, err, opts.Name)
+	}
+}
+
+// addressChanges is an in-memory AddressChangeRepository
+type addressChanges struct {
+	repository.AddressChangeRepository
+	changes  map[int64]*entity.AddressChange
+	verified map[int64]string
+}
+
+func (a *addressChanges) Get(_ context.Context, id int64) (*entity.AddressChange, error) {
+	return a.changes[id], nil
+}
+
+func (a *addressChanges) Verify(_ context.Context, id int64, signature string, _ time.Time) error {
+	a.verified[id] = signature
+	return nil
+}
+
+func TestHandler_VerifyAddressChange(t *testing.T) {
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	require.NoError(t, err)
+	newAddr := crypto.PubkeyToAddress(key.PublicKey)
+
+	repo := &addressChanges{
+		changes: map[int64]*entity.AddressChange{
+			1: {ID: 1, EmployeeID: 7, NewAddr: newAddr.Hex()},
+			2: {ID: 2, EmployeeID: 8, NewAddr: "0x00000000000000000000000000000000000000aa"},
+		},
+		verified: make(map[int64]string),
+	}
+	h := New(nil, nil, Repositories{AddressChanges: repo}, &config.Config{})
+
+	sign := func(change *entity.AddressChange) string {
+		sig, err := signer.NewKeySigner(key).SignText(context.Background(), []byte(addressChangeMessage(change)))
+		require.NoError(t, err)
+		return hexutil.Encode(sig)
+	}
+
+	require.NoError(t, h.VerifyAddressChange(context.Background(), 1, sign(repo.changes[1])))
+	assert.Contains(t, repo.verified, int64(1))
+
+	// only the new address can verify its change
+	assert.Error(t, h.VerifyAddressChange(context.Background(), 2, sign(repo.changes[2])))
+	assert.NotContains(t, repo.verified, int64(2))
+}
+
+// auditLog is an in-memory AuditRepository
+type auditLog struct {
+	repository.AuditRepository
+	entries []*entity.AuditEntry
+}
+
+func (a *auditLog) List(context.Context) ([]*entity.AuditEntry, error) {
+	return a.entries, nil
+}
+
+func TestHandler_VerifyAudit(t *testing.T) {
+	first := &entity.AuditEntry{ID: 1, Seq: 1, Action: "config.load", Entity: "config", Details: "{}"}
+	first.Hash = audit.Hash("", first)
+	log := &auditLog{entries: []*entity.AuditEntry{first}}
+	anchorFile := filepath.Join(t.TempDir(), "audit.anchor")
+	h := New(nil, nil, Repositories{Audit: log}, &config.Config{AuditAnchorFile: anchorFile})
+
+	// without an anchor kept outside the database the log is not verified
+	var out bytes.Buffer
+	assert.Error(t, h.VerifyAudit(context.Background(), &out, ""))
+
+	require.NoError(t, h.AnchorAudit(context.Background()))
+	anchor, err := os.ReadFile(anchorFile)
+	require.NoError(t, err)
+	assert.Equal(t, "1:"+first.Hash+"\n", string(anchor))
+
+	require.NoError(t, h.VerifyAudit(context.Background(), &out, ""))
+	assert.Contains(t, out.String(), "head: 1:"+first.Hash)
+
+	require.NoError(t, h.VerifyAudit(context.Background(), &out, "1:"+first.Hash))
+	assert.ErrorIs(t, h.VerifyAudit(context.Background(), &out, "2:"+first.Hash), audit.ErrTampered)
+	assert.Error(t, h.VerifyAudit(context.Background(), &out, first.Hash))
+
+	// a log rewritten with a valid chain no longer matches the anchor, which
+	// is left as it is
+	first.Details = `{"max_payment":0}`
+	first.Hash = audit.Hash("", first)
+	out.Reset()
+	assert.ErrorIs(t, h.VerifyAudit(context.Background(), &out, ""), audit.ErrTampered)
+	assert.Contains(t, out.String(), "problem: entry 1 does not match the anchor")
+	assert.ErrorIs(t, h.AnchorAudit(context.Background()), audit.ErrTampered)
+	kept, err := os.ReadFile(anchorFile)
+	require.NoError(t, err)
+	assert.Equal(t, anchor, kept)
+
+	first.Details = `{"max_payment":1}`
+	out.Reset()
+	assert.ErrorIs(t, h.VerifyAudit(context.Background(), &out, ""), audit.ErrTampered)
+	assert.Contains(t, out.String(), "problem: entry 1 was edited")
+}
+
+func TestReportOptions_ReportRange(t *testing.T) {
+	from, to, err := ReportOptions{Period: "2026-10"}.reportRange()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), to)
+
+	from, to, err = ReportOptions{}.reportRange()
+	require.NoError(t, err)
+	assert.True(t, from.IsZero())
+	assert.True(t, to.After(time.Now()))
+
+	_, _, err = ReportOptions{Period: "october"}.reportRange()
+	assert.Error(t, err)
+
+	h := New(nil, nil, Repositories{}, &config.Config{})
+	assert.ErrorIs(t, h.ReportTotals(context.Background(), &bytes.Buffer{}, ReportOptions{Format: "xml"}), report.ErrUnknownFormat)
+}
+
+// paidPayments is a ReportRepository of paid payments
+type paidPayments struct {
+	repository.ReportRepository
+	records     []*repository.PaymentRecord
+	outstanding []*repository.PaymentRecord
+	from, to    time.Time
+}
+
+func (p *paidPayments) Paid(_ context.Context, from, to time.Time) ([]*repository.PaymentRecord, error) {
+	p.from, p.to = from, to
+	return p.records, nil
+}
+
+func TestHandler_ExportAccounting(t *testing.T) {
+	reports := &paidPayments{records: []*repository.PaymentRecord{{
+		Employee: "alice",
+		Period:   "2026-10",
+		Payment:  &entity.Payment{ID: 1, Category: "salary", GrossAmount: 2_500_000, Amount: 2_500_000, TxHash: "0xt1"},
+	}}}
+	h := New(nil, nil, Repositories{Reports: reports}, &config.Config{})
+
+	var out bytes.Buffer
+	require.NoError(t, h.ExportAccounting(context.Background(), &out, AccountingOptions{Period: "2026-10", Format: "csv"}))
+	assert.Equal(t, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), reports.from)
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), reports.to)
+
+	// payments without a recorded token were paid in USDC
+	assert.Contains(t, out.String(), ",0xt1,\"salary 2026-10 to alice, payment 1\",Salaries,USDC,2.500000,\n")
+
+	assert.Error(t, h.ExportAccounting(context.Background(), &out, AccountingOptions{Format: "csv"}))
+	assert.ErrorIs(t, h.ExportAccounting(context.Background(), &out, AccountingOptions{Period: "2026-10", Format: "qif"}),
+		accounting.ErrUnknownExporter)
+}
+
+func (p *paidPayments) RunPayments(_ context.Context, from, to time.Time) ([]*repository.PaymentRecord, error) {
+	p.from, p.to = from, to
+	return p.records, nil
+}
+
+func TestHandler_Snapshot(t *testing.T) {
+	dbDriver, err := db.Open("", filepath.Join(t.TempDir(), "main.db"))
+	require.NoError(t, err)
+	defer dbDriver.Close()
+	_, err = dbDriver.Exec(`CREATE TABLE notes (body TEXT)`)
+	require.NoError(t, err)
+
+	// snapshots are encrypted
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	key := strings.Repeat("07", 32)
+	h := New(dbDriver, nil, Repositories{}, &config.Config{SnapshotDir: dir, SnapshotKeep: 2, DatabaseKeyHex: key})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, h.snapshot(context.Background()))
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ".enc", filepath.Ext(entries[0].Name()))
+
+	// and not taken in plain text unless allowed
+	dir = filepath.Join(t.TempDir(), "snapshots")
+	h = New(dbDriver, nil, Repositories{}, &config.Config{SnapshotDir: dir, SnapshotKeep: 2})
+	require.NoError(t, h.snapshot(context.Background()))
+	_, err = os.Stat(dir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	h.config.SnapshotPlaintext = true
+	require.NoError(t, h.snapshot(context.Background()))
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ".db", filepath.Ext(entries[0].Name()))
+
+	// snapshots are disabled without a count
+	h = New(dbDriver, nil, Repositories{}, &config.Config{SnapshotDir: t.TempDir()})
+	require.NoError(t, h.snapshot(context.Background()))
+	entries, err = os.ReadDir(h.config.SnapshotDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestHandler_GeneratePayslips(t *testing.T) {
+	reports := &paidPayments{records: []*repository.PaymentRecord{{
+		Employee: "alice",
+		Period:   "2026-10",
+		Payment: &entity.Payment{ID: 1, SalaryID: 3, EmployeeID: 7, Status: "done", Category: "salary",
+			GrossAmount: 2_500_000, Amount: 2_500_000, Rate: 2_500_000, TxHash: "0xt1"},
+	}}}
+	h := New(nil, nil, Repositories{Reports: reports}, &config.Config{ExplorerURL: "https://etherscan.io"})
+	dir := t.TempDir()
+
+	var out bytes.Buffer
+	require.NoError(t, h.GeneratePayslips(context.Background(), &out, PayslipOptions{Period: "2026-10", Dir: dir}))
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), reports.to)
+
+	name := filepath.Join(dir, "payslip-2026-10-run3-employee7")
+	assert.Equal(t, name+".html\n"+name+".pdf\n", out.String())
+	html, err := os.ReadFile(name + ".html")
+	require.NoError(t, err)
+	// payments without a recorded token were paid in USDC
+	assert.Contains(t, string(html), "<td>USDC</td>")
+	assert.Contains(t, string(html), "https://etherscan.io/tx/0xt1")
+
+	out.Reset()
+	require.NoError(t, h.GeneratePayslips(context.Background(), &out, PayslipOptions{Period: "2026-10", Dir: dir, EmployeeID: 8}))
+	assert.Equal(t, "No payments were paid by the payroll runs of 2026-10\n", out.String())
+
+	assert.Error(t, h.GeneratePayslips(context.Background(), &out, PayslipOptions{Dir: dir}))
+	assert.Error(t, h.GeneratePayslips(context.Background(), &out, PayslipOptions{Period: "2026-10", Format: "docx"}))
+}
+
+func TestHandler_Reconcile(t *testing.T) {
+	payer := "0x00000000000000000000000000000000000000aa"
+	reports := &paidPayments{records: []*repository.PaymentRecord{{
+		Employee: "alice",
+		Payment: &entity.Payment{ID: 1, Status: "done", Amount: 10, Payer: payer,
+			Addr: "0x0000000000000000000000000000000000000001", TxHash: common.HexToHash("0x01").Hex()},
+	}}}
+	h := New(nil, nil, Repositories{Reports: reports}, &config.Config{})
+
+	var query goethereum.FilterQuery
+	client := &ethereum.MockClient{
+		FilterLogsFn: func(ctx context.Context, q goethereum.FilterQuery) ([]types.Log, error) {
+			query = q
+			return nil, nil
+		},
+		TransactionReceiptFn: func(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+			return &types.Receipt{Status: 1, BlockNumber: big.NewInt(150)}, nil
+		},
+	}
+
+	var out bytes.Buffer
+	err := h.Reconcile(context.Background(), &out, client, ReconcileOptions{FromBlock: 100, ToBlock: 200, Format: "csv"})
+	assert.ErrorIs(t, err, ErrDiscrepancies)
+	// the recorded paying wallet is scanned
+	assert.Equal(t, []common.Hash{common.HexToHash(payer)}, query.Topics[1])
+	assert.Contains(t, out.String(), "missing_payment,"+common.HexToHash("0x01").Hex()+",,,"+payer+",")
+
+	assert.Error(t, h.Reconcile(context.Background(), &out, client, ReconcileOptions{FromBlock: 100, Format: "csv"}))
+	assert.Error(t, h.Reconcile(context.Background(), &out, client,
+		ReconcileOptions{FromBlock: 100, ToBlock: 200, Treasuries: []string{"nope"}, Format: "csv"}))
+}
+
+func (p *paidPayments) Outstanding(context.Context) ([]*repository.PaymentRecord, error) {
+	return p.outstanding, nil
+}
+
+// employeeList is an EmployeeRepository of fixed employees
+type employeeList struct {
+	repository.EmployeeRepository
+	employees []*entity.Employee
+}
+
+func (e *employeeList) List(context.Context) ([]*entity.Employee, error) {
+	return e.employees, nil
+}
+
+// recoveredPayments is a RecoveryRepository keeping what it recovers
+type recoveredPayments struct {
+	payments []*repository.RecoveredPayment
+}
+
+func (r *recoveredPayments) Recover(_ context.Context, payments []*repository.RecoveredPayment) (int64, error) {
+	r.payments = append(r.payments, payments...)
+	return 9, nil
+}
+
+func (a *auditLog) Append(_ context.Context, action, entityType string, entityID int64, details map[string]interface{}) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	a.entries = append(a.entries, &entity.AuditEntry{Action: action, Entity: entityType, EntityID: entityID, Details: string(data)})
+	return nil
+}
+
+func TestHandler_Recover(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	alice := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	createAt := time.Unix(1_793_000_000, 0).UTC()
+	later := createAt.Add(time.Hour)
+	reports := &paidPayments{outstanding: []*repository.PaymentRecord{{
+		Employee: "alice",
+		Payment:  &entity.Payment{ID: 4, EmployeeID: 1, Status: "processing", Amount: 10, Addr: alice.Hex(), CreateAt: &createAt},
+	}, {
+		Employee: "alice",
+		Payment:  &entity.Payment{ID: 5, EmployeeID: 1, Status: "created", Amount: 20, Addr: alice.Hex(), CreateAt: &later},
+	}}}
+	recovery := &recoveredPayments{}
+	log := &auditLog{}
+	locks := &payrollLocks{}
+	h := New(nil, nil, Repositories{
+		Reports:   reports,
+		Employees: &employeeList{employees: []*entity.Employee{{ID: 1, Name: "alice", Addr: alice.Hex()}}},
+		Recovery:  recovery,
+		Audit:     log,
+		Locks:     locks,
+	}, &config.Config{})
+
+	// the signer sent nonce 1 in block 100, which reverted, nonce 2 in block
+	// 150 paying alice three times and nonce 3 in block 200
+	txs := make(map[uint64]*types.Transaction)
+	for nonce, block := range map[uint64]uint64{1: 100, 2: 150, 3: 200} {
+		tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)),
+			&types.LegacyTx{Nonce: nonce, To: &alice, Gas: 21_000, GasPrice: big.NewInt(1)})
+		require.NoError(t, err)
+		txs[block] = tx
+	}
+	client := &ethereum.MockClient{
+		FilterLogsFn: func(ctx context.Context, q goethereum.FilterQuery) ([]types.Log, error) {
+			var logs []types.Log
+			for _, amount := range []int64{10, 15, 20} {
+				logs = append(logs, types.Log{
+					Address:     common.HexToAddress(payment.USDCContractAddress),
+					Topics:      []common.Hash{reconcile.TransferTopic, common.BytesToHash(signer.Bytes()), common.BytesToHash(alice.Bytes())},
+					Data:        common.BigToHash(big.NewInt(amount)).Bytes(),
+					BlockNumber: 150,
+					TxHash:      txs[150].Hash(),
+				})
+			}
+			return logs, nil
+		},
+		NonceAtFn: func(ctx context.Context, account common.Address, block *big.Int) (uint64, error) {
+			return block.Uint64() / 50, nil
+		},
+		HeaderByNumberFn: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: number, Time: uint64(createAt.Unix()) + number.Uint64()}, nil
+		},
+		BlockByNumberFn: func(ctx context.Context, number *big.Int) (*types.Block, error) {
+			return types.NewBlockWithHeader(&types.Header{Number: number}).WithBody([]*types.Transaction{txs[number.Uint64()]}, nil), nil
+		},
+		TransactionReceiptFn: func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			if txHash == txs[100].Hash() {
+				return &types.Receipt{Status: types.ReceiptStatusFailed}, nil
+			}
+			return &types.Receipt{Status: types.ReceiptStatusSuccessful}, nil
+		},
+	}
+	opts := RecoverOptions{FromBlock: 100, ToBlock: 200, Treasuries: []string{signer.Hex()}, Format: "csv"}
+
+	// without --apply the recovery is only listed
+	var out bytes.Buffer
+	require.NoError(t, h.Recover(context.Background(), &out, client, opts))
+	assert.Empty(t, recovery.payments)
+	assert.Contains(t, out.String(), ",4,1,alice,payment was processing\n")
+	assert.Contains(t, out.String(), ",,1,alice,recovered from block 150\n")
+	// payment 5 was created after the transfer of its amount was mined
+	assert.Contains(t, out.String(), "ambiguous,"+txs[150].Hash().Hex()+",150,")
+	assert.Contains(t, out.String(), ",,1,alice,\"may pay payment 5, matches none of their attempts, not applied\"\n")
+	assert.Contains(t, out.String(), "reverted,"+txs[100].Hash().Hex()+",100,"+signer.Hex()+",,,,,,,transaction 1 reverted\n")
+	assert.Contains(t, out.String(), "unaccounted,"+txs[200].Hash().Hex()+",200,"+signer.Hex()+",,,,,,,transaction 3 transferred no tokens\n")
+
+	// recovering waits for the payroll lock
+	opts.Apply = true
+	locks.owner = "another"
+	assert.ErrorIs(t, h.Recover(context.Background(), &bytes.Buffer{}, client, opts), ErrLocked)
+	assert.Empty(t, recovery.payments)
+
+	locks.owner = ""
+	require.NoError(t, h.Recover(context.Background(), &bytes.Buffer{}, client, opts))
+	require.Len(t, recovery.payments, 2)
+	assert.Equal(t, int64(4), recovery.payments[0].Payment.ID)
+	assert.Equal(t, int64(15), recovery.payments[1].Amount)
+	assert.Empty(t, locks.owner)
+	require.Len(t, log.entries, 1)
+	assert.Equal(t, "recovery.run", log.entries[0].Action)
+	assert.Equal(t, int64(9), log.entries[0].EntityID)
+	// the transactions are kept with their nonces, reverted or not
+	assert.Contains(t, log.entries[0].Details, `{"block":100,"nonce":1,"reverted":true,"transfers":0,"tx_hash":"`+txs[100].Hash().Hex()+`"}`)
+	assert.Contains(t, log.entries[0].Details, `{"block":150,"nonce":2,"reverted":false,"transfers":3,"tx_hash":"`+txs[150].Hash().Hex()+`"}`)
+}
+
+// payrollLocks is a LockRepository of a single lock
+type payrollLocks struct {
+	owner string
+}
+
+func (l *payrollLocks) Acquire(_ context.Context, _, owner string, _ time.Duration) (bool, error) {
+	if l.owner != "" && l.owner != owner {
+		return false, nil
+	}
+	l.owner = owner
+	return true, nil
+}
+
+func (l *payrollLocks) Release(_ context.Context, _, owner string) error {
+	if l.owner == owner {
+		l.owner = ""
+	}
+	return nil
+}
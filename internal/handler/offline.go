@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.midas.dev/back/river/internal/offline"
+)
+
+// Broadcast executes the broadcast command
+func (h *Handler) Broadcast(ctx context.Context, path string) error {
+	batch, err := offline.Read(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	err = h.withLock(ctx, func(ctx context.Context) error {
+		return h.salaryService.Broadcast(ctx, batch)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to broadcast %s: %w", path, err)
+	}
+
+	fmt.Printf("broadcast %d transactions of salary %d\n", len(batch.Transactions), batch.SalaryID)
+	return nil
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/accounting"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/payslip"
+)
+
+// PayslipOptions holds the flags of the payslip command
+type PayslipOptions struct {
+	// Period selects the payroll runs, as parsed by payroll.ParsePeriod
+	Period string
+	// EmployeeID limits the payslips to an employee, 0 for all
+	EmployeeID int64
+	// Dir is the directory the payslips are written to
+	Dir string
+	// Format is html, pdf or both
+	Format string
+	// Templates is a directory of payslip.html and payslip.txt templates
+	// replacing the built-in ones
+	Templates string
+}
+
+// GeneratePayslips executes the payslip command, writing a payslip for every
+// employee paid by the payroll runs of the period and listing the files
+func (h *Handler) GeneratePayslips(ctx context.Context, w io.Writer, opts PayslipOptions) error {
+	if opts.Period == "" {
+		return errors.New("pass the payroll period with --period")
+	}
+	period, err := payroll.ParsePeriod(opts.Period)
+	if err != nil {
+		return err
+	}
+
+	var html, pdf bool
+	switch opts.Format {
+	case "html":
+		html = true
+	case "pdf":
+		pdf = true
+	case "", "both":
+		html, pdf = true, true
+	default:
+		return fmt.Errorf("unknown payslip format %q, expected html, pdf or both", opts.Format)
+	}
+
+	renderer, err := payslip.NewRenderer(opts.Templates)
+	if err != nil {
+		return fmt.Errorf("failed to load the payslip templates: %w", err)
+	}
+
+	records, err := h.reports.RunPayments(ctx, period.Start, period.End.AddDate(0, 0, 1))
+	if err != nil {
+		return fmt.Errorf("failed to read the payments of the period: %w", err)
+	}
+
+	builder := payslip.Builder{
+		Currency:    newJournal(accounting.DefaultAccounts()).Currency,
+		ExplorerURL: h.config.ExplorerURL,
+		Issued:      time.Now(),
+	}
+	payslips := builder.Build(records)
+
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return err
+	}
+
+	written := 0
+	for _, p := range payslips {
+		if opts.EmployeeID != 0 && p.EmployeeID != opts.EmployeeID {
+			continue
+		}
+
+		if html {
+			if err := writePayslip(w, filepath.Join(opts.Dir, p.Name()+".html"), p, renderer.HTML); err != nil {
+				return err
+			}
+		}
+		if pdf {
+			if err := writePayslip(w, filepath.Join(opts.Dir, p.Name()+".pdf"), p, renderer.PDF); err != nil {
+				return err
+			}
+		}
+		written++
+	}
+
+	if written == 0 {
+		fmt.Fprintf(w, "No payments were paid by the payroll runs of %s\n", opts.Period)
+	}
+	return nil
+}
+
+// writePayslip renders a payslip into path, which it prints to w
+func writePayslip(w io.Writer, path string, p *payslip.Payslip, render func(io.Writer, *payslip.Payslip) error) error {
+	var buf bytes.Buffer
+	if err := render(&buf, p); err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	// payslips hold salaries, so they are readable by the owner only
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, path)
+	return nil
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/reconcile"
+	"gitlab.midas.dev/back/river/internal/report"
+	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/service/payment"
+)
+
+// ReconcileOptions holds the flags of the reconcile command
+type ReconcileOptions struct {
+	// FromBlock and ToBlock are the blocks scanned, inclusive
+	FromBlock uint64
+	ToBlock   uint64
+	// BatchSize is how many blocks are scanned per log query
+	BatchSize uint64
+	// Treasuries are addresses payments were sent from besides the paying
+	// wallets recorded, SAFE_ADDRESS and OFFLINE_ACCOUNT
+	Treasuries []string
+	// Format is table, csv or json
+	Format string
+}
+
+// ErrDiscrepancies is returned by Reconcile when the database and the chain disagree
+var ErrDiscrepancies = errors.New("the database and the chain disagree")
+
+// Reconcile executes the reconcile command, matching the ERC-20 transfers
+// sent from the treasuries in the blocks to the done payments and listing
+// the unmatched transfers, missing payments and amount mismatches
+func (h *Handler) Reconcile(ctx context.Context, w io.Writer, client ethereum.Client, opts ReconcileOptions) error {
+	format, err := report.ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	if opts.ToBlock == 0 {
+		return errors.New("pass the blocks to scan with --from-block and --to-block")
+	}
+
+	records, err := h.reports.Paid(ctx, time.Time{}, time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return fmt.Errorf("failed to read paid payments: %w", err)
+	}
+
+	treasuries, err := h.treasuries(opts.Treasuries, records)
+	if err != nil {
+		return err
+	}
+	r := &reconcile.Reconciler{
+		Client:     client,
+		Treasuries: treasuries,
+		// River paid in USDC only before it recorded the token of payments
+		DefaultToken: common.HexToAddress(payment.USDCContractAddress),
+		BatchSize:    opts.BatchSize,
+	}
+
+	transfers, err := r.Scan(ctx, opts.FromBlock, opts.ToBlock)
+	if err != nil {
+		return err
+	}
+	discrepancies, err := r.Match(ctx, transfers, records, opts.FromBlock, opts.ToBlock)
+	if err != nil {
+		return err
+	}
+
+	out := report.New("issue", "tx_hash", "block", "token", "from", "to", "amount", "payment_id", "employee", "detail")
+	for _, d := range discrepancies {
+		row := []interface{}{d.Kind, "", "", "", "", "", "", "", "", d.Detail}
+		if t := d.Transfer; t != nil {
+			row[1], row[2], row[3], row[4], row[5], row[6] = t.TxHash.Hex(), t.Block, t.Token.Hex(), t.From.Hex(), t.To.Hex(), t.Amount.String()
+		}
+		if rec := d.Record; rec != nil {
+			p := rec.Payment
+			row[7], row[8] = p.ID, rec.Employee
+			if d.Transfer == nil {
+				row[1], row[4], row[5], row[6] = p.TxHash, p.Payer, p.Addr, fmt.Sprint(p.Amount)
+			}
+		}
+		out.Add(row...)
+	}
+	if err := out.Write(w, format); err != nil {
+		return err
+	}
+
+	if len(discrepancies) > 0 {
+		return fmt.Errorf("%w: %d discrepancies in %d transfers", ErrDiscrepancies, len(discrepancies), len(transfers))
+	}
+	return nil
+}
+
+// treasuries returns the addresses payments are sent from: those given,
+// SAFE_ADDRESS, OFFLINE_ACCOUNT and the paying wallets of the records
+func (h *Handler) treasuries(addrs []string, records []*repository.PaymentRecord) ([]common.Address, error) {
+	unique := make(map[common.Address]bool)
+	for _, addr := range append(addrs, h.config.SafeAddress, h.config.OfflineAccount) {
+		if addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid treasury address %q", addr)
+		}
+		unique[common.HexToAddress(addr)] = true
+	}
+	for _, record := range records {
+		if common.IsHexAddress(record.Payment.Payer) {
+			unique[common.HexToAddress(record.Payment.Payer)] = true
+		}
+	}
+
+	treasuries := make([]common.Address, 0, len(unique))
+	for addr := range unique {
+		treasuries = append(treasuries, addr)
+	}
+	sort.Slice(treasuries, func(i, j int) bool {
+		return treasuries[i].Hex() < treasuries[j].Hex()
+	})
+	return treasuries, nil
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/reconcile"
+	"gitlab.midas.dev/back/river/internal/report"
+	"gitlab.midas.dev/back/river/internal/service/payment"
+)
+
+// RecoverOptions holds the flags of the recover command
+type RecoverOptions struct {
+	// FromBlock and ToBlock are the blocks scanned, inclusive
+	FromBlock uint64
+	ToBlock   uint64
+	// BatchSize is how many blocks are scanned per log query
+	BatchSize uint64
+	// Treasuries are the signing addresses and any other address payments
+	// were sent from, besides SAFE_ADDRESS and OFFLINE_ACCOUNT
+	Treasuries []string
+	// Apply records the recovered payments; without it they are only listed
+	Apply bool
+	// Format is table, csv or json
+	Format string
+}
+
+// Recover executes the recover command, rebuilding the payments sent from
+// the treasuries in the blocks from their Transfer events: unfinished
+// payments they paid are marked done, and transfers to employees no payment
+// accounts for are recorded as done payments. Transfers that may pay
+// unfinished payments but match none of their attempts are listed as
+// ambiguous and left alone. The transactions the treasuries sent are rebuilt
+// from their nonces: reverted ones and those that transferred nothing are
+// listed.
+func (h *Handler) Recover(ctx context.Context, w io.Writer, client ethereum.Client, opts RecoverOptions) error {
+	format, err := report.ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	if opts.ToBlock == 0 {
+		return errors.New("pass the blocks to scan with --from-block and --to-block")
+	}
+
+	records, err := h.reports.Paid(ctx, time.Time{}, time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return fmt.Errorf("failed to read paid payments: %w", err)
+	}
+	outstanding, err := h.reports.Outstanding(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read outstanding payments: %w", err)
+	}
+	employees, err := h.employees.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read employees: %w", err)
+	}
+
+	treasuries, err := h.treasuries(opts.Treasuries, records)
+	if err != nil {
+		return err
+	}
+	r := &reconcile.Reconciler{
+		Client:     client,
+		Treasuries: treasuries,
+		// River paid in USDC only before it recorded the token of payments
+		DefaultToken: common.HexToAddress(payment.USDCContractAddress),
+		BatchSize:    opts.BatchSize,
+	}
+
+	transfers, err := r.Scan(ctx, opts.FromBlock, opts.ToBlock)
+	if err != nil {
+		return err
+	}
+	attempts, err := r.Attempts(ctx, transfers, opts.FromBlock, opts.ToBlock)
+	if err != nil {
+		return err
+	}
+	plan, err := r.Plan(ctx, transfers, attempts, employees, append(records, outstanding...))
+	if err != nil {
+		return err
+	}
+	var recovered []*reconcile.Recovery
+	for _, recovery := range plan {
+		if recovery.Action == reconcile.Settle || recovery.Action == reconcile.Create {
+			recovered = append(recovered, recovery)
+		}
+	}
+
+	if opts.Apply {
+		err = h.withLock(ctx, func(ctx context.Context) error {
+			return h.applyRecovery(ctx, recovered, attempts, opts)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	out := report.New("action", "tx_hash", "block", "from", "to", "token", "amount", "payment_id", "employee_id", "employee", "detail")
+	for _, recovery := range plan {
+		t := recovery.Transfer
+		row := []interface{}{recovery.Action, t.TxHash.Hex(), t.Block, t.From.Hex(), t.To.Hex(), t.Token.Hex(), t.Amount.String(), "", "", "", ""}
+		if recovery.Record != nil {
+			row[7] = recovery.Record.Payment.ID
+		}
+		if recovery.Employee != nil {
+			row[8], row[9] = recovery.Employee.ID, recovery.Employee.Name
+		}
+		switch recovery.Action {
+		case reconcile.Settle:
+			row[10] = fmt.Sprintf("payment was %s", recovery.Record.Payment.Status)
+		case reconcile.Create:
+			row[10] = fmt.Sprintf("recovered from block %d", t.Block)
+		case reconcile.Ambiguous:
+			ids := make([]string, 0, len(recovery.Candidates))
+			for _, record := range recovery.Candidates {
+				ids = append(ids, strconv.FormatInt(record.Payment.ID, 10))
+			}
+			row[10] = fmt.Sprintf("may pay payment %s, matches none of their attempts, not applied", strings.Join(ids, " or "))
+		case reconcile.Unknown:
+			row[10] = "no employee or payment at the recipient"
+		}
+		out.Add(row...)
+	}
+	for _, a := range attempts {
+		for _, tx := range a.Transactions {
+			if tx.Reverted {
+				out.Add("reverted", tx.TxHash.Hex(), tx.Block, a.Treasury.Hex(), "", "", "", "", "", "", tx.String())
+			} else if tx.Transfers == 0 {
+				out.Add("unaccounted", tx.TxHash.Hex(), tx.Block, a.Treasury.Hex(), "", "", "", "", "", "", tx.String())
+			}
+		}
+	}
+	return out.Write(w, format)
+}
+
+// applyRecovery records the recovered payments, and the transactions of
+// the treasuries with their nonces in the audit log. It runs under the
+// payroll lock, so that no run pays what it settles.
+func (h *Handler) applyRecovery(ctx context.Context, recovered []*reconcile.Recovery, attempts []*reconcile.Attempts, opts RecoverOptions) error {
+	salaryID, err := h.recovery.Recover(ctx, reconcile.Payments(recovered))
+	if err != nil {
+		return fmt.Errorf("failed to record the recovered payments: %w", err)
+	}
+
+	sent := make(map[string]interface{})
+	for _, a := range attempts {
+		transactions := make([]map[string]interface{}, 0, len(a.Transactions))
+		for _, tx := range a.Transactions {
+			transactions = append(transactions, map[string]interface{}{
+				"nonce":     tx.Nonce,
+				"tx_hash":   tx.TxHash.Hex(),
+				"block":     tx.Block,
+				"reverted":  tx.Reverted,
+				"transfers": tx.Transfers,
+			})
+		}
+		sent[a.Treasury.Hex()] = map[string]interface{}{"sent": a.Sent, "transfers": a.Transfers, "transactions": transactions}
+	}
+	err = h.audit.Append(ctx, "recovery.run", "salary", salaryID, map[string]interface{}{
+		"from_block": opts.FromBlock,
+		"to_block":   opts.ToBlock,
+		"payments":   len(recovered),
+		"attempts":   sent,
+	})
+	if err != nil {
+		return err
+	}
+
+	if salaryID != 0 {
+		log.Printf("recovered %d payments, recording those of no payroll run in run %d", len(recovered), salaryID)
+	} else {
+		log.Printf("recovered %d payments", len(recovered))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/report"
+)
+
+// ReportOptions holds the flags of the report commands
+type ReportOptions struct {
+	// Period limits the report to a period as parsed by payroll.ParsePeriod;
+	// empty covers every period
+	Period string
+	// Format is table, csv or json
+	Format string
+}
+
+// reportRange returns the dates the report covers, from inclusive to exclusive
+func (opts ReportOptions) reportRange() (time.Time, time.Time, error) {
+	if opts.Period == "" {
+		return time.Time{}, time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	period, err := payroll.ParsePeriod(opts.Period)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return period.Start, period.End.AddDate(0, 0, 1), nil
+}
+
+// ReportHistory executes the report history command, listing the payments
+// of an employee paid, or created if not paid, in the period
+func (h *Handler) ReportHistory(ctx context.Context, w io.Writer, employeeID int64, opts ReportOptions) error {
+	format, err := report.ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	from, to, err := opts.reportRange()
+	if err != nil {
+		return err
+	}
+
+	records, err := h.reports.EmployeeHistory(ctx, employeeID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to read the history of employee %d: %w", employeeID, err)
+	}
+
+	r := report.New("id", "salary_id", "period", "status", "category",
+		"gross", "deductions", "net", "token", "tx_hash", "paid_at")
+	for _, rec := range records {
+		p := rec.Payment
+		r.Add(p.ID, p.SalaryID, rec.Period, p.Status, p.Category,
+			p.GrossAmount, p.DeductionAmount, p.Amount, p.Token, p.TxHash, p.PaidAt)
+	}
+	return r.Write(w, format)
+}
+
+// ReportTotals executes the report totals command, summing the done
+// payments of the runs for the period by token
+func (h *Handler) ReportTotals(ctx context.Context, w io.Writer, opts ReportOptions) error {
+	format, err := report.ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	from, to, err := opts.reportRange()
+	if err != nil {
+		return err
+	}
+
+	totals, err := h.reports.PeriodTotals(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to read payroll totals: %w", err)
+	}
+
+	r := report.New("salary_id", "period", "token", "payments", "gross", "deductions", "net")
+	for _, t := range totals {
+		r.Add(t.SalaryID, t.Period, t.Token, t.Payments, t.Gross, t.Deductions, t.Net)
+	}
+	return r.Write(w, format)
+}
+
+// ReportGas executes the report gas command, summing the gas of the
+// transactions River sent itself for the runs of the period
+func (h *Handler) ReportGas(ctx context.Context, w io.Writer, opts ReportOptions) error {
+	format, err := report.ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	from, to, err := opts.reportRange()
+	if err != nil {
+		return err
+	}
+
+	runs, err := h.reports.GasByRun(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to read gas spent: %w", err)
+	}
+
+	r := report.New("salary_id", "period", "transactions", "gas_used", "gas_fee_wei")
+	for _, run := range runs {
+		r.Add(run.SalaryID, run.Period, run.Transactions, run.GasUsed, run.GasFee)
+	}
+	return r.Write(w, format)
+}
+
+// ReportOutstanding executes the report outstanding command, listing the
+// payments that are neither done nor rejected
+func (h *Handler) ReportOutstanding(ctx context.Context, w io.Writer, opts ReportOptions) error {
+	format, err := report.ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.reports.Outstanding(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read outstanding payments: %w", err)
+	}
+
+	r := report.New("id", "salary_id", "period", "employee_id", "employee", "status", "category", "net", "error")
+	for _, rec := range records {
+		p := rec.Payment
+		r.Add(p.ID, p.SalaryID, rec.Period, p.EmployeeID, rec.Employee, p.Status, p.Category, p.Amount, p.Error)
+	}
+	return r.Write(w, format)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SafeStatus executes the safe status command
+func (h *Handler) SafeStatus(ctx context.Context, w io.Writer) error {
+	err := h.withLock(ctx, h.salaryService.SyncProposals)
+	if err != nil {
+		return fmt.Errorf("failed to sync Safe proposals: %w", err)
+	}
+
+	proposals, err := h.salaryService.SafeProposals(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list Safe proposals: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSALARY\tSAFE\tNONCE\tSAFE TX HASH\tPROPOSED")
+	for _, p := range proposals {
+		var proposed string
+		if p.CreateAt != nil {
+			proposed = p.CreateAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%s\t%s\n", p.ID, p.SalaryID, p.Safe, p.Nonce, p.SafeTxHash, proposed)
+	}
+	return tw.Flush()
+}
+
+// ConfirmSafeProposal executes the safe confirm command
+func (h *Handler) ConfirmSafeProposal(ctx context.Context, id int64, txHash string) error {
+	hash := common.HexToHash(txHash)
+	if len(common.FromHex(txHash)) != common.HashLength {
+		return fmt.Errorf("invalid transaction hash %q", txHash)
+	}
+
+	err := h.withLock(ctx, func(ctx context.Context) error {
+		status, err := h.salaryService.ConfirmProposal(ctx, id, hash.Hex())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("safe proposal %d %s\n", id, status)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to confirm Safe proposal: %w", err)
+	}
+	return nil
+}
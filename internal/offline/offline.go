@@ -0,0 +1,207 @@
+package offline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// Version is the version of the batch file format
+const Version = 1
+
+// ErrInvalidBatch is returned for batches whose transactions do not pay
+// exactly the payments they list
+var ErrInvalidBatch = errors.New("invalid offline batch")
+
+var transferSelector = crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+
+// Batch is the file of transactions paying a payroll run, exported unsigned
+// by River, signed on an offline machine and broadcast by River
+type Batch struct {
+	Version  int            `json:"version"`
+	SalaryID int64          `json:"salary_id"`
+	ChainID  *big.Int       `json:"chain_id"`
+	From     common.Address `json:"from"`
+	Token    common.Address `json:"token"`
+	// Transactions are ordered by nonce
+	Transactions []*Transaction `json:"transactions"`
+}
+
+// Transaction is a token transfer paying a single payment
+type Transaction struct {
+	PaymentID int64          `json:"payment_id"`
+	Recipient common.Address `json:"recipient"`
+	Amount    int64          `json:"amount"`
+	Nonce     uint64         `json:"nonce"`
+	Gas       uint64         `json:"gas"`
+	GasPrice  *big.Int       `json:"gas_price"`
+	Data      hexutil.Bytes  `json:"data"`
+	// SigningHash is the hash the sender signs, covering the nonce, fees,
+	// token, calldata and chain
+	SigningHash common.Hash `json:"signing_hash"`
+	// Signed is the raw signed transaction, set by Sign
+	Signed hexutil.Bytes `json:"signed,omitempty"`
+	Hash   *common.Hash  `json:"hash,omitempty"`
+}
+
+// Transfer returns the calldata of a token transfer
+func Transfer(to common.Address, amount *big.Int) []byte {
+	data := append([]byte{}, transferSelector...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
+
+// NewTransaction returns the unsigned transaction paying amount to recipient
+// with the token of the batch
+func (b *Batch) NewTransaction(paymentID int64, recipient common.Address, amount int64, nonce, gas uint64, gasPrice *big.Int) *Transaction {
+	t := &Transaction{
+		PaymentID: paymentID,
+		Recipient: recipient,
+		Amount:    amount,
+		Nonce:     nonce,
+		Gas:       gas,
+		GasPrice:  gasPrice,
+		Data:      Transfer(recipient, big.NewInt(amount)),
+	}
+	t.SigningHash = b.signer().Hash(b.unsigned(t))
+	return t
+}
+
+// Validate checks that every transaction pays its payment, and only it, and
+// still has the signing hash recorded at export
+func (b *Batch) Validate() error {
+	if b.Version != Version {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidBatch, b.Version)
+	}
+	if b.ChainID == nil || b.ChainID.Sign() <= 0 {
+		return fmt.Errorf("%w: missing chain ID", ErrInvalidBatch)
+	}
+
+	payments := make(map[int64]bool)
+	for i, t := range b.Transactions {
+		switch {
+		case payments[t.PaymentID]:
+			return fmt.Errorf("%w: payment %d paid twice", ErrInvalidBatch, t.PaymentID)
+		case t.Amount <= 0:
+			return fmt.Errorf("%w: payment %d amount %d", ErrInvalidBatch, t.PaymentID, t.Amount)
+		case t.GasPrice == nil:
+			return fmt.Errorf("%w: payment %d has no gas price", ErrInvalidBatch, t.PaymentID)
+		case i > 0 && t.Nonce != b.Transactions[i-1].Nonce+1:
+			return fmt.Errorf("%w: payment %d nonce %d out of sequence", ErrInvalidBatch, t.PaymentID, t.Nonce)
+		case !bytes.Equal(t.Data, Transfer(t.Recipient, big.NewInt(t.Amount))):
+			return fmt.Errorf("%w: payment %d data does not transfer %d to %s",
+				ErrInvalidBatch, t.PaymentID, t.Amount, t.Recipient.Hex())
+		case b.signer().Hash(b.unsigned(t)) != t.SigningHash:
+			return fmt.Errorf("%w: payment %d transaction does not match its signing hash", ErrInvalidBatch, t.PaymentID)
+		}
+		payments[t.PaymentID] = true
+	}
+
+	return nil
+}
+
+// Total returns the amount the batch pays
+func (b *Batch) Total() int64 {
+	var total int64
+	for _, t := range b.Transactions {
+		total += t.Amount
+	}
+	return total
+}
+
+// Sign validates the batch and signs its transactions with the sender's signer
+func Sign(ctx context.Context, b *Batch, sgn signer.Signer) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if sgn.Address() != b.From {
+		return fmt.Errorf("%w: signer %s is not the sender %s", ErrInvalidBatch, sgn.Address().Hex(), b.From.Hex())
+	}
+
+	for _, t := range b.Transactions {
+		signed, err := sgn.SignTx(ctx, b.unsigned(t), b.ChainID)
+		if err != nil {
+			return fmt.Errorf("payment %d: %w", t.PaymentID, err)
+		}
+
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		hash := signed.Hash()
+		t.Signed, t.Hash = raw, &hash
+	}
+
+	return nil
+}
+
+// SignedTx returns the signed transaction, checking that it is the one the
+// sender of the batch was asked to sign
+func (b *Batch) SignedTx(t *Transaction) (*types.Transaction, error) {
+	if len(t.Signed) == 0 {
+		return nil, fmt.Errorf("%w: payment %d is not signed", ErrInvalidBatch, t.PaymentID)
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(t.Signed); err != nil {
+		return nil, fmt.Errorf("%w: payment %d: %v", ErrInvalidBatch, t.PaymentID, err)
+	}
+
+	s := b.signer()
+	if s.Hash(tx) != t.SigningHash {
+		return nil, fmt.Errorf("%w: payment %d signed a different transaction", ErrInvalidBatch, t.PaymentID)
+	}
+
+	from, err := types.Sender(s, tx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payment %d: %v", ErrInvalidBatch, t.PaymentID, err)
+	}
+	if from != b.From {
+		return nil, fmt.Errorf("%w: payment %d signed by %s instead of %s", ErrInvalidBatch, t.PaymentID, from.Hex(), b.From.Hex())
+	}
+
+	return tx, nil
+}
+
+// Read reads a batch file
+func Read(path string) (*Batch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := new(Batch)
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBatch, err)
+	}
+	return b, nil
+}
+
+// Write writes a batch file readable only by its owner
+func Write(path string, b *Batch) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// unsigned returns the legacy transaction of t, calling the token contract
+func (b *Batch) unsigned(t *Transaction) *types.Transaction {
+	return types.NewTransaction(t.Nonce, b.Token, new(big.Int), t.Gas, t.GasPrice, t.Data)
+}
+
+func (b *Batch) signer() types.Signer {
+	return types.LatestSignerForChainID(b.ChainID)
+}
@@ -0,0 +1,143 @@
+package offline
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+const testKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+var (
+	alice = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	bob   = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+func newTestBatch(t *testing.T) (*Batch, signer.Signer) {
+	t.Helper()
+
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+	sgn := signer.NewKeySigner(key)
+
+	b := &Batch{
+		Version:  Version,
+		SalaryID: 3,
+		ChainID:  big.NewInt(1),
+		From:     sgn.Address(),
+		Token:    common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+	}
+	b.Transactions = []*Transaction{
+		b.NewTransaction(10, alice, 100, 7, 100_000, big.NewInt(1_000_000_000)),
+		b.NewTransaction(11, bob, 200, 8, 100_000, big.NewInt(1_000_000_000)),
+	}
+	return b, sgn
+}
+
+func TestBatch_SignRoundTrip(t *testing.T) {
+	b, sgn := newTestBatch(t)
+	require.NoError(t, b.Validate())
+	assert.Equal(t, int64(300), b.Total())
+
+	require.NoError(t, Sign(context.Background(), b, sgn))
+
+	path := filepath.Join(t.TempDir(), "signed.json")
+	require.NoError(t, Write(path, b))
+	read, err := Read(path)
+	require.NoError(t, err)
+	require.NoError(t, read.Validate())
+
+	for _, tr := range read.Transactions {
+		tx, err := read.SignedTx(tr)
+		require.NoError(t, err)
+		assert.Equal(t, *tr.Hash, tx.Hash())
+		assert.Equal(t, tr.Nonce, tx.Nonce())
+		assert.Equal(t, read.Token, *tx.To())
+		assert.Equal(t, []byte(tr.Data), tx.Data())
+	}
+}
+
+func TestBatch_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(b *Batch)
+	}{
+		{
+			name:   "recipient replaced",
+			tamper: func(b *Batch) { b.Transactions[0].Recipient = bob },
+		},
+		{
+			name: "recipient and data replaced",
+			tamper: func(b *Batch) {
+				b.Transactions[0].Recipient = bob
+				b.Transactions[0].Data = Transfer(bob, big.NewInt(100))
+			},
+		},
+		{
+			name:   "gas price raised",
+			tamper: func(b *Batch) { b.Transactions[1].GasPrice = big.NewInt(1_000_000_000_000) },
+		},
+		{
+			name:   "token replaced",
+			tamper: func(b *Batch) { b.Token = bob },
+		},
+		{
+			name:   "chain replaced",
+			tamper: func(b *Batch) { b.ChainID = big.NewInt(5) },
+		},
+		{
+			name:   "payment paid twice",
+			tamper: func(b *Batch) { b.Transactions[1].PaymentID = 10 },
+		},
+		{
+			name:   "nonce gap",
+			tamper: func(b *Batch) { b.Transactions[1].Nonce = 9 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, sgn := newTestBatch(t)
+			tt.tamper(b)
+
+			assert.ErrorIs(t, b.Validate(), ErrInvalidBatch)
+			assert.ErrorIs(t, Sign(context.Background(), b, sgn), ErrInvalidBatch)
+		})
+	}
+}
+
+func TestBatch_SignedTx(t *testing.T) {
+	b, sgn := newTestBatch(t)
+
+	_, err := b.SignedTx(b.Transactions[0])
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+
+	require.NoError(t, Sign(context.Background(), b, sgn))
+
+	// a transaction signed for another payment is refused
+	b.Transactions[0].Signed = b.Transactions[1].Signed
+	_, err = b.SignedTx(b.Transactions[0])
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+
+	// so is the same transaction signed by another key
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tx, err := signer.NewKeySigner(other).SignTx(context.Background(), b.unsigned(b.Transactions[1]), b.ChainID)
+	require.NoError(t, err)
+	b.Transactions[1].Signed, err = tx.MarshalBinary()
+	require.NoError(t, err)
+
+	_, err = b.SignedTx(b.Transactions[1])
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+
+	// and signing with another key than the sender
+	fresh, _ := newTestBatch(t)
+	assert.ErrorIs(t, Sign(context.Background(), fresh, signer.NewKeySigner(other)), ErrInvalidBatch)
+}
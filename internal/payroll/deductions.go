@@ -0,0 +1,49 @@
+package payroll
+
+import (
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// basisPoints is 100%
+const basisPoints = 10_000
+
+// Withholding is an amount withheld by a deduction
+type Withholding struct {
+	Deduction *entity.Deduction
+	Amount    int64
+}
+
+// ApplyDeductions evaluates the active deductions in order against the gross
+// amount. Percentages are taken from the gross amount, and deductions are
+// capped so the net amount never becomes negative.
+func ApplyDeductions(gross int64, deductions []*entity.Deduction) (int64, []Withholding) {
+	net := gross
+	withheld := make([]Withholding, 0, len(deductions))
+
+	for _, d := range deductions {
+		if !d.Active {
+			continue
+		}
+
+		var amount int64
+		switch repository.DeductionKind(d.Kind) {
+		case repository.FixedDeduction:
+			amount = d.Amount
+		case repository.PercentDeduction:
+			amount = gross * d.Amount / basisPoints
+		}
+
+		if amount > net {
+			amount = net
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		net -= amount
+		withheld = append(withheld, Withholding{Deduction: d, Amount: amount})
+	}
+
+	return net, withheld
+}
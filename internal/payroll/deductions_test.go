@@ -0,0 +1,28 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestApplyDeductions(t *testing.T) {
+	tax := &entity.Deduction{Name: "tax", Kind: string(repository.PercentDeduction), Amount: 1250, Active: true}
+	loan := &entity.Deduction{Name: "loan", Kind: string(repository.FixedDeduction), Amount: 300, Active: true}
+	old := &entity.Deduction{Name: "old", Kind: string(repository.FixedDeduction), Amount: 100, Active: false}
+
+	net, withheld := ApplyDeductions(2000, []*entity.Deduction{tax, loan, old})
+	assert.Equal(t, int64(1450), net)
+	assert.Equal(t, []Withholding{{Deduction: tax, Amount: 250}, {Deduction: loan, Amount: 300}}, withheld)
+
+	// deductions never take the net amount below zero
+	net, withheld = ApplyDeductions(400, []*entity.Deduction{tax, loan, loan})
+	assert.Equal(t, int64(0), net)
+	assert.Equal(t, []Withholding{{Deduction: tax, Amount: 50}, {Deduction: loan, Amount: 300}, {Deduction: loan, Amount: 50}}, withheld)
+
+	net, withheld = ApplyDeductions(400, nil)
+	assert.Equal(t, int64(400), net)
+	assert.Empty(t, withheld)
+}
@@ -0,0 +1,88 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// ErrLimitExceeded is returned for payments that break a spending limit
+var ErrLimitExceeded = errors.New("spending limit exceeded")
+
+// Limits are guardrails checked before a payment is signed. Zero disables a limit.
+type Limits struct {
+	// MaxPayment caps a single payment
+	MaxPayment int64
+	// MaxRunTotal caps everything a payroll run sends
+	MaxRunTotal int64
+	// MaxEmployeePeriod caps what an employee receives for one period
+	MaxEmployeePeriod int64
+	// MaxChangePercent caps the change of an employee's gross salary versus
+	// their previous one
+	MaxChangePercent int64
+}
+
+// Check returns the violations of the payments about to be sent, by payment
+// ID. Payments released by a reviewer are not checked but count toward the
+// totals. A run over MaxRunTotal holds back all of its unreviewed payments.
+func (l Limits) Check(payments []*entity.Payment, history *repository.SpendingHistory) map[int64]error {
+	violations := make(map[int64]error)
+
+	if history == nil {
+		history = &repository.SpendingHistory{}
+	}
+
+	runTotal := history.RunSent
+	employeeTotal := make(map[int64]int64)
+	for _, p := range payments {
+		runTotal += p.Amount
+
+		withholding := p.Category == string(repository.WithholdingCategory)
+		if !withholding {
+			if _, ok := employeeTotal[p.EmployeeID]; !ok {
+				employeeTotal[p.EmployeeID] = history.EmployeePeriod[p.EmployeeID]
+			}
+			employeeTotal[p.EmployeeID] += p.Amount
+		}
+
+		if p.Reviewed {
+			continue
+		}
+
+		switch {
+		case l.MaxPayment > 0 && p.Amount > l.MaxPayment:
+			violations[p.ID] = fmt.Errorf("%w: payment of %d above %d", ErrLimitExceeded, p.Amount, l.MaxPayment)
+		case !withholding && l.MaxEmployeePeriod > 0 && employeeTotal[p.EmployeeID] > l.MaxEmployeePeriod:
+			violations[p.ID] = fmt.Errorf("%w: employee %d would receive %d for the period, above %d",
+				ErrLimitExceeded, p.EmployeeID, employeeTotal[p.EmployeeID], l.MaxEmployeePeriod)
+		case p.Category == string(repository.SalaryCategory) && l.MaxChangePercent > 0:
+			previous := history.PreviousSalary[p.EmployeeID]
+			if previous > 0 && changePercentAbove(previous, p.GrossAmount, l.MaxChangePercent) {
+				violations[p.ID] = fmt.Errorf("%w: salary of %d changed from %d by more than %d%%",
+					ErrLimitExceeded, p.GrossAmount, previous, l.MaxChangePercent)
+			}
+		}
+	}
+
+	if l.MaxRunTotal > 0 && runTotal > l.MaxRunTotal {
+		for _, p := range payments {
+			if _, ok := violations[p.ID]; !ok && !p.Reviewed {
+				violations[p.ID] = fmt.Errorf("%w: run total of %d above %d", ErrLimitExceeded, runTotal, l.MaxRunTotal)
+			}
+		}
+	}
+
+	return violations
+}
+
+// changePercentAbove reports whether current differs from previous by more
+// than percent
+func changePercentAbove(previous, current, percent int64) bool {
+	diff := current - previous
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff*100 > previous*percent
+}
@@ -0,0 +1,52 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestLimits_Check(t *testing.T) {
+	salary := func(id, employeeID, amount int64) *entity.Payment {
+		return &entity.Payment{ID: id, EmployeeID: employeeID, Amount: amount, GrossAmount: amount,
+			Category: string(repository.SalaryCategory)}
+	}
+
+	history := &repository.SpendingHistory{
+		EmployeePeriod: map[int64]int64{2: 1000},
+		PreviousSalary: map[int64]int64{1: 1000, 3: 1000},
+	}
+
+	payments := []*entity.Payment{
+		salary(1, 1, 1100),  // +10%
+		salary(2, 2, 600),   // 1600 for the period
+		salary(3, 3, 2000),  // doubled
+		salary(4, 4, 99999), // above MaxPayment
+		{ID: 5, EmployeeID: 2, Amount: 900, Category: string(repository.WithholdingCategory)},
+	}
+
+	limits := Limits{MaxPayment: 5000, MaxEmployeePeriod: 1500, MaxChangePercent: 20}
+	violations := limits.Check(payments, history)
+
+	assert.Len(t, violations, 3)
+	assert.NotContains(t, violations, int64(1))
+	assert.ErrorIs(t, violations[2], ErrLimitExceeded)
+	assert.ErrorIs(t, violations[3], ErrLimitExceeded)
+	assert.ErrorIs(t, violations[4], ErrLimitExceeded)
+	assert.NotContains(t, violations, int64(5))
+
+	// reviewed payments are released
+	payments[3].Reviewed = true
+	assert.Len(t, limits.Check(payments, history), 2)
+
+	// the run total holds back every unreviewed payment
+	limits = Limits{MaxRunTotal: 1000}
+	violations = limits.Check(payments, &repository.SpendingHistory{})
+	assert.Len(t, violations, 4)
+	assert.NotContains(t, violations, int64(4))
+
+	// no limits, no violations
+	assert.Empty(t, Limits{}.Check(payments, nil))
+}
@@ -0,0 +1,84 @@
+package payroll
+
+import (
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+const day = 24 * time.Hour
+
+// MonthPeriod returns the calendar month containing t
+func MonthPeriod(t time.Time) entity.Period {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return entity.Period{Start: start, End: start.AddDate(0, 1, -1)}
+}
+
+// Days returns the number of calendar days in the period
+func Days(period entity.Period) int64 {
+	return daysBetween(truncate(period.Start), truncate(period.End)) + 1
+}
+
+// Eligible reports whether the employee should be paid for the period
+func Eligible(emp *entity.Employee, period entity.Period) bool {
+	return workedDays(emp, period) > 0
+}
+
+// Prorate returns the amount owed to the employee for the period and whether
+// the employee is eligible at all. Employees who join or leave mid-period are
+// paid for the calendar days they were employed, rounded down.
+func Prorate(emp *entity.Employee, period entity.Period) (int64, bool) {
+	worked := workedDays(emp, period)
+	if worked <= 0 {
+		return 0, false
+	}
+
+	total := Days(period)
+	amount := int64(emp.SalaryAmount)
+	if worked >= total {
+		return amount, true
+	}
+
+	return amount * worked / total, true
+}
+
+// workedDays returns the number of days of the period the employee was employed
+func workedDays(emp *entity.Employee, period entity.Period) int64 {
+	switch repository.EmployeeStatus(emp.Status) {
+	case repository.ActiveEmployeeStatus, "":
+	case repository.TerminatedEmployeeStatus:
+		// A terminated employee only gets a final payout for the period
+		// the employment ended in, so the end date must be known.
+		if emp.EndDate == nil {
+			return 0
+		}
+	default:
+		return 0
+	}
+
+	start := truncate(period.Start)
+	end := truncate(period.End)
+
+	if emp.StartDate != nil && truncate(*emp.StartDate).After(start) {
+		start = truncate(*emp.StartDate)
+	}
+	if emp.EndDate != nil && truncate(*emp.EndDate).Before(end) {
+		end = truncate(*emp.EndDate)
+	}
+
+	if end.Before(start) {
+		return 0
+	}
+	return daysBetween(start, end) + 1
+}
+
+func truncate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int64 {
+	return int64(to.Sub(from) / day)
+}
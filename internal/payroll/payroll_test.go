@@ -0,0 +1,103 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func date(year int, month time.Month, d int) *time.Time {
+	t := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestMonthPeriod(t *testing.T) {
+	period := MonthPeriod(time.Date(2026, time.February, 14, 15, 4, 5, 0, time.UTC))
+	assert.Equal(t, *date(2026, time.February, 1), period.Start)
+	assert.Equal(t, *date(2026, time.February, 28), period.End)
+	assert.Equal(t, int64(28), Days(period))
+}
+
+func TestProrate(t *testing.T) {
+	period := MonthPeriod(*date(2026, time.October, 1))
+
+	tests := []struct {
+		name     string
+		employee entity.Employee
+		amount   int64
+		eligible bool
+	}{
+		{
+			name:     "active full period",
+			employee: entity.Employee{SalaryAmount: 3100, Status: string(repository.ActiveEmployeeStatus)},
+			amount:   3100,
+			eligible: true,
+		},
+		{
+			name:     "legacy row without status",
+			employee: entity.Employee{SalaryAmount: 3100},
+			amount:   3100,
+			eligible: true,
+		},
+		{
+			name:     "on leave",
+			employee: entity.Employee{SalaryAmount: 3100, Status: string(repository.OnLeaveEmployeeStatus)},
+			eligible: false,
+		},
+		{
+			name: "joined mid period",
+			employee: entity.Employee{
+				SalaryAmount: 3100,
+				Status:       string(repository.ActiveEmployeeStatus),
+				StartDate:    date(2026, time.October, 22),
+			},
+			amount:   1000,
+			eligible: true,
+		},
+		{
+			name: "starts next period",
+			employee: entity.Employee{
+				SalaryAmount: 3100,
+				Status:       string(repository.ActiveEmployeeStatus),
+				StartDate:    date(2026, time.November, 1),
+			},
+			eligible: false,
+		},
+		{
+			name: "terminated mid period gets final payout",
+			employee: entity.Employee{
+				SalaryAmount: 3100,
+				Status:       string(repository.TerminatedEmployeeStatus),
+				EndDate:      date(2026, time.October, 10),
+			},
+			amount:   1000,
+			eligible: true,
+		},
+		{
+			name: "terminated in a previous period",
+			employee: entity.Employee{
+				SalaryAmount: 3100,
+				Status:       string(repository.TerminatedEmployeeStatus),
+				EndDate:      date(2026, time.September, 30),
+			},
+			eligible: false,
+		},
+		{
+			name:     "terminated without end date",
+			employee: entity.Employee{SalaryAmount: 3100, Status: string(repository.TerminatedEmployeeStatus)},
+			eligible: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, eligible := Prorate(&tt.employee, period)
+			assert.Equal(t, tt.eligible, eligible)
+			assert.Equal(t, tt.amount, amount)
+			assert.Equal(t, tt.eligible, Eligible(&tt.employee, period))
+		})
+	}
+}
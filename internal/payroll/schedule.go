@@ -0,0 +1,109 @@
+package payroll
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+)
+
+// Schedule defines how often payroll runs
+type Schedule string
+
+const (
+	Monthly     Schedule = "monthly"
+	SemiMonthly Schedule = "semi-monthly"
+	Weekly      Schedule = "weekly"
+	// Custom marks runs for an explicitly given date range
+	Custom Schedule = "custom"
+)
+
+const (
+	monthLayout = "2006-01"
+	dayLayout   = "2006-01-02"
+	rangeSep    = ".."
+)
+
+// ParseSchedule validates a schedule name
+func ParseSchedule(s string) (Schedule, error) {
+	switch schedule := Schedule(strings.ToLower(strings.TrimSpace(s))); schedule {
+	case Monthly, SemiMonthly, Weekly:
+		return schedule, nil
+	default:
+		return "", fmt.Errorf("unknown pay schedule %q", s)
+	}
+}
+
+// PeriodAt returns the period of the schedule containing t
+func (s Schedule) PeriodAt(t time.Time) entity.Period {
+	t = truncate(t)
+
+	switch s {
+	case SemiMonthly:
+		if t.Day() <= 15 {
+			start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+			return entity.Period{Start: start, End: start.AddDate(0, 0, 14)}
+		}
+		month := MonthPeriod(t)
+		return entity.Period{Start: month.Start.AddDate(0, 0, 15), End: month.End}
+	case Weekly:
+		// ISO weeks start on Monday
+		offset := (int(t.Weekday()) + 6) % 7
+		start := t.AddDate(0, 0, -offset)
+		return entity.Period{Start: start, End: start.AddDate(0, 0, 6)}
+	default:
+		return MonthPeriod(t)
+	}
+}
+
+// ParsePeriod parses a period given as a month (2026-10), an ISO week
+// (2026-W42) or an inclusive date range (2026-10-01..2026-10-14)
+func ParsePeriod(s string) (entity.Period, error) {
+	s = strings.TrimSpace(s)
+
+	if from, to, ok := strings.Cut(s, rangeSep); ok {
+		start, err := time.Parse(dayLayout, from)
+		if err != nil {
+			return entity.Period{}, fmt.Errorf("invalid period start %q: %w", from, err)
+		}
+		end, err := time.Parse(dayLayout, to)
+		if err != nil {
+			return entity.Period{}, fmt.Errorf("invalid period end %q: %w", to, err)
+		}
+		if end.Before(start) {
+			return entity.Period{}, fmt.Errorf("period %q ends before it starts", s)
+		}
+		return entity.Period{Start: start, End: end}, nil
+	}
+
+	var year, week int
+	if n, err := fmt.Sscanf(s, "%4d-W%2d", &year, &week); err == nil && n == 2 {
+		if week < 1 || week > 53 {
+			return entity.Period{}, fmt.Errorf("invalid week in period %q", s)
+		}
+		// January 4th is always in ISO week 1
+		period := Weekly.PeriodAt(time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC))
+		period.Start = period.Start.AddDate(0, 0, 7*(week-1))
+		period.End = period.End.AddDate(0, 0, 7*(week-1))
+		return period, nil
+	}
+
+	month, err := time.Parse(monthLayout, s)
+	if err != nil {
+		return entity.Period{}, fmt.Errorf("invalid period %q, expected YYYY-MM, YYYY-Www or YYYY-MM-DD..YYYY-MM-DD", s)
+	}
+	return MonthPeriod(month), nil
+}
+
+// Label returns the canonical name of the period, e.g. 2026-10 for a calendar
+// month and 2026-10-01..2026-10-15 for any other range
+func Label(period entity.Period) string {
+	start := truncate(period.Start)
+	end := truncate(period.End)
+
+	if month := MonthPeriod(start); month.Start.Equal(start) && month.End.Equal(end) {
+		return start.Format(monthLayout)
+	}
+	return start.Format(dayLayout) + rangeSep + end.Format(dayLayout)
+}
@@ -0,0 +1,68 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulePeriodAt(t *testing.T) {
+	at := time.Date(2026, time.October, 21, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		schedule Schedule
+		label    string
+	}{
+		{Monthly, "2026-10"},
+		{SemiMonthly, "2026-10-16..2026-10-31"},
+		{Weekly, "2026-10-19..2026-10-25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.schedule), func(t *testing.T) {
+			assert.Equal(t, tt.label, Label(tt.schedule.PeriodAt(at)))
+		})
+	}
+
+	assert.Equal(t, "2026-10-01..2026-10-15", Label(SemiMonthly.PeriodAt(*date(2026, time.October, 15))))
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		in      string
+		label   string
+		wantErr bool
+	}{
+		{in: "2026-10", label: "2026-10"},
+		{in: "2026-10-01..2026-10-14", label: "2026-10-01..2026-10-14"},
+		{in: "2026-10-01..2026-10-31", label: "2026-10"},
+		{in: "2026-W01", label: "2025-12-29..2026-01-04"},
+		{in: "2026-W43", label: "2026-10-19..2026-10-25"},
+		{in: "2026-10-14..2026-10-01", wantErr: true},
+		{in: "2026-W60", wantErr: true},
+		{in: "october", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			period, err := ParsePeriod(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.label, Label(period))
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("Semi-Monthly")
+	require.NoError(t, err)
+	assert.Equal(t, SemiMonthly, schedule)
+
+	_, err = ParseSchedule("fortnightly")
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package payslip
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"gitlab.midas.dev/back/river/internal/accounting"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+const (
+	// HTMLTemplate and TextTemplate are the names of the templates payslips
+	// are rendered with; the text template is laid out in the PDF
+	HTMLTemplate = "payslip.html"
+	TextTemplate = "payslip.txt"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Payslip is what an employee was paid by a payroll run in a token.
+// Amounts are formatted in the token's units.
+type Payslip struct {
+	SalaryID   int64
+	Period     string
+	EmployeeID int64
+	Employee   string
+	Token      string
+	Issued     time.Time
+	Lines      []Line
+	Gross      string
+	Deductions string
+	Net        string
+}
+
+// Line is a payment of a payslip
+type Line struct {
+	PaymentID int64
+	Category  string
+	Memo      string
+	// Rate is the salary the gross amount was prorated from, empty for
+	// payments not prorated
+	Rate       string
+	Gross      string
+	Deductions string
+	Net        string
+	Address    string
+	TxHash     string
+	// TxURL links the transaction on a block explorer
+	TxURL  string
+	PaidAt *time.Time
+}
+
+// Builder groups the paid payments of payroll runs into payslips
+type Builder struct {
+	// Currency returns the currency of the token at an address
+	Currency func(token string) accounting.Currency
+	// ExplorerURL is the block explorer transactions are linked to, empty for none
+	ExplorerURL string
+	Issued      time.Time
+}
+
+// Build returns a payslip for every employee and token of every run with
+// done payments. Withholdings paid to a treasury are left out, they are
+// the deductions of the employee's salary.
+func (b Builder) Build(records []*repository.PaymentRecord) []*Payslip {
+	type key struct {
+		salaryID, employeeID int64
+		token                string
+	}
+
+	var payslips []*Payslip
+	totals := make(map[*Payslip][3]int64)
+	currencies := make(map[*Payslip]accounting.Currency)
+	index := make(map[key]*Payslip)
+
+	for _, record := range records {
+		p := record.Payment
+		if p.Status != string(repository.DoneStatus) || p.Category == string(repository.WithholdingCategory) {
+			continue
+		}
+
+		currency := b.Currency(p.Token)
+		k := key{p.SalaryID, p.EmployeeID, currency.Code}
+		slip, ok := index[k]
+		if !ok {
+			slip = &Payslip{
+				SalaryID:   p.SalaryID,
+				Period:     record.Period,
+				EmployeeID: p.EmployeeID,
+				Employee:   record.Employee,
+				Token:      currency.Code,
+				Issued:     b.Issued,
+			}
+			index[k] = slip
+			currencies[slip] = currency
+			payslips = append(payslips, slip)
+		}
+
+		line := Line{
+			PaymentID:  p.ID,
+			Category:   p.Category,
+			Memo:       p.Memo,
+			Gross:      currency.Format(p.GrossAmount),
+			Deductions: currency.Format(p.DeductionAmount),
+			Net:        currency.Format(p.Amount),
+			Address:    p.Addr,
+			TxHash:     p.TxHash,
+			PaidAt:     p.PaidAt,
+		}
+		if p.Rate > 0 {
+			line.Rate = currency.Format(p.Rate)
+		}
+		if p.TxHash != "" && b.ExplorerURL != "" {
+			line.TxURL = strings.TrimSuffix(b.ExplorerURL, "/") + "/tx/" + p.TxHash
+		}
+		slip.Lines = append(slip.Lines, line)
+
+		t := totals[slip]
+		totals[slip] = [3]int64{t[0] + p.GrossAmount, t[1] + p.DeductionAmount, t[2] + p.Amount}
+	}
+
+	for _, slip := range payslips {
+		t, currency := totals[slip], currencies[slip]
+		slip.Gross, slip.Deductions, slip.Net = currency.Format(t[0]), currency.Format(t[1]), currency.Format(t[2])
+	}
+
+	return payslips
+}
+
+// Name returns the base name of the payslip's files, e.g.
+// payslip-2026-10-run3-employee7
+func (p *Payslip) Name() string {
+	period := strings.NewReplacer("..", "_", "/", "_").Replace(p.Period)
+	return fmt.Sprintf("payslip-%s-run%d-employee%d", period, p.SalaryID, p.EmployeeID)
+}
+
+// Renderer renders payslips with the templates
+type Renderer struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// NewRenderer parses the built-in templates, replaced by payslip.html and
+// payslip.txt in dir if it is set and they exist
+func NewRenderer(dir string) (*Renderer, error) {
+	htmlSrc, err := source(dir, HTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	textSrc, err := source(dir, TextTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.New(HTMLTemplate).Parse(htmlSrc)
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(TextTemplate).Parse(textSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Renderer{html: html, text: text}, nil
+}
+
+// source returns the template name from dir, or the built-in one
+func source(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	data, err := templates.ReadFile("templates/" + name)
+	return string(data), err
+}
+
+// HTML writes the payslip as an HTML page
+func (r *Renderer) HTML(w io.Writer, p *Payslip) error {
+	return r.html.Execute(w, p)
+}
+
+// PDF writes the lines of the text template as a PDF document
+func (r *Renderer) PDF(w io.Writer, p *Payslip) error {
+	var buf bytes.Buffer
+	if err := r.text.Execute(&buf, p); err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	return writePDF(w, lines)
+}
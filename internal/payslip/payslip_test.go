@@ -0,0 +1,122 @@
+package payslip
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/accounting"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func testBuilder() Builder {
+	return Builder{
+		Currency: func(token string) accounting.Currency {
+			return accounting.Currency{Code: "USDC", Decimals: 6}
+		},
+		ExplorerURL: "https://etherscan.io/",
+		Issued:      time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func testRecords() []*repository.PaymentRecord {
+	paidAt := time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC)
+	record := func(p entity.Payment) *repository.PaymentRecord {
+		return &repository.PaymentRecord{Payment: &p, Employee: "alice <& co>", Period: "2026-10"}
+	}
+	return []*repository.PaymentRecord{
+		record(entity.Payment{ID: 1, SalaryID: 3, EmployeeID: 7, Status: "done", Category: "salary", Addr: "0x01",
+			GrossAmount: 1_000_000, DeductionAmount: 100_000, Amount: 900_000, Rate: 2_000_000, TxHash: "0xt1", PaidAt: &paidAt}),
+		record(entity.Payment{ID: 2, SalaryID: 3, EmployeeID: 7, Status: "done", Category: "bonus", Memo: "Q3",
+			GrossAmount: 500_000, Amount: 500_000, TxHash: "0xt2", PaidAt: &paidAt}),
+		record(entity.Payment{ID: 3, SalaryID: 3, EmployeeID: 8, Status: "needs_review", Category: "salary", Amount: 1}),
+		record(entity.Payment{ID: 4, SalaryID: 3, EmployeeID: 7, Status: "done", Category: "withholding", Amount: 100_000}),
+	}
+}
+
+func TestBuilder_Build(t *testing.T) {
+	payslips := testBuilder().Build(testRecords())
+	require.Len(t, payslips, 1)
+
+	p := payslips[0]
+	assert.Equal(t, int64(3), p.SalaryID)
+	assert.Equal(t, int64(7), p.EmployeeID)
+	assert.Equal(t, "USDC", p.Token)
+	assert.Equal(t, "1.500000", p.Gross)
+	assert.Equal(t, "0.100000", p.Deductions)
+	assert.Equal(t, "1.400000", p.Net)
+	assert.Equal(t, "payslip-2026-10-run3-employee7", p.Name())
+
+	require.Len(t, p.Lines, 2)
+	assert.Equal(t, "2.000000", p.Lines[0].Rate)
+	assert.Equal(t, "https://etherscan.io/tx/0xt1", p.Lines[0].TxURL)
+	// the bonus was not prorated from a rate
+	assert.Empty(t, p.Lines[1].Rate)
+}
+
+func TestRenderer_HTML(t *testing.T) {
+	r, err := NewRenderer("")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, r.HTML(&out, testBuilder().Build(testRecords())[0]))
+	assert.Contains(t, out.String(), "alice &lt;&amp; co&gt;")
+	assert.Contains(t, out.String(), `href="https://etherscan.io/tx/0xt1"`)
+	assert.Contains(t, out.String(), "1.400000")
+}
+
+func TestRenderer_PDF(t *testing.T) {
+	r, err := NewRenderer("")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, r.PDF(&out, testBuilder().Build(testRecords())[0]))
+	assert.True(t, strings.HasPrefix(out.String(), "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(out.String(), "%%EOF\n"))
+	assert.Contains(t, out.String(), "(  Transaction 0xt1) '")
+	assert.Contains(t, out.String(), "/Count 1")
+}
+
+func TestRenderer_Templates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, TextTemplate), []byte("{{.Employee}} {{.Net}}\n"), 0o600))
+
+	r, err := NewRenderer(dir)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, r.PDF(&out, testBuilder().Build(testRecords())[0]))
+	assert.Contains(t, out.String(), `(alice <& co> 1.400000) '`)
+
+	// the HTML template is still the built-in one
+	out.Reset()
+	require.NoError(t, r.HTML(&out, testBuilder().Build(testRecords())[0]))
+	assert.Contains(t, out.String(), "<html")
+}
+
+func TestWritePDF(t *testing.T) {
+	lines := make([]string, linesPerPage+1)
+	lines[0] = `a (b) \c é ✓`
+	lines[1] = strings.Repeat("x", maxLineLength+1)
+
+	var out bytes.Buffer
+	require.NoError(t, writePDF(&out, lines))
+	assert.Contains(t, out.String(), `(a \(b\) \\c \351 ?) '`)
+	// the long line wrapped, pushing the last line onto a second page
+	assert.Contains(t, out.String(), "/Count 2")
+	assert.Contains(t, out.String(), "(x) '")
+
+	// startxref points at the xref table
+	pdf := out.String()
+	var offset int
+	_, err := fmt.Sscanf(pdf[strings.LastIndex(pdf, "startxref\n"):], "startxref\n%d", &offset)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(pdf[offset:], "xref\n0 8\n"))
+}
@@ -0,0 +1,123 @@
+package payslip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Pages are A4 with text in 10pt Courier, so that the columns of the text
+// template stay aligned
+const (
+	pageWidth     = 595
+	pageHeight    = 842
+	margin        = 56
+	fontSize      = 10
+	leading       = 13
+	linesPerPage  = (pageHeight - 2*margin) / leading
+	maxLineLength = (pageWidth - 2*margin) * 10 / (6 * fontSize)
+)
+
+// writePDF writes lines of text as a minimal PDF document. Lines too long
+// for the page are wrapped, and characters outside Latin-1 are replaced.
+func writePDF(w io.Writer, lines []string) error {
+	var wrapped []string
+	for _, line := range lines {
+		wrapped = append(wrapped, wrap(line)...)
+	}
+
+	var pages [][]string
+	for len(wrapped) > linesPerPage {
+		pages = append(pages, wrapped[:linesPerPage])
+		wrapped = wrapped[linesPerPage:]
+	}
+	pages = append(pages, wrapped)
+
+	// objects 1 and 2 are the catalog and the page tree, 3 the font, then
+	// every page is followed by its content stream
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		content := pageContent(page)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+				"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pageContent returns the content stream showing the lines from the top of the page
+func pageContent(lines []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, pageHeight-margin)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "(%s) '\n", escape(line))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+// wrap splits a line into lines fitting the page
+func wrap(line string) []string {
+	runes := []rune(line)
+	if len(runes) <= maxLineLength {
+		return []string{line}
+	}
+
+	var lines []string
+	for len(runes) > maxLineLength {
+		lines = append(lines, string(runes[:maxLineLength]))
+		runes = runes[maxLineLength:]
+	}
+	return append(lines, string(runes))
+}
+
+// escape encodes a line as the bytes of a PDF string in WinAnsiEncoding,
+// which matches Latin-1 for printable characters
+func escape(line string) string {
+	var b strings.Builder
+	for _, r := range line {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '\t':
+			b.WriteString("    ")
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
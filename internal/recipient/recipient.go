@@ -0,0 +1,129 @@
+package recipient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// ErrUnsafeRecipient is returned for addresses River must not pay
+var ErrUnsafeRecipient = errors.New("unsafe recipient")
+
+// CodeReader reads the contract code deployed at an account
+type CodeReader interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// Checker validates the recipients of payments before they are signed
+type Checker struct {
+	code    CodeReader
+	blocked map[common.Address]string
+}
+
+// NewChecker creates a checker refusing payments to the blocked addresses,
+// described by what they are, and warning about contracts when code is set
+func NewChecker(code CodeReader, blocked map[common.Address]string) *Checker {
+	return &Checker{
+		code:    code,
+		blocked: blocked,
+	}
+}
+
+// Validate parses a recipient address, refusing malformed ones, mixed-case
+// ones with a wrong EIP-55 checksum and the zero address
+func Validate(addr string) (common.Address, error) {
+	if !common.IsHexAddress(addr) {
+		return common.Address{}, fmt.Errorf("%w: %q is not an address", ErrUnsafeRecipient, addr)
+	}
+
+	address := common.HexToAddress(addr)
+
+	digits := strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X")
+	mixed := digits != strings.ToLower(digits) && digits != strings.ToUpper(digits)
+	if mixed && "0x"+digits != address.Hex() {
+		return common.Address{}, fmt.Errorf("%w: %s has an invalid checksum", ErrUnsafeRecipient, addr)
+	}
+
+	if address == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%w: zero address", ErrUnsafeRecipient)
+	}
+
+	return address, nil
+}
+
+// Check returns the payments that must not be sent, by payment ID. Invalid
+// and blocked addresses are always refused; an address shared by several
+// employees is refused unless a reviewer released the payment. Recipients
+// that are contracts are only logged.
+func (c *Checker) Check(ctx context.Context, payments []*entity.Payment) map[int64]error {
+	violations := make(map[int64]error)
+
+	employees := make(map[common.Address]map[int64]bool)
+	addrs := make(map[int64]common.Address)
+	for _, p := range payments {
+		addr, err := Validate(p.Addr)
+		if err != nil {
+			violations[p.ID] = err
+			continue
+		}
+
+		if what, ok := c.blocked[addr]; ok {
+			violations[p.ID] = fmt.Errorf("%w: %s is the %s", ErrUnsafeRecipient, addr.Hex(), what)
+			continue
+		}
+
+		addrs[p.ID] = addr
+
+		// withholding payments go to treasuries shared by design
+		if p.Category == string(repository.WithholdingCategory) {
+			continue
+		}
+		if employees[addr] == nil {
+			employees[addr] = make(map[int64]bool)
+		}
+		employees[addr][p.EmployeeID] = true
+	}
+
+	checked := make(map[common.Address]bool)
+	for _, p := range payments {
+		addr, ok := addrs[p.ID]
+		if !ok {
+			continue
+		}
+
+		shared := len(employees[addr]) > 1
+		if shared && !p.Reviewed && p.Category != string(repository.WithholdingCategory) {
+			violations[p.ID] = fmt.Errorf("%w: %s is shared by %d employees",
+				ErrUnsafeRecipient, addr.Hex(), len(employees[addr]))
+			continue
+		}
+
+		if c.code != nil && !checked[addr] {
+			checked[addr] = true
+			c.warnContract(ctx, addr)
+		}
+	}
+
+	return violations
+}
+
+// warnContract logs when the address holds contract code, which may not be
+// able to move the tokens it receives
+func (c *Checker) warnContract(ctx context.Context, addr common.Address) {
+	code, err := c.code.CodeAt(ctx, addr, nil)
+	if err != nil {
+		log.Printf("check code at %s error: %v", addr.Hex(), err)
+		return
+	}
+
+	if len(code) > 0 {
+		log.Printf("warning: recipient %s is a contract", addr.Hex())
+	}
+}
@@ -0,0 +1,89 @@
+package recipient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+type codeReader map[common.Address][]byte
+
+func (c codeReader) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if account == common.HexToAddress("0x00000000000000000000000000000000000000ee") {
+		return nil, errors.New("node unavailable")
+	}
+	return c[account], nil
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr bool
+	}{
+		{name: "checksummed", addr: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "lower case", addr: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		{name: "upper case", addr: "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"},
+		{name: "wrong checksum", addr: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", wantErr: true},
+		{name: "zero address", addr: "0x0000000000000000000000000000000000000000", wantErr: true},
+		{name: "not hex", addr: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeZ", wantErr: true},
+		{name: "too short", addr: "0x5aAeb6053F", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := Validate(tt.addr)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnsafeRecipient)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, common.HexToAddress(tt.addr), addr)
+		})
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	token := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	own := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	shared := "0x00000000000000000000000000000000000000aa"
+	treasury := "0x00000000000000000000000000000000000000bb"
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+	checker := NewChecker(codeReader{contract: {0x60, 0x80}}, map[common.Address]string{
+		token: "token contract",
+		own:   "River signer",
+	})
+
+	violations := checker.Check(context.Background(), []*entity.Payment{
+		{ID: 1, EmployeeID: 1, Addr: "0x0000000000000000000000000000000000000000"},
+		{ID: 2, EmployeeID: 2, Addr: token.Hex()},
+		{ID: 3, EmployeeID: 3, Addr: own.Hex(), Reviewed: true},
+		{ID: 4, EmployeeID: 4, Addr: shared},
+		{ID: 5, EmployeeID: 5, Addr: shared},
+		{ID: 6, EmployeeID: 6, Addr: shared, Reviewed: true},
+		// withholding payments share treasuries by design
+		{ID: 7, EmployeeID: 7, Addr: treasury, Category: string(repository.WithholdingCategory)},
+		{ID: 8, EmployeeID: 8, Addr: treasury, Category: string(repository.WithholdingCategory)},
+		// one employee paid twice to the same address is fine
+		{ID: 9, EmployeeID: 9, Addr: contract.Hex()},
+		{ID: 10, EmployeeID: 9, Addr: contract.Hex(), Category: string(repository.BonusCategory)},
+		{ID: 11, EmployeeID: 11, Addr: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"},
+		{ID: 12, EmployeeID: 12, Addr: "0x00000000000000000000000000000000000000ee"},
+	})
+
+	assert.Len(t, violations, 6)
+	for _, id := range []int64{1, 2, 3, 4, 5, 11} {
+		assert.ErrorIs(t, violations[id], ErrUnsafeRecipient, "payment %d", id)
+	}
+	assert.Contains(t, violations[2].Error(), "token contract")
+	assert.Contains(t, violations[3].Error(), "River signer")
+	assert.Contains(t, violations[4].Error(), "shared by 3 employees")
+}
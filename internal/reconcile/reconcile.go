@@ -0,0 +1,260 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// TransferTopic is the topic of ERC-20 Transfer(address,address,uint256) events
+var TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// DefaultBatchSize is how many blocks are scanned per log query, below the
+// limits nodes put on eth_getLogs
+const DefaultBatchSize = 5_000
+
+// Transfer is an ERC-20 Transfer event
+type Transfer struct {
+	TxHash   common.Hash
+	Block    uint64
+	LogIndex uint
+	Token    common.Address
+	From     common.Address
+	To       common.Address
+	Amount   *big.Int
+}
+
+// Kind is the kind of a discrepancy between the database and the chain
+type Kind string
+
+const (
+	// UnmatchedTransfer is a transfer from a treasury no payment records
+	UnmatchedTransfer Kind = "unmatched_transfer"
+	// MissingPayment is a payment recorded as done that no transfer of its
+	// transaction pays
+	MissingPayment Kind = "missing_payment"
+	// AmountMismatch is a transfer of a payment's transaction to its
+	// recipient of another amount or token than the payment
+	AmountMismatch Kind = "amount_mismatch"
+)
+
+// Discrepancy is a transfer or payment the other side does not agree with
+type Discrepancy struct {
+	Kind Kind
+	// Transfer is nil for missing payments
+	Transfer *Transfer
+	// Record is nil for unmatched transfers
+	Record *repository.PaymentRecord
+	Detail string
+}
+
+// Reconciler matches the Transfer events sent from treasury addresses to
+// the payments recorded as done
+type Reconciler struct {
+	Client ethereum.Client
+	// Treasuries are the addresses payments are sent from
+	Treasuries []common.Address
+	// DefaultToken is the token of payments recorded without one
+	DefaultToken common.Address
+	// BatchSize is how many blocks are scanned per log query, DefaultBatchSize if 0
+	BatchSize uint64
+}
+
+// Scan returns the Transfer events sent from the treasuries in the blocks
+// from and to, inclusive, in chain order
+func (r *Reconciler) Scan(ctx context.Context, from, to uint64) ([]*Transfer, error) {
+	if len(r.Treasuries) == 0 {
+		return nil, errors.New("no treasury addresses to scan")
+	}
+	if from > to {
+		return nil, fmt.Errorf("block range %d..%d is empty", from, to)
+	}
+
+	batch := r.BatchSize
+	if batch == 0 {
+		batch = DefaultBatchSize
+	}
+
+	senders := make([]common.Hash, len(r.Treasuries))
+	for i, addr := range r.Treasuries {
+		senders[i] = common.BytesToHash(addr.Bytes())
+	}
+
+	var transfers []*Transfer
+	for start := from; start <= to; start += batch {
+		end := start + batch - 1
+		if end > to || end < start {
+			end = to
+		}
+
+		logs, err := r.Client.FilterLogs(ctx, goethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Topics:    [][]common.Hash{{TransferTopic}, senders},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the logs of blocks %d..%d: %w", start, end, err)
+		}
+
+		for _, log := range logs {
+			if transfer := parseTransfer(log); transfer != nil {
+				transfers = append(transfers, transfer)
+			}
+		}
+
+		if end == to {
+			break
+		}
+	}
+
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].Block != transfers[j].Block {
+			return transfers[i].Block < transfers[j].Block
+		}
+		return transfers[i].LogIndex < transfers[j].LogIndex
+	})
+	return transfers, nil
+}
+
+// parseTransfer returns the ERC-20 transfer of a log, nil for removed logs
+// and ERC-721 transfers, which index the token id
+func parseTransfer(log types.Log) *Transfer {
+	if log.Removed || len(log.Topics) != 3 || log.Topics[0] != TransferTopic || len(log.Data) != 32 {
+		return nil
+	}
+
+	return &Transfer{
+		TxHash:   log.TxHash,
+		Block:    log.BlockNumber,
+		LogIndex: log.Index,
+		Token:    log.Address,
+		From:     common.BytesToAddress(log.Topics[1].Bytes()),
+		To:       common.BytesToAddress(log.Topics[2].Bytes()),
+		Amount:   new(big.Int).SetBytes(log.Data),
+	}
+}
+
+// Match pairs the transfers of the blocks from and to with the done
+// payments by transaction hash, recipient and amount, and returns what
+// either side lacks. Payments whose transaction is not among the transfers
+// are looked up, and left out if it was mined outside the blocks.
+func (r *Reconciler) Match(ctx context.Context, transfers []*Transfer, records []*repository.PaymentRecord, from, to uint64) ([]*Discrepancy, error) {
+	type recipient struct {
+		tx common.Hash
+		to common.Address
+	}
+
+	scanned := make(map[common.Hash]bool)
+	for _, t := range transfers {
+		scanned[t.TxHash] = true
+	}
+
+	payments := make(map[recipient][]*repository.PaymentRecord)
+	var done []*repository.PaymentRecord
+	for _, record := range records {
+		p := record.Payment
+		if p.Status != string(repository.DoneStatus) || p.TxHash == "" {
+			continue
+		}
+		k := recipient{common.HexToHash(p.TxHash), common.HexToAddress(p.Addr)}
+		payments[k] = append(payments[k], record)
+		done = append(done, record)
+	}
+
+	var discrepancies []*Discrepancy
+	matched := make(map[*repository.PaymentRecord]bool)
+	for _, t := range transfers {
+		candidates := payments[recipient{t.TxHash, t.To}]
+
+		var match *repository.PaymentRecord
+		for _, record := range candidates {
+			if !matched[record] && r.pays(record, t) {
+				match = record
+				break
+			}
+		}
+		if match == nil {
+			for _, record := range candidates {
+				if !matched[record] {
+					match = record
+					break
+				}
+			}
+			if match == nil {
+				discrepancies = append(discrepancies, &Discrepancy{
+					Kind: UnmatchedTransfer, Transfer: t, Detail: "no payment records this transfer",
+				})
+				continue
+			}
+
+			discrepancies = append(discrepancies, &Discrepancy{
+				Kind: AmountMismatch, Transfer: t, Record: match,
+				Detail: fmt.Sprintf("payment %d records %d of %s", match.Payment.ID, match.Payment.Amount, r.token(match).Hex()),
+			})
+		}
+		matched[match] = true
+	}
+
+	receipts := make(map[common.Hash]*types.Receipt)
+	for _, record := range done {
+		if matched[record] {
+			continue
+		}
+
+		hash := common.HexToHash(record.Payment.TxHash)
+		if scanned[hash] {
+			discrepancies = append(discrepancies, &Discrepancy{
+				Kind: MissingPayment, Record: record, Detail: "no transfer of its transaction pays the recipient",
+			})
+			continue
+		}
+
+		receipt, ok := receipts[hash]
+		if !ok {
+			var err error
+			receipt, err = r.Client.TransactionReceipt(ctx, hash)
+			if err != nil && !errors.Is(err, goethereum.NotFound) {
+				return nil, fmt.Errorf("failed to read the receipt of %s: %w", hash.Hex(), err)
+			}
+			receipts[hash] = receipt
+		}
+
+		var detail string
+		switch {
+		case receipt == nil || receipt.BlockNumber == nil:
+			detail = "transaction not found on chain"
+		case receipt.BlockNumber.Uint64() < from || receipt.BlockNumber.Uint64() > to:
+			// mined outside the scanned blocks
+			continue
+		case receipt.Status != types.ReceiptStatusSuccessful:
+			detail = "transaction failed"
+		default:
+			detail = "no transfer from a treasury in its transaction pays the recipient"
+		}
+		discrepancies = append(discrepancies, &Discrepancy{Kind: MissingPayment, Record: record, Detail: detail})
+	}
+
+	return discrepancies, nil
+}
+
+// pays reports whether a transfer is of the amount and token of a payment
+func (r *Reconciler) pays(record *repository.PaymentRecord, t *Transfer) bool {
+	return t.Token == r.token(record) && t.Amount.Cmp(big.NewInt(record.Payment.Amount)) == 0
+}
+
+// token returns the token a payment was sent in
+func (r *Reconciler) token(record *repository.PaymentRecord) common.Address {
+	if record.Payment.Token == "" {
+		return r.DefaultToken
+	}
+	return common.HexToAddress(record.Payment.Token)
+}
@@ -0,0 +1,150 @@
+package reconcile
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+var (
+	treasury = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	usdc     = common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	alice    = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	bob      = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+// transferLog returns the log of a transfer from the treasury
+func transferLog(tx string, block uint64, to common.Address, amount int64) types.Log {
+	return types.Log{
+		Address:     usdc,
+		Topics:      []common.Hash{TransferTopic, common.BytesToHash(treasury.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.BigToHash(big.NewInt(amount)).Bytes(),
+		BlockNumber: block,
+		TxHash:      common.HexToHash(tx),
+	}
+}
+
+func paid(id int64, tx string, to common.Address, amount int64) *repository.PaymentRecord {
+	return &repository.PaymentRecord{Payment: &entity.Payment{
+		ID: id, Status: "done", TxHash: common.HexToHash(tx).Hex(), Addr: to.Hex(), Amount: amount,
+	}}
+}
+
+func TestReconciler_Scan(t *testing.T) {
+	var queries []goethereum.FilterQuery
+	client := &ethereum.MockClient{
+		FilterLogsFn: func(ctx context.Context, q goethereum.FilterQuery) ([]types.Log, error) {
+			queries = append(queries, q)
+			if q.FromBlock.Uint64() > 100 {
+				return nil, nil
+			}
+
+			nft := transferLog("0x02", 100, alice, 0)
+			nft.Topics = append(nft.Topics, common.Hash{})
+			nft.Data = nil
+			return []types.Log{transferLog("0x01", 100, alice, 5), nft}, nil
+		},
+	}
+
+	r := &Reconciler{Client: client, Treasuries: []common.Address{treasury}, BatchSize: 100}
+	transfers, err := r.Scan(context.Background(), 1, 250)
+	require.NoError(t, err)
+
+	require.Len(t, queries, 3)
+	assert.Equal(t, uint64(101), queries[1].FromBlock.Uint64())
+	assert.Equal(t, uint64(200), queries[1].ToBlock.Uint64())
+	assert.Equal(t, uint64(250), queries[2].ToBlock.Uint64())
+	assert.Equal(t, [][]common.Hash{{TransferTopic}, {common.BytesToHash(treasury.Bytes())}}, queries[0].Topics)
+
+	// the ERC-721 transfer is skipped
+	require.Len(t, transfers, 1)
+	assert.Equal(t, &Transfer{
+		TxHash: common.HexToHash("0x01"), Block: 100, Token: usdc, From: treasury, To: alice, Amount: big.NewInt(5),
+	}, transfers[0])
+
+	_, err = r.Scan(context.Background(), 10, 9)
+	assert.Error(t, err)
+	_, err = (&Reconciler{Client: client}).Scan(context.Background(), 1, 2)
+	assert.Error(t, err)
+}
+
+func TestReconciler_Match(t *testing.T) {
+	client := &ethereum.MockClient{
+		TransactionReceiptFn: func(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+			switch hash {
+			case common.HexToHash("0x05"):
+				return &types.Receipt{Status: 1, BlockNumber: big.NewInt(500)}, nil
+			case common.HexToHash("0x06"):
+				return &types.Receipt{Status: 0, BlockNumber: big.NewInt(150)}, nil
+			}
+			return nil, goethereum.NotFound
+		},
+	}
+	r := &Reconciler{Client: client, Treasuries: []common.Address{treasury}, DefaultToken: usdc}
+
+	var transfers []*Transfer
+	for _, log := range []types.Log{
+		transferLog("0x01", 100, alice, 10),
+		transferLog("0x01", 100, bob, 20),
+		transferLog("0x02", 110, bob, 99),
+		transferLog("0x03", 120, alice, 7),
+	} {
+		transfers = append(transfers, parseTransfer(log))
+	}
+
+	records := []*repository.PaymentRecord{
+		// a Safe transaction paying both, matched
+		paid(1, "0x01", alice, 10),
+		paid(2, "0x01", bob, 20),
+		// the wrong amount reached bob
+		paid(3, "0x02", bob, 30),
+		// mined outside the blocks
+		paid(4, "0x05", alice, 10),
+		// failed, and never mined
+		paid(5, "0x06", alice, 10),
+		paid(6, "0x07", alice, 10),
+		// not done
+		{Payment: &entity.Payment{ID: 7, Status: "processing", TxHash: "0x08"}},
+	}
+
+	discrepancies, err := r.Match(context.Background(), transfers, records, 100, 200)
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 4)
+
+	assert.Equal(t, AmountMismatch, discrepancies[0].Kind)
+	assert.Equal(t, int64(3), discrepancies[0].Record.Payment.ID)
+	assert.Equal(t, big.NewInt(99), discrepancies[0].Transfer.Amount)
+
+	assert.Equal(t, UnmatchedTransfer, discrepancies[1].Kind)
+	assert.Equal(t, common.HexToHash("0x03"), discrepancies[1].Transfer.TxHash)
+	assert.Nil(t, discrepancies[1].Record)
+
+	assert.Equal(t, MissingPayment, discrepancies[2].Kind)
+	assert.Equal(t, int64(5), discrepancies[2].Record.Payment.ID)
+	assert.Equal(t, "transaction failed", discrepancies[2].Detail)
+	assert.Equal(t, MissingPayment, discrepancies[3].Kind)
+	assert.Equal(t, int64(6), discrepancies[3].Record.Payment.ID)
+	assert.Equal(t, "transaction not found on chain", discrepancies[3].Detail)
+}
+
+func TestReconciler_MatchToken(t *testing.T) {
+	r := &Reconciler{Client: &ethereum.MockClient{}, DefaultToken: usdc}
+	transfer := parseTransfer(transferLog("0x01", 100, alice, 10))
+
+	record := paid(1, "0x01", alice, 10)
+	record.Payment.Token = "0x00000000000000000000000000000000000000dd"
+
+	discrepancies, err := r.Match(context.Background(), []*Transfer{transfer}, []*repository.PaymentRecord{record}, 100, 100)
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 1)
+	assert.Equal(t, AmountMismatch, discrepancies[0].Kind)
+}
@@ -0,0 +1,397 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/safe"
+)
+
+// Action is what recovery does with a transfer found on chain
+type Action string
+
+const (
+	// Recorded transfers are already recorded by a done payment
+	Recorded Action = "recorded"
+	// Settle transfers paid an unfinished payment, which is marked done
+	Settle Action = "settle"
+	// Create transfers paid an employee no payment accounts for, and are
+	// recorded as done payments
+	Create Action = "create"
+	// Ambiguous transfers may pay unfinished payments, but match none of
+	// their attempts to send them, and are left to the operator
+	Ambiguous Action = "ambiguous"
+	// Unknown transfers paid an address of no employee or payment, or an
+	// amount too large to record
+	Unknown Action = "unknown"
+)
+
+// Recovery is what recovery does with a transfer
+type Recovery struct {
+	Transfer *Transfer
+	Action   Action
+	// Record is the payment recording or settled by the transfer, nil for
+	// the other actions
+	Record *repository.PaymentRecord
+	// Candidates are the unfinished payments an ambiguous transfer may pay
+	Candidates []*repository.PaymentRecord
+	// Employee is the employee paid, nil for unknown transfers
+	Employee *entity.Employee
+	// PaidAt is when the block of the transfer was mined
+	PaidAt time.Time
+}
+
+// Plan decides what to do with every transfer: transfers of a done
+// payment's transaction to its recipient are recorded already, others
+// settle the one unfinished payment of the same recipient, amount and token
+// they match the attempt of, and the rest are recorded as new payments of
+// the employee at the recipient address. Transfers that may pay unfinished
+// payments but match no single attempt are ambiguous. Records are the done
+// and unfinished payments, attempts those of the scanned blocks.
+func (r *Reconciler) Plan(ctx context.Context, transfers []*Transfer, attempts []*Attempts, employees []*entity.Employee, records []*repository.PaymentRecord) ([]*Recovery, error) {
+	employeeByID := make(map[int64]*entity.Employee)
+	employeeByAddr := make(map[common.Address]*entity.Employee)
+	for _, emp := range employees {
+		employeeByID[emp.ID] = emp
+		addr := common.HexToAddress(emp.Addr)
+		if _, ok := employeeByAddr[addr]; !ok {
+			employeeByAddr[addr] = emp
+		}
+	}
+
+	nonces := make(map[common.Hash]*Attempt)
+	for _, a := range attempts {
+		for _, tx := range a.Transactions {
+			nonces[tx.TxHash] = tx
+		}
+	}
+
+	plan := make([]*Recovery, 0, len(transfers))
+	for _, t := range transfers {
+		plan = append(plan, &Recovery{Transfer: t, Action: Unknown})
+	}
+	if err := r.date(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	used := make(map[*repository.PaymentRecord]bool)
+	for _, recovery := range plan {
+		t := recovery.Transfer
+
+		if record := r.recorded(t, records, used); record != nil {
+			recovery.Action, recovery.Record = Recorded, record
+		} else if record, candidates, err := r.unfinished(ctx, recovery, nonces, records, used); err != nil {
+			return nil, err
+		} else if record != nil {
+			recovery.Action, recovery.Record = Settle, record
+		} else if len(candidates) > 0 {
+			recovery.Action, recovery.Candidates = Ambiguous, candidates
+			recovery.Employee = employeeByID[candidates[0].Payment.EmployeeID]
+		} else if emp, ok := employeeByAddr[t.To]; ok && t.Amount.IsInt64() {
+			recovery.Action, recovery.Employee = Create, emp
+		}
+
+		if recovery.Record != nil {
+			used[recovery.Record] = true
+			recovery.Employee = employeeByID[recovery.Record.Payment.EmployeeID]
+		}
+	}
+
+	return plan, nil
+}
+
+// recorded returns the done payment recording a transfer
+func (r *Reconciler) recorded(t *Transfer, records []*repository.PaymentRecord, used map[*repository.PaymentRecord]bool) *repository.PaymentRecord {
+	for _, record := range records {
+		p := record.Payment
+		if used[record] || p.Status != string(repository.DoneStatus) || p.TxHash == "" {
+			continue
+		}
+		if common.HexToHash(p.TxHash) == t.TxHash && common.HexToAddress(p.Addr) == t.To {
+			return record
+		}
+	}
+	return nil
+}
+
+// unfinished returns the payment a transfer pays that was not recorded as
+// done, whether it was never sent or interrupted while it was, if it is the
+// only one of the recipient, amount and token whose attempt the transfer
+// matches. Otherwise it returns the payments of the recipient, amount and
+// token the transfer may pay.
+func (r *Reconciler) unfinished(ctx context.Context, recovery *Recovery, nonces map[common.Hash]*Attempt, records []*repository.PaymentRecord, used map[*repository.PaymentRecord]bool) (*repository.PaymentRecord, []*repository.PaymentRecord, error) {
+	t := recovery.Transfer
+
+	var matched, candidates []*repository.PaymentRecord
+	for _, record := range records {
+		p := record.Payment
+		if used[record] || common.HexToAddress(p.Addr) != t.To || !r.pays(record, t) {
+			continue
+		}
+
+		switch repository.PaymentStatus(p.Status) {
+		case repository.CreatedStatus, repository.ProcessingStatus, repository.ProposedStatus, repository.ExportedStatus:
+		default:
+			continue
+		}
+
+		candidates = append(candidates, record)
+		ok, err := r.attempted(ctx, record, recovery, nonces)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			matched = append(matched, record)
+		}
+	}
+
+	if len(matched) == 1 {
+		return matched[0], nil, nil
+	}
+	return nil, candidates, nil
+}
+
+// attempted reports whether a transfer matches the attempt to send a
+// payment: the transaction of the nonce an exported payment was signed
+// with, the transaction recorded when a payment was sent, or the execution
+// of the Safe transaction a payment was proposed in. Payments sent without a
+// recorded transaction match transfers mined after they were created.
+func (r *Reconciler) attempted(ctx context.Context, record *repository.PaymentRecord, recovery *Recovery, nonces map[common.Hash]*Attempt) (bool, error) {
+	p, t := record.Payment, recovery.Transfer
+
+	switch repository.PaymentStatus(p.Status) {
+	case repository.ExportedStatus:
+		tx, ok := nonces[t.TxHash]
+		return ok && tx.Treasury == common.HexToAddress(p.Payer) && tx.Nonce == p.Nonce, nil
+	case repository.ProposedStatus:
+		if p.SafeTxHash == "" {
+			return false, nil
+		}
+		receipt, err := r.Client.TransactionReceipt(ctx, t.TxHash)
+		if err != nil {
+			return false, fmt.Errorf("failed to read the receipt of %s: %w", t.TxHash.Hex(), err)
+		}
+		executed, success := safe.Execution(receipt.Logs, t.From, common.HexToHash(p.SafeTxHash))
+		return executed && success, nil
+	case repository.ProcessingStatus:
+		if p.TxHash != "" {
+			return common.HexToHash(p.TxHash) == t.TxHash, nil
+		}
+		return p.CreateAt != nil && recovery.PaidAt.After(*p.CreateAt), nil
+	default:
+		return p.CreateAt != nil && recovery.PaidAt.After(*p.CreateAt), nil
+	}
+}
+
+// date sets when the transfers of the plan were paid from the time of their blocks
+func (r *Reconciler) date(ctx context.Context, plan []*Recovery) error {
+	times := make(map[uint64]time.Time)
+	for _, recovery := range plan {
+		block := recovery.Transfer.Block
+
+		at, ok := times[block]
+		if !ok {
+			header, err := r.Client.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+			if err != nil {
+				return fmt.Errorf("failed to read block %d: %w", block, err)
+			}
+			at = time.Unix(int64(header.Time), 0).UTC()
+			times[block] = at
+		}
+		recovery.PaidAt = at
+	}
+	return nil
+}
+
+// Payments returns the payments to record for the plan
+func Payments(plan []*Recovery) []*repository.RecoveredPayment {
+	var payments []*repository.RecoveredPayment
+	for _, recovery := range plan {
+		if recovery.Action != Settle && recovery.Action != Create {
+			continue
+		}
+
+		t := recovery.Transfer
+		p := &repository.RecoveredPayment{
+			Addr:     t.To.Hex(),
+			Amount:   t.Amount.Int64(),
+			Memo:     fmt.Sprintf("recovered from block %d", t.Block),
+			Transfer: entity.Transfer{TxHash: t.TxHash.Hex(), From: t.From.Hex(), Token: t.Token.Hex()},
+			PaidAt:   recovery.PaidAt,
+		}
+		if recovery.Action == Settle {
+			p.Payment = recovery.Record.Payment
+			p.EmployeeID = p.Payment.EmployeeID
+		} else {
+			p.EmployeeID = recovery.Employee.ID
+		}
+		payments = append(payments, p)
+	}
+	return payments
+}
+
+// Attempts is what the nonce history of a treasury says about the
+// transactions it sent in the scanned blocks
+type Attempts struct {
+	Treasury common.Address
+	// Sent is how many transactions the treasury sent
+	Sent uint64
+	// Transfers is how many of them transferred tokens from it
+	Transfers uint64
+	// Transactions are the transactions sent, by nonce
+	Transactions []*Attempt
+}
+
+// Attempt is a transaction a treasury sent
+type Attempt struct {
+	Treasury common.Address
+	Nonce    uint64
+	TxHash   common.Hash
+	Block    uint64
+	// Reverted transactions were mined but failed
+	Reverted bool
+	// Transfers is how many of the scanned transfers it made
+	Transfers int
+}
+
+// Unaccounted returns how many transactions sent transferred no tokens:
+// reverted payments, or transactions of something else
+func (a *Attempts) Unaccounted() uint64 {
+	if a.Sent < a.Transfers {
+		return 0
+	}
+	return a.Sent - a.Transfers
+}
+
+// Attempts rebuilds the transactions the treasuries sent in the blocks from
+// and to from their nonces before and after, finding the block of every
+// nonce, and matches them with the transfers, so that reverted transactions
+// are kept too. Contracts such as a Safe send no transactions of their own.
+func (r *Reconciler) Attempts(ctx context.Context, transfers []*Transfer, from, to uint64) ([]*Attempts, error) {
+	sent := make(map[common.Address]map[common.Hash]int)
+	for _, t := range transfers {
+		if sent[t.From] == nil {
+			sent[t.From] = make(map[common.Hash]int)
+		}
+		sent[t.From][t.TxHash]++
+	}
+
+	attempts := make([]*Attempts, 0, len(r.Treasuries))
+	for _, treasury := range r.Treasuries {
+		var before uint64
+		if from > 0 {
+			var err error
+			before, err = r.nonceAt(ctx, treasury, from-1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		after, err := r.nonceAt(ctx, treasury, to)
+		if err != nil {
+			return nil, err
+		}
+
+		a := &Attempts{Treasury: treasury, Transfers: uint64(len(sent[treasury]))}
+		block := from
+		for nonce := before; nonce < after; nonce++ {
+			block, err = r.nonceBlock(ctx, treasury, nonce, block, to)
+			if err != nil {
+				return nil, err
+			}
+			tx, err := r.attempt(ctx, treasury, nonce, block)
+			if err != nil {
+				return nil, err
+			}
+			tx.Transfers = sent[treasury][tx.TxHash]
+			a.Transactions = append(a.Transactions, tx)
+		}
+		a.Sent = uint64(len(a.Transactions))
+		attempts = append(attempts, a)
+	}
+	return attempts, nil
+}
+
+// nonceBlock returns the first block of from to to after which the nonce of
+// a treasury is past nonce, the block its transaction of nonce was mined in
+func (r *Reconciler) nonceBlock(ctx context.Context, treasury common.Address, nonce, from, to uint64) (uint64, error) {
+	for from < to {
+		mid := from + (to-from)/2
+		n, err := r.nonceAt(ctx, treasury, mid)
+		if err != nil {
+			return 0, err
+		}
+		if n > nonce {
+			to = mid
+		} else {
+			from = mid + 1
+		}
+	}
+	return from, nil
+}
+
+// attempt finds the transaction of a treasury with nonce in a block, and
+// whether it reverted
+func (r *Reconciler) attempt(ctx context.Context, treasury common.Address, nonce, number uint64) (*Attempt, error) {
+	block, err := r.Client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block %d: %w", number, err)
+	}
+
+	for _, tx := range block.Transactions() {
+		if tx.Nonce() != nonce {
+			continue
+		}
+		var signer types.Signer = types.HomesteadSigner{}
+		if tx.Protected() {
+			signer = types.LatestSignerForChainID(tx.ChainId())
+		}
+		if sender, err := types.Sender(signer, tx); err != nil || sender != treasury {
+			continue
+		}
+
+		receipt, err := r.Client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the receipt of %s: %w", tx.Hash().Hex(), err)
+		}
+		return &Attempt{
+			Treasury: treasury,
+			Nonce:    nonce,
+			TxHash:   tx.Hash(),
+			Block:    number,
+			Reverted: receipt.Status == types.ReceiptStatusFailed,
+		}, nil
+	}
+	return nil, fmt.Errorf("transaction %d of %s is not in block %d", nonce, treasury.Hex(), number)
+}
+
+// nonceAt returns the nonce of a treasury after a block
+func (r *Reconciler) nonceAt(ctx context.Context, treasury common.Address, block uint64) (uint64, error) {
+	nonce, err := r.Client.NonceAt(ctx, treasury, new(big.Int).SetUint64(block))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the nonce of %s: %w", treasury.Hex(), err)
+	}
+	return nonce, nil
+}
+
+// String describes the attempts
+func (a *Attempts) String() string {
+	return fmt.Sprintf("%d transactions sent, %d transferring tokens", a.Sent, a.Transfers)
+}
+
+// String describes the attempt
+func (a *Attempt) String() string {
+	switch {
+	case a.Reverted:
+		return fmt.Sprintf("transaction %d reverted", a.Nonce)
+	case a.Transfers == 0:
+		return fmt.Sprintf("transaction %d transferred no tokens", a.Nonce)
+	default:
+		return fmt.Sprintf("transaction %d made %d transfers", a.Nonce, a.Transfers)
+	}
+}
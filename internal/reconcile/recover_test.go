@@ -0,0 +1,199 @@
+package reconcile
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/safe"
+)
+
+func TestReconciler_Plan(t *testing.T) {
+	carol := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	safeTx := common.HexToHash("0x5a")
+	client := &ethereum.MockClient{
+		HeaderByNumberFn: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: number, Time: 1_793_000_000 + number.Uint64()}, nil
+		},
+		TransactionReceiptFn: func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			receipt := &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful}
+			if txHash == common.HexToHash("0x08") {
+				receipt.Logs = []*types.Log{{Address: treasury, Topics: safe.ExecutionTopics()[:1], Data: append(safeTx.Bytes(), make([]byte, 32)...)}}
+			}
+			return receipt, nil
+		},
+	}
+	r := &Reconciler{Client: client, Treasuries: []common.Address{treasury}, DefaultToken: usdc}
+
+	var transfers []*Transfer
+	for _, log := range []types.Log{
+		transferLog("0x01", 100, alice, 10),
+		transferLog("0x02", 110, bob, 20),
+		transferLog("0x03", 120, alice, 30),
+		transferLog("0x04", 130, carol, 40),
+		transferLog("0x05", 140, bob, 50),
+		transferLog("0x06", 150, bob, 60),
+		transferLog("0x07", 160, bob, 70),
+		transferLog("0x08", 170, bob, 80),
+	} {
+		transfers = append(transfers, parseTransfer(log))
+	}
+	attempts := []*Attempts{{Treasury: treasury, Transactions: []*Attempt{{Treasury: treasury, Nonce: 3, TxHash: common.HexToHash("0x07"), Block: 160}}}}
+
+	before, after := time.Unix(1_793_000_000, 0).UTC(), time.Unix(1_793_000_155, 0).UTC()
+	unfinished := func(id int64, status string, amount int64, createAt time.Time) *repository.PaymentRecord {
+		return &repository.PaymentRecord{Payment: &entity.Payment{
+			ID: id, EmployeeID: 2, Status: status, Addr: bob.Hex(), Amount: amount, CreateAt: &createAt,
+		}}
+	}
+	employees := []*entity.Employee{{ID: 1, Name: "alice", Addr: alice.Hex()}, {ID: 2, Name: "bob", Addr: "0x0000000000000000000000000000000000000002"}}
+	interrupted := unfinished(2, "processing", 20, before)
+	exported := unfinished(7, "exported", 70, before)
+	exported.Payment.Nonce, exported.Payment.Payer = 3, treasury.Hex()
+	other := unfinished(8, "exported", 70, before)
+	other.Payment.Nonce, other.Payment.Payer = 4, treasury.Hex()
+	proposed := unfinished(9, "proposed", 80, before)
+	proposed.Payment.SafeTxHash = safeTx.Hex()
+	records := []*repository.PaymentRecord{
+		paid(1, "0x01", alice, 10),
+		// bob's payment of another amount is not settled
+		unfinished(3, "created", 21, before),
+		interrupted,
+		// two payments of bob of the same amount
+		unfinished(4, "processing", 50, before),
+		unfinished(5, "created", 50, before),
+		// a payment created after the transfer was mined
+		unfinished(6, "created", 60, after),
+		exported,
+		other,
+		proposed,
+	}
+	records[0].Payment.EmployeeID = 1
+
+	plan, err := r.Plan(context.Background(), transfers, attempts, employees, records)
+	require.NoError(t, err)
+	require.Len(t, plan, 8)
+
+	assert.Equal(t, Recorded, plan[0].Action)
+	assert.Equal(t, int64(1), plan[0].Record.Payment.ID)
+	assert.Equal(t, "alice", plan[0].Employee.Name)
+
+	assert.Equal(t, Settle, plan[1].Action)
+	assert.Same(t, interrupted, plan[1].Record)
+	assert.Equal(t, time.Unix(1_793_000_110, 0).UTC(), plan[1].PaidAt)
+
+	assert.Equal(t, Create, plan[2].Action)
+	assert.Nil(t, plan[2].Record)
+	assert.Equal(t, int64(1), plan[2].Employee.ID)
+
+	assert.Equal(t, Unknown, plan[3].Action)
+	assert.Nil(t, plan[3].Employee)
+
+	// a transfer matching several payments, or one created after it, is
+	// left to the operator
+	assert.Equal(t, Ambiguous, plan[4].Action)
+	assert.Nil(t, plan[4].Record)
+	assert.Equal(t, []*repository.PaymentRecord{records[3], records[4]}, plan[4].Candidates)
+	assert.Equal(t, "bob", plan[4].Employee.Name)
+	assert.Equal(t, Ambiguous, plan[5].Action)
+	assert.Equal(t, []*repository.PaymentRecord{records[5]}, plan[5].Candidates)
+
+	// exported and proposed payments are settled by their own transactions
+	assert.Equal(t, Settle, plan[6].Action)
+	assert.Same(t, exported, plan[6].Record)
+	assert.Equal(t, Settle, plan[7].Action)
+	assert.Same(t, proposed, plan[7].Record)
+
+	payments := Payments(plan)
+	require.Len(t, payments, 4)
+	assert.Same(t, interrupted.Payment, payments[0].Payment)
+	assert.Equal(t, int64(2), payments[0].EmployeeID)
+	assert.Nil(t, payments[1].Payment)
+	assert.Equal(t, int64(1), payments[1].EmployeeID)
+	assert.Equal(t, int64(30), payments[1].Amount)
+	assert.Equal(t, "recovered from block 120", payments[1].Memo)
+	assert.Equal(t, entity.Transfer{TxHash: common.HexToHash("0x03").Hex(), From: treasury.Hex(), Token: usdc.Hex()}, payments[1].Transfer)
+	assert.Same(t, exported.Payment, payments[2].Payment)
+	assert.Same(t, proposed.Payment, payments[3].Payment)
+}
+
+func TestReconciler_Attempts(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	safeAddr := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	// the sender sent nonce 4 in block 120 and nonces 5 and 6 in block 150,
+	// after another account's nonce 5
+	send := func(key *ecdsa.PrivateKey, nonce uint64) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &alice, Gas: 21_000, GasPrice: big.NewInt(1)})
+		require.NoError(t, err)
+		return tx
+	}
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	reverted, paying, other := send(key, 4), send(key, 5), send(key, 6)
+	txs := map[uint64][]*types.Transaction{120: {reverted}, 150: {send(otherKey, 5), paying, other}}
+
+	client := &ethereum.MockClient{
+		NonceAtFn: func(ctx context.Context, account common.Address, block *big.Int) (uint64, error) {
+			if account == safeAddr {
+				return 1, nil
+			}
+			switch {
+			case block.Uint64() < 120:
+				return 4, nil
+			case block.Uint64() < 150:
+				return 5, nil
+			}
+			return 7, nil
+		},
+		BlockByNumberFn: func(ctx context.Context, number *big.Int) (*types.Block, error) {
+			return types.NewBlockWithHeader(&types.Header{Number: number}).WithBody(txs[number.Uint64()], nil), nil
+		},
+		TransactionReceiptFn: func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			status := types.ReceiptStatusSuccessful
+			if txHash == reverted.Hash() {
+				status = types.ReceiptStatusFailed
+			}
+			return &types.Receipt{TxHash: txHash, Status: status}, nil
+		},
+	}
+	r := &Reconciler{Client: client, Treasuries: []common.Address{sender, safeAddr}}
+
+	transfers := []*Transfer{
+		{TxHash: paying.Hash(), Block: 150, From: sender, To: alice, Amount: big.NewInt(10)},
+		{TxHash: paying.Hash(), Block: 150, From: sender, To: bob, Amount: big.NewInt(20)},
+	}
+	attempts, err := r.Attempts(context.Background(), transfers, 100, 200)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+
+	// one transaction paid both, one reverted and one transferred nothing
+	a := attempts[0]
+	assert.Equal(t, uint64(3), a.Sent)
+	assert.Equal(t, uint64(1), a.Transfers)
+	assert.Equal(t, uint64(2), a.Unaccounted())
+	assert.Equal(t, "3 transactions sent, 1 transferring tokens", a.String())
+	require.Len(t, a.Transactions, 3)
+	assert.Equal(t, &Attempt{Treasury: sender, Nonce: 4, TxHash: reverted.Hash(), Block: 120, Reverted: true}, a.Transactions[0])
+	assert.Equal(t, "transaction 4 reverted", a.Transactions[0].String())
+	assert.Equal(t, &Attempt{Treasury: sender, Nonce: 5, TxHash: paying.Hash(), Block: 150, Transfers: 2}, a.Transactions[1])
+	assert.Equal(t, "transaction 5 made 2 transfers", a.Transactions[1].String())
+	assert.Equal(t, &Attempt{Treasury: sender, Nonce: 6, TxHash: other.Hash(), Block: 150}, a.Transactions[2])
+	assert.Equal(t, "transaction 6 transferred no tokens", a.Transactions[2].String())
+
+	assert.Equal(t, uint64(0), attempts[1].Unaccounted())
+	assert.Empty(t, attempts[1].Transactions)
+}
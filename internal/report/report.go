@@ -0,0 +1,163 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format is how a report is written
+type Format string
+
+const (
+	// TableFormat aligns the columns for reading in a terminal
+	TableFormat Format = "table"
+	// CSVFormat writes a header row followed by the rows
+	CSVFormat Format = "csv"
+	// JSONFormat writes an array of objects keyed by column
+	JSONFormat Format = "json"
+)
+
+// ErrUnknownFormat is returned for formats other than table, csv and json
+var ErrUnknownFormat = errors.New("unknown report format")
+
+// ParseFormat returns the format named s
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case TableFormat, CSVFormat, JSONFormat:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w %q, expected table, csv or json", ErrUnknownFormat, s)
+}
+
+// Report is rows of values under named columns. Columns are snake_case,
+// and values strings, integers, booleans or times; nil times are empty.
+type Report struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// New creates an empty report with the columns
+func New(columns ...string) *Report {
+	return &Report{Columns: columns}
+}
+
+// Add appends a row, one value per column
+func (r *Report) Add(values ...interface{}) {
+	r.Rows = append(r.Rows, values)
+}
+
+// Write writes the report to w in format
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case TableFormat:
+		return r.writeTable(w)
+	case CSVFormat:
+		return r.writeCSV(w)
+	case JSONFormat:
+		return r.writeJSON(w)
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+func (r *Report) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(r.Columns, "\t")))
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = text(v)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (r *Report) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = text(v)
+		}
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes the rows as objects keeping the order of the columns,
+// which encoding/json does not for maps
+func (r *Report) writeJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range r.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+		for j, v := range row {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			key, err := json.Marshal(r.Columns[j])
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(jsonValue(v))
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteString(": ")
+			buf.Write(value)
+		}
+		buf.WriteString("}")
+	}
+	if len(r.Rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// text formats a value for table and CSV cells
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// jsonValue keeps numbers and booleans as they are, and nil times null
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return v.UTC().Format(time.RFC3339)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return v
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *Report {
+	paidAt := time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC)
+
+	r := New("id", "employee", "amount", "paid_at")
+	r.Add(int64(1), "alice", int64(1000), &paidAt)
+	r.Add(int64(2), "bob, jr", int64(500), (*time.Time)(nil))
+	return r
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("CSV")
+	require.NoError(t, err)
+	assert.Equal(t, CSVFormat, f)
+
+	_, err = ParseFormat("xml")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestReport_WriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport().Write(&buf, TableFormat))
+
+	assert.Equal(t, ""+
+		"ID  EMPLOYEE  AMOUNT  PAID_AT\n"+
+		"1   alice     1000    2026-10-31T12:00:00Z\n"+
+		"2   bob, jr   500     \n", buf.String())
+}
+
+func TestReport_WriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport().Write(&buf, CSVFormat))
+
+	assert.Equal(t, ""+
+		"id,employee,amount,paid_at\n"+
+		"1,alice,1000,2026-10-31T12:00:00Z\n"+
+		"2,\"bob, jr\",500,\n", buf.String())
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport().Write(&buf, JSONFormat))
+
+	assert.Equal(t, `[
+  {"id": 1, "employee": "alice", "amount": 1000, "paid_at": "2026-10-31T12:00:00Z"},
+  {"id": 2, "employee": "bob, jr", "amount": 500, "paid_at": null}
+]
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, New("id").Write(&buf, JSONFormat))
+	assert.Equal(t, "[]\n", buf.String())
+}
@@ -2,6 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"gitlab.midas.dev/back/river/internal/entity"
 )
@@ -9,21 +13,353 @@ import (
 type PaymentStatus string
 
 const (
+	// DraftStatus salaries wait for approval before they can be executed
+	DraftStatus PaymentStatus = "draft"
+	// ApprovedStatus salaries were approved by enough approvers
+	ApprovedStatus PaymentStatus = "approved"
+
 	CreatedStatus    PaymentStatus = "created"
 	ProcessingStatus PaymentStatus = "processing"
 	DoneStatus       PaymentStatus = "done"
+	// NeedsReviewStatus payments exceeded a spending limit and wait for a reviewer
+	NeedsReviewStatus PaymentStatus = "needs_review"
+	// RejectedStatus payments were rejected by a reviewer and are never sent
+	RejectedStatus PaymentStatus = "rejected"
+	// ProposedStatus payments wait for the owners of the Safe to execute them
+	ProposedStatus PaymentStatus = "proposed"
+	// ExportedStatus payments were exported unsigned and wait to be signed offline and broadcast
+	ExportedStatus PaymentStatus = "exported"
+)
+
+type PaymentCategory string
+
+const (
+	SalaryCategory        PaymentCategory = "salary"
+	BonusCategory         PaymentCategory = "bonus"
+	ReimbursementCategory PaymentCategory = "reimbursement"
+	AdjustmentCategory    PaymentCategory = "adjustment"
+	// WithholdingCategory payments route withheld deductions to a treasury
+	WithholdingCategory PaymentCategory = "withholding"
+)
+
+type DeductionKind string
+
+const (
+	FixedDeduction   DeductionKind = "fixed"
+	PercentDeduction DeductionKind = "percent"
+)
+
+type AddressChangeStatus string
+
+const (
+	PendingAddressChange   AddressChangeStatus = "pending"
+	AppliedAddressChange   AddressChangeStatus = "applied"
+	CancelledAddressChange AddressChangeStatus = "cancelled"
+)
+
+type SafeProposalStatus string
+
+const (
+	PendingSafeProposal  SafeProposalStatus = "pending"
+	ExecutedSafeProposal SafeProposalStatus = "executed"
+	// FailedSafeProposal proposals were executed but reverted
+	FailedSafeProposal SafeProposalStatus = "failed"
+	// ReplacedSafeProposal proposals lost their nonce to another transaction
+	ReplacedSafeProposal SafeProposalStatus = "replaced"
 )
 
+// AdHocSchedule is the schedule of payroll runs created for standalone payments
+const AdHocSchedule = "adhoc"
+
+type EmployeeStatus string
+
+const (
+	ActiveEmployeeStatus     EmployeeStatus = "active"
+	OnLeaveEmployeeStatus    EmployeeStatus = "on_leave"
+	TerminatedEmployeeStatus EmployeeStatus = "terminated"
+)
+
+// ErrPeriodAlreadyPaid is returned when a payroll run overlaps a period that
+// already has one and the run was not forced
+var ErrPeriodAlreadyPaid = errors.New("period already paid")
+
+var (
+	ErrEmployeeNotFound = errors.New("employee not found")
+	ErrSalaryNotFound   = errors.New("salary not found or already paid")
+	ErrPaymentNotFound  = errors.New("payment not found or not awaiting review")
+	// ErrAddressChangeNotFound is returned for unknown or no longer pending address changes
+	ErrAddressChangeNotFound = errors.New("address change not found or not pending")
+	// ErrSafeProposalNotFound is returned for unknown or already resolved Safe proposals
+	ErrSafeProposalNotFound = errors.New("safe proposal not found or not pending")
+	// ErrExportNotFound is returned for payments not exported, or exported again since
+	ErrExportNotFound = errors.New("payment not exported with this transaction")
+)
+
+// ErrMalformedRows is matched by RowError and ScanError
+var ErrMalformedRows = errors.New("malformed rows")
+
+// RowError is a row that could not be read
+type RowError struct {
+	Table string
+	// ID is the id of the row, 0 if it could not be read either
+	ID  int64
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("%s row %d: %v", e.Table, e.ID, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RowError) Is(target error) bool {
+	return target == ErrMalformedRows
+}
+
+// ScanError lists the rows of a query that could not be read. Queries return
+// no rows then, so that a malformed row is never silently left out of a
+// payroll run.
+type ScanError struct {
+	Rows []*RowError
+}
+
+func (e *ScanError) Error() string {
+	msgs := make([]string, 0, len(e.Rows))
+	for _, row := range e.Rows {
+		msgs = append(msgs, row.Error())
+	}
+	return fmt.Sprintf("%d malformed row(s), see `river db diagnose`: %s", len(e.Rows), strings.Join(msgs, "; "))
+}
+
+func (e *ScanError) Is(target error) bool {
+	return target == ErrMalformedRows
+}
+
+// CreateSalaryParams describes the payroll run to create
+type CreateSalaryParams struct {
+	Period   entity.Period
+	Schedule string
+	// Force allows paying a period that already has a payroll run
+	Force bool
+	// Draft creates the run in draft status, to be approved before it is paid
+	Draft bool
+	// Now is when the run is created; address changes due by then are applied
+	Now time.Time
+	// RequireVerifiedAddress only applies address changes signed by the new address
+	RequireVerifiedAddress bool
+	// HoldPendingAddress holds the salaries of employees with a pending address
+	// change for review instead of paying their old address
+	HoldPendingAddress bool
+}
+
+// PaymentItem is a one-off payment such as a bonus or a reimbursement
+type PaymentItem struct {
+	// SalaryID attaches the item to an unfinished payroll run; zero queues it
+	// for the next run
+	SalaryID   int64
+	EmployeeID int64
+	Amount     int64
+	Category   PaymentCategory
+	Memo       string
+}
+
+// SpendingHistory holds the amounts already sent that spending limits are
+// checked against
+type SpendingHistory struct {
+	// RunSent is the amount already sent by the payroll run
+	RunSent int64
+	// EmployeePeriod is the amount already sent to each employee for the
+	// run's period, withholdings excluded
+	EmployeePeriod map[int64]int64
+	// PreviousSalary is each employee's gross amount in their last paid
+	// salary before this run
+	PreviousSalary map[int64]int64
+}
+
+// PaymentExport is the unsigned transaction a payment was exported as
+type PaymentExport struct {
+	PaymentID   int64
+	Nonce       uint64
+	SigningHash string
+	// Token is the token contract the transaction sends
+	Token string
+	// From is the offline account sending the transaction
+	From string
+}
+
 type EmployeeRepository interface {
 	List(ctx context.Context) ([]*entity.Employee, error)
+	// Add inserts an employee and returns their id
+	Add(ctx context.Context, emp *entity.Employee) (int64, error)
+	// Rename changes the name of an employee. It returns ErrEmployeeNotFound
+	// if there is no such employee.
+	Rename(ctx context.Context, id int64, name string) error
 }
 
 type SalaryRepository interface {
-	Create(ctx context.Context) error
-	UpdateStatusToProcessing(ctx context.Context, id int64) error
+	Create(ctx context.Context, params CreateSalaryParams) error
+	// ClaimSalary moves a salary to status in a single compare-and-set on the
+	// status and version it was read with, and updates both. It returns
+	// ErrStatusConflict if the salary changed since, e.g. because another
+	// River process claimed it, or another process's claim on it has not
+	// expired yet, and ErrInvalidTransition for changes the status graph does
+	// not allow.
+	ClaimSalary(ctx context.Context, salary *entity.Salary, status PaymentStatus) error
+	// ClaimPayment moves a payment to status like ClaimSalary, refusing
+	// payments of a salary claimed by another process. A payment in
+	// processing is only claimed again once the transaction sending it was
+	// recorded.
+	ClaimPayment(ctx context.Context, payment *entity.Payment, status PaymentStatus) error
+	// ClaimPaymentSend claims a payment for processing like ClaimPayment,
+	// recording the transaction signed to send it in the same transaction
+	ClaimPaymentSend(ctx context.Context, payment *entity.Payment, signed *entity.SignedTransfer) error
+	// CompletePayment moves a payment claimed for processing to done like
+	// ClaimPayment, recording the transfer that sent it
+	CompletePayment(ctx context.Context, payment *entity.Payment, transfer *entity.Transfer) error
 	UpdateStatusToDone(ctx context.Context, id int64) error
-	UpdatePaymentStatusToProcessing(ctx context.Context, id int64) error
-	UpdatePaymentStatusToDone(ctx context.Context, id int64) error
 	ListByStatus(ctx context.Context, status PaymentStatus) ([]*entity.Salary, error)
 	ListPaymentsBySalaryID(ctx context.Context, salaryID int64) ([]*entity.Payment, error)
+	// AddPayment adds a one-off payment to a payroll run or queues it for the next one
+	AddPayment(ctx context.Context, item PaymentItem) (int64, error)
+	// CreateAdHoc creates a payroll run containing only the given items,
+	// in draft status if draft is set
+	CreateAdHoc(ctx context.Context, items []PaymentItem, draft bool) (int64, error)
+	GetSalary(ctx context.Context, id int64) (*entity.Salary, error)
+	UpdateStatusToDraft(ctx context.Context, id int64) error
+	UpdateStatusToApproved(ctx context.Context, id int64) error
+	// AddApproval records an approval; approving the same manifest twice is a no-op
+	AddApproval(ctx context.Context, approval *entity.Approval) error
+	ListApprovals(ctx context.Context, salaryID int64) ([]*entity.Approval, error)
+	// UpdatePaymentStatusToNeedsReview holds a payment back, recording why
+	UpdatePaymentStatusToNeedsReview(ctx context.Context, id int64, reason string) error
+	// ReviewPayment releases a payment held for review, or rejects it
+	ReviewPayment(ctx context.Context, id int64, approve bool) error
+	ListPaymentsByStatus(ctx context.Context, status PaymentStatus) ([]*entity.Payment, error)
+	SpendingHistory(ctx context.Context, salaryID int64) (*SpendingHistory, error)
+	// AddSafeProposal records a pending proposal and moves its payments to proposed
+	AddSafeProposal(ctx context.Context, proposal *entity.SafeProposal, paymentIDs []int64) (int64, error)
+	ListSafeProposals(ctx context.Context, status SafeProposalStatus) ([]*entity.SafeProposal, error)
+	// ResolveSafeProposal records the outcome of a pending proposal. Payments
+	// of executed proposals are done; the others are created again.
+	ResolveSafeProposal(ctx context.Context, id int64, status SafeProposalStatus, txHash string) error
+	// ExportPayments moves payments to exported, recording their unsigned transactions
+	ExportPayments(ctx context.Context, exports []PaymentExport) error
+	// ResolveExport records the outcome of the broadcast of an exported
+	// payment's transaction. Paid payments are done; the others are created again.
+	ResolveExport(ctx context.Context, id int64, signingHash, txHash string, paid bool) error
+}
+
+type DeductionRepository interface {
+	Add(ctx context.Context, deduction *entity.Deduction) (int64, error)
+	List(ctx context.Context) ([]*entity.Deduction, error)
+	Deactivate(ctx context.Context, id int64) error
+}
+
+// AddressChangeRepository records payout address changes. Changes are
+// applied to employees by SalaryRepository.Create once they are due.
+type AddressChangeRepository interface {
+	// Request records a pending change, cancelling earlier pending changes of the employee
+	Request(ctx context.Context, change *entity.AddressChange) (int64, error)
+	Get(ctx context.Context, id int64) (*entity.AddressChange, error)
+	// Verify stores the new address's signature of a pending change
+	Verify(ctx context.Context, id int64, signature string, at time.Time) error
+	Cancel(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]*entity.AddressChange, error)
+	// ApplyDue applies the changes that are due, releasing salaries held for them
+	ApplyDue(ctx context.Context, now time.Time, requireVerified bool) error
+}
+
+// AuditRepository appends to the audit log, which repositories also write to
+// in the transaction of every change they make
+type AuditRepository interface {
+	// Append records an entry done by the actor of ctx
+	Append(ctx context.Context, action, entityType string, entityID int64, details map[string]interface{}) error
+	// AppendChanged records an entry unless the last entry of the action has the same details
+	AppendChanged(ctx context.Context, action, entityType string, entityID int64, details map[string]interface{}) error
+	// List returns every entry ordered by ID
+	List(ctx context.Context) ([]*entity.AuditEntry, error)
+}
+
+// PaymentRecord is a payment along with its employee and the period of its
+// payroll run
+type PaymentRecord struct {
+	Payment  *entity.Payment
+	Employee string
+	Period   string
+}
+
+// PeriodTotal is what the done payments of a payroll run sent in a token
+type PeriodTotal struct {
+	SalaryID   int64
+	Period     string
+	Token      string
+	Payments   int64
+	Gross      int64
+	Deductions int64
+	Net        int64
+}
+
+// RunGas is the gas of the transactions River sent itself for a payroll run
+type RunGas struct {
+	SalaryID     int64
+	Period       string
+	Transactions int64
+	GasUsed      int64
+	// GasFee is in wei
+	GasFee int64
+}
+
+// ReportRepository answers questions about past payroll runs. Reports cover
+// the runs or payments dated from from, inclusive, to to, exclusive.
+type ReportRepository interface {
+	// EmployeeHistory returns the payments of an employee, dated by when they
+	// were paid or, if not paid, created
+	EmployeeHistory(ctx context.Context, employeeID int64, from, to time.Time) ([]*PaymentRecord, error)
+	// PeriodTotals returns the totals of the runs dated by the start of their
+	// period or, for ad hoc runs, their creation, by token
+	PeriodTotals(ctx context.Context, from, to time.Time) ([]*PeriodTotal, error)
+	// GasByRun returns the gas spent by the runs dated like PeriodTotals
+	GasByRun(ctx context.Context, from, to time.Time) ([]*RunGas, error)
+	// Paid returns the done payments, dated by when they were paid or, if
+	// paid before that was recorded, created
+	Paid(ctx context.Context, from, to time.Time) ([]*PaymentRecord, error)
+	// RunPayments returns the payments of the runs dated like PeriodTotals
+	RunPayments(ctx context.Context, from, to time.Time) ([]*PaymentRecord, error)
+	// Outstanding returns the payments that are neither done nor rejected:
+	// failed or interrupted, held for review, queued, proposed or exported
+	Outstanding(ctx context.Context) ([]*PaymentRecord, error)
+}
+
+// RecoveredPayment is a payment rebuilt from a transfer found on chain
+type RecoveredPayment struct {
+	// Payment is the unfinished payment the transfer paid, as read; nil to
+	// record a new payment
+	Payment    *entity.Payment
+	EmployeeID int64
+	Addr       string
+	Amount     int64
+	Memo       string
+	Transfer   entity.Transfer
+	PaidAt     time.Time
+}
+
+// RecoveryRepository records the payments found on chain after the database
+// lost them
+type RecoveryRepository interface {
+	// Recover marks the unfinished payments paid by the transfers done, and
+	// records the others as done payments of an ad hoc payroll run of their
+	// own, in one transaction. It returns the ID of that run, 0 if every
+	// payment was unfinished.
+	Recover(ctx context.Context, payments []*RecoveredPayment) (int64, error)
+}
+
+// LockRepository provides named, expiring locks shared by every River
+// instance using the same database
+type LockRepository interface {
+	// Acquire takes or renews the lock for owner until ttl elapses. It reports
+	// false if the lock is held by another owner and has not expired.
+	Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, owner string) error
 }
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidTransition is returned for status changes the status graph does not allow
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrStatusConflict is returned when a salary or payment changed since it
+	// was read, typically because another River process claimed it first
+	ErrStatusConflict = errors.New("status changed concurrently")
+)
+
+// salaryTransitions are the statuses a payroll run may move to from each
+// status. Runs in processing are claimed again by repay, by the process that
+// claimed them or once its claim expired.
+var salaryTransitions = map[PaymentStatus][]PaymentStatus{
+	DraftStatus:      {ApprovedStatus},
+	ApprovedStatus:   {DraftStatus, ProcessingStatus},
+	CreatedStatus:    {ProcessingStatus},
+	ProcessingStatus: {ProcessingStatus, DoneStatus},
+}
+
+// paymentTransitions are the statuses a payment may move to from each
+// status. Payments in processing were interrupted while sent and are claimed
+// again by repay, by the process that claimed them or once its claim
+// expired, to send the transaction recorded with their claim again. Those
+// without one are held for review instead.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	CreatedStatus:     {ProcessingStatus, NeedsReviewStatus, ProposedStatus, ExportedStatus},
+	ProcessingStatus:  {ProcessingStatus, DoneStatus, NeedsReviewStatus, ProposedStatus, ExportedStatus},
+	NeedsReviewStatus: {CreatedStatus, RejectedStatus},
+	ProposedStatus:    {CreatedStatus, DoneStatus},
+	ExportedStatus:    {CreatedStatus, DoneStatus},
+}
+
+// CheckSalaryTransition returns ErrInvalidTransition unless a payroll run may
+// move from one status to the other
+func CheckSalaryTransition(from, to PaymentStatus) error {
+	return checkTransition(salaryTransitions, "salary", from, to)
+}
+
+// CheckPaymentTransition returns ErrInvalidTransition unless a payment may
+// move from one status to the other
+func CheckPaymentTransition(from, to PaymentStatus) error {
+	return checkTransition(paymentTransitions, "payment", from, to)
+}
+
+func checkTransition(graph map[PaymentStatus][]PaymentStatus, kind string, from, to PaymentStatus) error {
+	for _, next := range graph[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s from %s to %s", ErrInvalidTransition, kind, from, to)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSalaryTransition(t *testing.T) {
+	assert.NoError(t, CheckSalaryTransition(CreatedStatus, ProcessingStatus))
+	assert.NoError(t, CheckSalaryTransition(ApprovedStatus, DraftStatus))
+	assert.NoError(t, CheckSalaryTransition(ProcessingStatus, ProcessingStatus))
+
+	// drafts are paid once approved, and done runs are never paid again
+	assert.ErrorIs(t, CheckSalaryTransition(DraftStatus, ProcessingStatus), ErrInvalidTransition)
+	assert.ErrorIs(t, CheckSalaryTransition(DoneStatus, ProcessingStatus), ErrInvalidTransition)
+	assert.EqualError(t, CheckSalaryTransition(CreatedStatus, DoneStatus),
+		"invalid status transition: salary from created to done")
+}
+
+func TestCheckPaymentTransition(t *testing.T) {
+	assert.NoError(t, CheckPaymentTransition(CreatedStatus, ProcessingStatus))
+	assert.NoError(t, CheckPaymentTransition(ProcessingStatus, DoneStatus))
+	assert.NoError(t, CheckPaymentTransition(ExportedStatus, CreatedStatus))
+
+	assert.ErrorIs(t, CheckPaymentTransition(CreatedStatus, DoneStatus), ErrInvalidTransition)
+	assert.ErrorIs(t, CheckPaymentTransition(NeedsReviewStatus, ProcessingStatus), ErrInvalidTransition)
+	assert.ErrorIs(t, CheckPaymentTransition(DoneStatus, ProcessingStatus), ErrInvalidTransition)
+	assert.ErrorIs(t, CheckPaymentTransition(RejectedStatus, CreatedStatus), ErrInvalidTransition)
+}
@@ -0,0 +1,153 @@
+package safe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrNotFound is returned by the transaction service for unknown transactions
+var ErrNotFound = errors.New("Safe transaction not found")
+
+// Proposal is a signed Safe transaction, in the format of the Safe
+// transaction service. It is also the format exported to files.
+type Proposal struct {
+	Safe                    string `json:"safe"`
+	To                      string `json:"to"`
+	Value                   string `json:"value"`
+	Data                    string `json:"data"`
+	Operation               uint8  `json:"operation"`
+	SafeTxGas               string `json:"safeTxGas"`
+	BaseGas                 string `json:"baseGas"`
+	GasPrice                string `json:"gasPrice"`
+	GasToken                string `json:"gasToken"`
+	RefundReceiver          string `json:"refundReceiver"`
+	Nonce                   uint64 `json:"nonce"`
+	ContractTransactionHash string `json:"contractTransactionHash"`
+	Sender                  string `json:"sender"`
+	Signature               string `json:"signature"`
+	Origin                  string `json:"origin,omitempty"`
+}
+
+// NewProposal returns the proposal of a transaction signed by sender
+func NewProposal(safe common.Address, tx *Transaction, hash common.Hash, sender common.Address, sig []byte) *Proposal {
+	return &Proposal{
+		Safe:                    safe.Hex(),
+		To:                      tx.To.Hex(),
+		Value:                   decimal(tx.Value),
+		Data:                    hexutil.Encode(tx.Data),
+		Operation:               uint8(tx.Operation),
+		SafeTxGas:               decimal(tx.SafeTxGas),
+		BaseGas:                 decimal(tx.BaseGas),
+		GasPrice:                decimal(tx.GasPrice),
+		GasToken:                tx.GasToken.Hex(),
+		RefundReceiver:          tx.RefundReceiver.Hex(),
+		Nonce:                   tx.Nonce,
+		ContractTransactionHash: hash.Hex(),
+		Sender:                  sender.Hex(),
+		Signature:               hexutil.Encode(sig),
+		Origin:                  "River",
+	}
+}
+
+// Status is the execution status of a transaction in the transaction service
+type Status struct {
+	Safe                  string  `json:"safe"`
+	Nonce                 uint64  `json:"nonce"`
+	IsExecuted            bool    `json:"isExecuted"`
+	IsSuccessful          *bool   `json:"isSuccessful"`
+	TransactionHash       *string `json:"transactionHash"`
+	ConfirmationsRequired int     `json:"confirmationsRequired"`
+	Confirmations         []struct {
+		Owner string `json:"owner"`
+	} `json:"confirmations"`
+}
+
+// API is a client of the Safe transaction service
+type API struct {
+	url    string
+	client *http.Client
+}
+
+// NewAPI creates a client of the transaction service at url, for example
+// https://safe-transaction-mainnet.safe.global
+func NewAPI(url string, timeout time.Duration) *API {
+	return &API{
+		url:    strings.TrimRight(url, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Propose submits a signed transaction for the other owners to confirm
+func (a *API) Propose(ctx context.Context, proposal *Proposal) error {
+	body, err := json.Marshal(proposal)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/api/v1/safes/%s/multisig-transactions/", proposal.Safe)
+	return a.do(ctx, http.MethodPost, path, body, nil)
+}
+
+// Status returns the status of the transaction with the given Safe hash
+func (a *API) Status(ctx context.Context, hash common.Hash) (*Status, error) {
+	var status Status
+	path := fmt.Sprintf("/api/v1/multisig-transactions/%s/", hash.Hex())
+	if err := a.do(ctx, http.MethodGet, path, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (a *API) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, a.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("Safe transaction service: %s %s: %s: %s",
+			method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// decimal formats a uint256, nil being zero
+func decimal(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+	return n.String()
+}
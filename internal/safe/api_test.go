@@ -0,0 +1,109 @@
+package safe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serviceStandIn is a minimal Safe transaction service keeping proposals in memory
+type serviceStandIn struct {
+	proposals map[string]*Proposal
+	executed  map[string]string
+}
+
+func (s *serviceStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/v1/safes/"):
+		var p Proposal
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path != "/api/v1/safes/"+p.Safe+"/multisig-transactions/" {
+			http.NotFound(w, r)
+			return
+		}
+		if len(p.Signature) != 2+65*2 {
+			http.Error(w, `{"signature": "invalid"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		s.proposals[p.ContractTransactionHash] = &p
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/multisig-transactions/"):
+		hash := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/multisig-transactions/"), "/")
+		p, ok := s.proposals[hash]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		status := map[string]interface{}{
+			"safe":                  p.Safe,
+			"nonce":                 p.Nonce,
+			"isExecuted":            false,
+			"isSuccessful":          nil,
+			"transactionHash":       nil,
+			"confirmationsRequired": 2,
+			"confirmations":         []map[string]string{{"owner": p.Sender}},
+		}
+		if tx, ok := s.executed[hash]; ok {
+			status["isExecuted"] = true
+			status["isSuccessful"] = true
+			status["transactionHash"] = tx
+		}
+		_ = json.NewEncoder(w).Encode(status)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	standIn := &serviceStandIn{proposals: map[string]*Proposal{}, executed: map[string]string{}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	api := NewAPI(server.URL+"/", time.Second)
+	ctx := context.Background()
+
+	safe := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tx := MultiSend(common.HexToAddress(DefaultMultiSendAddress), nil, 4)
+	hash := common.HexToHash("0x01")
+	proposal := NewProposal(safe, tx, hash, common.HexToAddress("0x00000000000000000000000000000000000000bb"), make([]byte, 65))
+
+	require.NoError(t, api.Propose(ctx, proposal))
+	assert.Equal(t, "0", standIn.proposals[hash.Hex()].SafeTxGas)
+	assert.Equal(t, uint8(OperationDelegateCall), standIn.proposals[hash.Hex()].Operation)
+
+	status, err := api.Status(ctx, hash)
+	require.NoError(t, err)
+	assert.False(t, status.IsExecuted)
+	assert.Equal(t, uint64(4), status.Nonce)
+	assert.Len(t, status.Confirmations, 1)
+
+	standIn.executed[hash.Hex()] = "0x02"
+	status, err = api.Status(ctx, hash)
+	require.NoError(t, err)
+	assert.True(t, status.IsExecuted)
+	require.NotNil(t, status.TransactionHash)
+	assert.Equal(t, "0x02", *status.TransactionHash)
+
+	_, err = api.Status(ctx, common.HexToHash("0x03"))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	proposal.Signature = "0x"
+	err = api.Propose(ctx, proposal)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "422")
+	assert.Contains(t, err.Error(), `"signature": "invalid"`)
+}
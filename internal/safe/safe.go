@@ -0,0 +1,202 @@
+package safe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// DefaultMultiSendAddress is the MultiSendCallOnly contract of Safe v1.3.0,
+// deployed at the same address on most chains
+const DefaultMultiSendAddress = "0x40A2aCCbd92BCA938b02010E17A5b8929b49130D"
+
+// Operation is how a Safe executes its transaction
+type Operation uint8
+
+const (
+	OperationCall         Operation = 0
+	OperationDelegateCall Operation = 1
+)
+
+var (
+	domainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	safeTxTypeHash = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation," +
+		"uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+
+	executionSuccessTopic = crypto.Keccak256Hash([]byte("ExecutionSuccess(bytes32,uint256)"))
+	executionFailureTopic = crypto.Keccak256Hash([]byte("ExecutionFailure(bytes32,uint256)"))
+
+	multiSendSelector = crypto.Keccak256([]byte("multiSend(bytes)"))[:4]
+	nonceSelector     = crypto.Keccak256([]byte("nonce()"))[:4]
+)
+
+// ErrInvalidSignature is returned for signatures that do not recover an owner
+var ErrInvalidSignature = errors.New("invalid Safe signature")
+
+// Call is a single call bundled into a MultiSend transaction
+type Call struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+// Transaction is a Safe transaction. Gas refund fields are left at zero, so
+// whoever executes it pays the gas.
+type Transaction struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      Operation
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          uint64
+}
+
+// MultiSend returns a Safe transaction executing the calls in order through
+// the MultiSend contract, all of them reverting if one fails
+func MultiSend(multiSend common.Address, calls []Call, nonce uint64) *Transaction {
+	var packed []byte
+	for _, call := range calls {
+		value := call.Value
+		if value == nil {
+			value = new(big.Int)
+		}
+
+		packed = append(packed, byte(OperationCall))
+		packed = append(packed, call.To.Bytes()...)
+		packed = append(packed, common.LeftPadBytes(value.Bytes(), 32)...)
+		packed = append(packed, common.LeftPadBytes(big.NewInt(int64(len(call.Data))).Bytes(), 32)...)
+		packed = append(packed, call.Data...)
+	}
+
+	data := append([]byte{}, multiSendSelector...)
+	data = append(data, common.LeftPadBytes(big.NewInt(32).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(packed))).Bytes(), 32)...)
+	data = append(data, common.RightPadBytes(packed, (len(packed)+31)/32*32)...)
+
+	return &Transaction{
+		To:        multiSend,
+		Value:     new(big.Int),
+		Data:      data,
+		Operation: OperationDelegateCall,
+		Nonce:     nonce,
+	}
+}
+
+// Hash returns the EIP-712 hash of the transaction that the owners of the
+// Safe at the given address sign
+func (tx *Transaction) Hash(chainID *big.Int, safe common.Address) common.Hash {
+	domain := crypto.Keccak256(
+		domainTypeHash.Bytes(),
+		word(chainID),
+		common.LeftPadBytes(safe.Bytes(), 32),
+	)
+
+	message := crypto.Keccak256(
+		safeTxTypeHash.Bytes(),
+		common.LeftPadBytes(tx.To.Bytes(), 32),
+		word(tx.Value),
+		crypto.Keccak256(tx.Data),
+		word(big.NewInt(int64(tx.Operation))),
+		word(tx.SafeTxGas),
+		word(tx.BaseGas),
+		word(tx.GasPrice),
+		common.LeftPadBytes(tx.GasToken.Bytes(), 32),
+		common.LeftPadBytes(tx.RefundReceiver.Bytes(), 32),
+		word(new(big.Int).SetUint64(tx.Nonce)),
+	)
+
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain, message)
+}
+
+// Sign signs the Safe transaction hash as an owner. The signature is an
+// eth_sign one, with V of 31 or 32, so that any message signer can produce it.
+func Sign(ctx context.Context, owner signer.MessageSigner, hash common.Hash) ([]byte, error) {
+	sig, err := owner.SignText(ctx, hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidSignature, len(sig))
+	}
+
+	sig[crypto.RecoveryIDOffset] += 4
+	return sig, nil
+}
+
+// Owner returns the owner that signed the Safe transaction hash, accepting
+// plain ECDSA signatures (V of 27 or 28) and eth_sign ones (V of 31 or 32)
+func Owner(hash common.Hash, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("%w: length %d", ErrInvalidSignature, len(sig))
+	}
+
+	digest := hash.Bytes()
+	sig = append([]byte{}, sig...)
+	switch v := sig[crypto.RecoveryIDOffset]; v {
+	case 27, 28:
+		sig[crypto.RecoveryIDOffset] = v - 27
+	case 31, 32:
+		sig[crypto.RecoveryIDOffset] = v - 31
+		digest = accounts.TextHash(digest)
+	default:
+		return common.Address{}, fmt.Errorf("%w: unsupported V %d", ErrInvalidSignature, v)
+	}
+
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Execution looks for the execution of the Safe transaction hash in the logs
+// of a transaction receipt. It reports whether the Safe executed it and, if
+// so, whether the execution succeeded.
+func Execution(logs []*types.Log, safe common.Address, hash common.Hash) (executed, success bool) {
+	for _, l := range logs {
+		if l.Address != safe || len(l.Topics) == 0 || len(l.Data) < 32 {
+			continue
+		}
+		if common.BytesToHash(l.Data[:32]) != hash {
+			continue
+		}
+
+		switch l.Topics[0] {
+		case executionSuccessTopic:
+			return true, true
+		case executionFailureTopic:
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// ExecutionTopics returns the topics of the events a Safe emits when it
+// executes a transaction, successfully or not
+func ExecutionTopics() []common.Hash {
+	return []common.Hash{executionSuccessTopic, executionFailureTopic}
+}
+
+// NonceCall returns the calldata reading the nonce of a Safe
+func NonceCall() []byte {
+	return append([]byte{}, nonceSelector...)
+}
+
+// word encodes a uint256, nil being zero
+func word(n *big.Int) []byte {
+	if n == nil {
+		return make([]byte, 32)
+	}
+	return common.LeftPadBytes(n.Bytes(), 32)
+}
@@ -0,0 +1,151 @@
+package safe
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+const testKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+func TestMultiSend(t *testing.T) {
+	token := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	multiSend := common.HexToAddress(DefaultMultiSendAddress)
+
+	tx := MultiSend(multiSend, []Call{
+		{To: token, Data: []byte{0xa9, 0x05, 0x9c, 0xbb}},
+		{To: token, Value: big.NewInt(1), Data: []byte{0x01}},
+	}, 7)
+
+	assert.Equal(t, multiSend, tx.To)
+	assert.Equal(t, OperationDelegateCall, tx.Operation)
+	assert.Equal(t, uint64(7), tx.Nonce)
+
+	assert.Equal(t, multiSendSelector, tx.Data[:4])
+	assert.Equal(t, big.NewInt(32), new(big.Int).SetBytes(tx.Data[4:36]))
+
+	// each call is operation (1) + to (20) + value (32) + length (32) + data
+	length := new(big.Int).SetBytes(tx.Data[36:68]).Int64()
+	assert.Equal(t, int64(85+4+85+1), length)
+	assert.Zero(t, len(tx.Data[68:])%32)
+
+	packed := tx.Data[68 : 68+length]
+	assert.Equal(t, byte(OperationCall), packed[0])
+	assert.Equal(t, token.Bytes(), packed[1:21])
+	assert.Equal(t, []byte{0xa9, 0x05, 0x9c, 0xbb}, packed[85:89])
+	assert.Equal(t, big.NewInt(1), new(big.Int).SetBytes(packed[89+21:89+53]))
+}
+
+func TestTransaction_Hash(t *testing.T) {
+	safe := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tx := MultiSend(common.HexToAddress(DefaultMultiSendAddress), []Call{
+		{To: common.HexToAddress("0x00000000000000000000000000000000000000bb"), Data: []byte{0x01, 0x02}},
+	}, 3)
+
+	// the hash must match a generic EIP-712 implementation
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"SafeTx": {
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain: apitypes.TypedDataDomain{
+			ChainId:           math.NewHexOrDecimal256(5),
+			VerifyingContract: safe.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"to":             tx.To.Hex(),
+			"value":          "0",
+			"data":           hexutil.Encode(tx.Data),
+			"operation":      "1",
+			"safeTxGas":      "0",
+			"baseGas":        "0",
+			"gasPrice":       "0",
+			"gasToken":       common.Address{}.Hex(),
+			"refundReceiver": common.Address{}.Hex(),
+			"nonce":          "3",
+		},
+	}
+
+	want, _, err := apitypes.TypedDataAndHash(typedData)
+	require.NoError(t, err)
+
+	assert.Equal(t, common.BytesToHash(want), tx.Hash(big.NewInt(5), safe))
+	assert.NotEqual(t, tx.Hash(big.NewInt(5), safe), tx.Hash(big.NewInt(1), safe))
+}
+
+func TestSignAndOwner(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+	owner := signer.NewKeySigner(key)
+	hash := crypto.Keccak256Hash([]byte("safe tx"))
+
+	sig, err := Sign(context.Background(), owner, hash)
+	require.NoError(t, err)
+	assert.Contains(t, []byte{31, 32}, sig[crypto.RecoveryIDOffset])
+
+	addr, err := Owner(hash, sig)
+	require.NoError(t, err)
+	assert.Equal(t, owner.Address(), addr)
+
+	// plain ECDSA signatures of the hash are accepted too
+	plain, err := crypto.Sign(hash.Bytes(), key)
+	require.NoError(t, err)
+	plain[crypto.RecoveryIDOffset] += 27
+
+	addr, err = Owner(hash, plain)
+	require.NoError(t, err)
+	assert.Equal(t, owner.Address(), addr)
+
+	sig[crypto.RecoveryIDOffset] = 1
+	_, err = Owner(hash, sig)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestExecution(t *testing.T) {
+	safe := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	hash := crypto.Keccak256Hash([]byte("safe tx"))
+	data := append(hash.Bytes(), make([]byte, 32)...)
+
+	executed, success := Execution([]*types.Log{
+		{Address: common.HexToAddress("0x00000000000000000000000000000000000000bb"), Topics: []common.Hash{executionSuccessTopic}, Data: data},
+		{Address: safe, Topics: []common.Hash{executionSuccessTopic}, Data: data},
+	}, safe, hash)
+	assert.True(t, executed)
+	assert.True(t, success)
+
+	executed, success = Execution([]*types.Log{
+		{Address: safe, Topics: []common.Hash{executionFailureTopic}, Data: data},
+	}, safe, hash)
+	assert.True(t, executed)
+	assert.False(t, success)
+
+	executed, _ = Execution([]*types.Log{
+		{Address: safe, Topics: []common.Hash{executionSuccessTopic}, Data: data},
+	}, safe, crypto.Keccak256Hash([]byte("another tx")))
+	assert.False(t, executed)
+}
@@ -0,0 +1,144 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/offline"
+)
+
+// OfflineOptions configures payments signed on an offline machine
+type OfflineOptions struct {
+	// From is the account of the offline key paying the salaries
+	From common.Address
+	// ExportDir receives the files of unsigned transactions
+	ExportDir string
+}
+
+// Offline exports payments as unsigned transactions of an offline account
+// and broadcasts them once signed
+type Offline struct {
+	client ethereum.Client
+	abi    abi.ABI
+	token  common.Address
+	opts   OfflineOptions
+
+	receiptInterval time.Duration
+	receiptRetries  int
+}
+
+// NewOffline creates an exporter of transactions sent from opts.From
+func NewOffline(client ethereum.Client, opts OfflineOptions) (*Offline, error) {
+	ab, err := abi.JSON(strings.NewReader(erc20abi))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Offline{
+		client:          client,
+		abi:             ab,
+		token:           common.HexToAddress(USDCContractAddress),
+		opts:            opts,
+		receiptInterval: 2 * time.Second,
+		receiptRetries:  10,
+	}, nil
+}
+
+// Address returns the offline account
+func (o *Offline) Address() common.Address {
+	return o.opts.From
+}
+
+// Export writes the unsigned transactions paying the salary's payments to a
+// file. Their nonces follow the account's pending nonce, or minNonce if
+// higher, so that they do not conflict with batches not broadcast yet.
+func (o *Offline) Export(ctx context.Context, salaryID int64, payments []*entity.Payment, minNonce uint64) (*offline.Batch, error) {
+	var total int64
+	for _, p := range payments {
+		if !common.IsHexAddress(p.Addr) {
+			return nil, fmt.Errorf("payment %d: invalid address %q", p.ID, p.Addr)
+		}
+		total += p.Amount
+	}
+
+	balance, err := tokenBalance(ctx, o.client, o.abi, o.token, o.opts.From)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(big.NewInt(total)) < 0 {
+		return nil, fmt.Errorf("%w: %s holds %s, the salary needs %d", ErrInsufficientFunds, o.opts.From.Hex(), balance, total)
+	}
+
+	nonce, err := o.client.PendingNonceAt(ctx, o.opts.From)
+	if err != nil {
+		return nil, err
+	}
+	if minNonce > nonce {
+		nonce = minNonce
+	}
+
+	gasPrice, err := o.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := o.client.NetworkID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &offline.Batch{
+		Version:  offline.Version,
+		SalaryID: salaryID,
+		ChainID:  chainID,
+		From:     o.opts.From,
+		Token:    o.token,
+	}
+	for i, p := range payments {
+		tx := batch.NewTransaction(p.ID, common.HexToAddress(p.Addr), p.Amount, nonce+uint64(i), gasLimit, gasPrice)
+		batch.Transactions = append(batch.Transactions, tx)
+	}
+
+	name := filepath.Join(o.opts.ExportDir, fmt.Sprintf("unsigned-%d-%d.json", salaryID, nonce))
+	if err := offline.Write(name, batch); err != nil {
+		return nil, err
+	}
+	log.Printf("exported %d unsigned transactions of salary %d to %s", len(payments), salaryID, name)
+
+	return batch, nil
+}
+
+// Broadcast sends a signed transaction and waits for it, returning whether
+// it succeeded. A transaction already mined is not sent again.
+func (o *Offline) Broadcast(ctx context.Context, tx *types.Transaction) (bool, error) {
+	receipt, err := o.client.TransactionReceipt(ctx, tx.Hash())
+	switch {
+	case err == nil && receipt != nil && receipt.BlockNumber != nil:
+		return receipt.Status == types.ReceiptStatusSuccessful, nil
+	case err != nil && !errors.Is(err, goethereum.NotFound):
+		return false, err
+	}
+
+	log.Printf("send transaction %s nonce %d", tx.Hash().String(), tx.Nonce())
+	if err := o.client.SendTransaction(ctx, tx); err != nil && !strings.Contains(err.Error(), "already known") {
+		return false, err
+	}
+
+	_, err = waitReceipt(ctx, o.client, tx.Hash(), o.receiptInterval, o.receiptRetries)
+	if errors.Is(err, ErrTransactionFailed) {
+		return false, nil
+	}
+	return err == nil, err
+}
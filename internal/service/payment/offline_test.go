@@ -0,0 +1,129 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/offline"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+func newTestOffline(t *testing.T, balance int64, client *ethereum.MockClient) (*Offline, signer.Signer) {
+	t.Helper()
+
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+	sgn := signer.NewKeySigner(key)
+
+	client.CallContractFn = func(ctx context.Context, call goethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		return common.LeftPadBytes(big.NewInt(balance).Bytes(), 32), nil
+	}
+
+	o, err := NewOffline(client, OfflineOptions{From: sgn.Address(), ExportDir: t.TempDir()})
+	require.NoError(t, err)
+	o.receiptInterval = 0
+
+	return o, sgn
+}
+
+func TestOffline_Export(t *testing.T) {
+	client := &ethereum.MockClient{
+		PendingNonceAtFn: func(ctx context.Context, account common.Address) (uint64, error) {
+			return 4, nil
+		},
+	}
+	o, sgn := newTestOffline(t, 1_000, client)
+
+	payments := []*entity.Payment{
+		{ID: 10, Addr: "0x00000000000000000000000000000000000000aa", Amount: 100},
+		{ID: 11, Addr: "0x00000000000000000000000000000000000000bb", Amount: 200},
+	}
+
+	// the nonce skips those of batches not broadcast yet
+	batch, err := o.Export(context.Background(), 3, payments, 6)
+	require.NoError(t, err)
+	require.Len(t, batch.Transactions, 2)
+	assert.Equal(t, uint64(6), batch.Transactions[0].Nonce)
+	assert.Equal(t, uint64(7), batch.Transactions[1].Nonce)
+	assert.Equal(t, sgn.Address(), batch.From)
+	assert.Equal(t, common.HexToAddress(USDCContractAddress), batch.Token)
+
+	written, err := offline.Read(filepath.Join(o.opts.ExportDir, "unsigned-3-6.json"))
+	require.NoError(t, err)
+	require.NoError(t, written.Validate())
+	assert.Equal(t, batch.Transactions[1].SigningHash, written.Transactions[1].SigningHash)
+
+	args, err := o.abi.Methods[MethodErc20Transfer].Inputs.Unpack(written.Transactions[1].Data[4:])
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(payments[1].Addr), args[0])
+	assert.Equal(t, big.NewInt(200), args[1])
+
+	_, err = o.Export(context.Background(), 3, append(payments, &entity.Payment{ID: 12, Addr: payments[0].Addr, Amount: 701}), 0)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestOffline_Broadcast(t *testing.T) {
+	var sent []common.Hash
+	mined := make(map[common.Hash]uint64)
+	client := &ethereum.MockClient{
+		SendTransactionFn: func(ctx context.Context, tx *types.Transaction) error {
+			sent = append(sent, tx.Hash())
+			if _, ok := mined[tx.Hash()]; !ok {
+				mined[tx.Hash()] = types.ReceiptStatusSuccessful
+			}
+			return nil
+		},
+		TransactionReceiptFn: func(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+			status, ok := mined[hash]
+			if !ok {
+				return nil, goethereum.NotFound
+			}
+			return &types.Receipt{Status: status, BlockNumber: big.NewInt(1)}, nil
+		},
+	}
+	o, sgn := newTestOffline(t, 1_000, client)
+
+	batch, err := o.Export(context.Background(), 3, []*entity.Payment{
+		{ID: 10, Addr: "0x00000000000000000000000000000000000000aa", Amount: 100},
+	}, 0)
+	require.NoError(t, err)
+	require.NoError(t, offline.Sign(context.Background(), batch, sgn))
+
+	tx, err := batch.SignedTx(batch.Transactions[0])
+	require.NoError(t, err)
+
+	paid, err := o.Broadcast(context.Background(), tx)
+	require.NoError(t, err)
+	assert.True(t, paid)
+	assert.Equal(t, []common.Hash{tx.Hash()}, sent)
+
+	// a mined transaction is not sent again
+	paid, err = o.Broadcast(context.Background(), tx)
+	require.NoError(t, err)
+	assert.True(t, paid)
+	assert.Len(t, sent, 1)
+
+	// a reverted transaction did not pay
+	mined[tx.Hash()] = types.ReceiptStatusFailed
+	paid, err = o.Broadcast(context.Background(), tx)
+	require.NoError(t, err)
+	assert.False(t, paid)
+
+	client.SendTransactionFn = func(ctx context.Context, tx *types.Transaction) error {
+		return errors.New("nonce too low")
+	}
+	delete(mined, tx.Hash())
+	_, err = o.Broadcast(context.Background(), tx)
+	assert.EqualError(t, err, "nonce too low")
+}
@@ -0,0 +1,319 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/safe"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// ErrNoSafeOwner is returned when proposing without the key of a Safe owner
+var ErrNoSafeOwner = errors.New("no Safe owner key configured")
+
+// executionBatchSize is how many blocks are searched per log query for the
+// execution of a proposal
+const executionBatchSize = 5000
+
+// SafeOptions configures payments from a Safe
+type SafeOptions struct {
+	// Safe holds the funds and executes the payments
+	Safe common.Address
+	// MultiSend is the MultiSendCallOnly contract bundling the transfers
+	MultiSend common.Address
+	// API posts proposals to the Safe transaction service and tracks them;
+	// without it proposals are exported to ExportDir
+	API       *safe.API
+	ExportDir string
+}
+
+// Safe proposes payments as a MultiSend transaction of a Safe, signed by one
+// of its owners, for the other owners to confirm and execute
+type Safe struct {
+	client ethereum.Client
+	abi    abi.ABI
+	token  common.Address
+	owner  signer.MessageSigner
+	opts   SafeOptions
+}
+
+// NewSafe creates a Safe proposer signing with owner. A nil owner only tracks
+// proposals already made.
+func NewSafe(client ethereum.Client, owner signer.MessageSigner, opts SafeOptions) (*Safe, error) {
+	ab, err := abi.JSON(strings.NewReader(erc20abi))
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MultiSend == (common.Address{}) {
+		opts.MultiSend = common.HexToAddress(safe.DefaultMultiSendAddress)
+	}
+
+	return &Safe{
+		client: client,
+		abi:    ab,
+		token:  common.HexToAddress(USDCContractAddress),
+		owner:  owner,
+		opts:   opts,
+	}, nil
+}
+
+// Address returns the address of the Safe
+func (s *Safe) Address() common.Address {
+	return s.opts.Safe
+}
+
+// Propose signs a transaction of the Safe transferring every payment, to be
+// recorded and then published with Publish. Its nonce is the Safe's current
+// one, or minNonce if higher, so that it does not conflict with proposals
+// still pending.
+func (s *Safe) Propose(ctx context.Context, payments []*entity.Payment, minNonce uint64) (*entity.SafeProposal, error) {
+	if s.owner == nil {
+		return nil, ErrNoSafeOwner
+	}
+
+	calls := make([]safe.Call, 0, len(payments))
+	for _, p := range payments {
+		if !common.IsHexAddress(p.Addr) {
+			return nil, fmt.Errorf("payment %d: invalid address %q", p.ID, p.Addr)
+		}
+
+		data, err := s.abi.Pack(MethodErc20Transfer, common.HexToAddress(p.Addr), big.NewInt(p.Amount))
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, safe.Call{To: s.token, Data: data})
+	}
+
+	nonce, err := s.nonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if minNonce > nonce {
+		nonce = minNonce
+	}
+
+	chainID, err := s.client.NetworkID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := safe.MultiSend(s.opts.MultiSend, calls, nonce)
+	hash := tx.Hash(chainID, s.opts.Safe)
+
+	head, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := safe.Sign(ctx, s.owner, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.MarshalIndent(safe.NewProposal(s.opts.Safe, tx, hash, s.owner.Address(), sig), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	proposal := &entity.SafeProposal{
+		Safe:       s.opts.Safe.Hex(),
+		SafeTxHash: hash.Hex(),
+		Nonce:      nonce,
+		Token:      s.token.Hex(),
+		Payload:    string(payload),
+	}
+	if head.Number != nil {
+		proposal.Block = head.Number.Uint64()
+	}
+	return proposal, nil
+}
+
+// Publish posts a signed proposal to the transaction service, or writes it
+// to a file for the owners to import. Publishing it again is harmless.
+func (s *Safe) Publish(ctx context.Context, proposal *entity.SafeProposal) error {
+	if proposal.Payload == "" {
+		return fmt.Errorf("Safe transaction %s was not kept to be published", proposal.SafeTxHash)
+	}
+
+	var signed safe.Proposal
+	if err := json.Unmarshal([]byte(proposal.Payload), &signed); err != nil {
+		return fmt.Errorf("Safe transaction %s: %w", proposal.SafeTxHash, err)
+	}
+	return s.publish(ctx, &signed)
+}
+
+// Status returns whether a pending proposal was executed, failed or was
+// replaced, along with the transaction that executed it. Without the
+// transaction service a proposal stays pending until it is confirmed. A
+// proposal the service does not know, because publishing it failed, is
+// published again.
+func (s *Safe) Status(ctx context.Context, proposal *entity.SafeProposal) (repository.SafeProposalStatus, string, error) {
+	if s.opts.API == nil {
+		return repository.PendingSafeProposal, "", s.exportMissing(ctx, proposal)
+	}
+
+	status, err := s.opts.API.Status(ctx, common.HexToHash(proposal.SafeTxHash))
+	if err != nil && !errors.Is(err, safe.ErrNotFound) {
+		return "", "", err
+	}
+
+	if status != nil && status.IsExecuted && status.TransactionHash != nil {
+		txHash := *status.TransactionHash
+		result, err := s.Confirm(ctx, proposal, txHash)
+		return result, txHash, err
+	}
+
+	nonce, err := s.nonce(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if nonce <= proposal.Nonce {
+		if status == nil && proposal.Payload != "" {
+			return repository.PendingSafeProposal, "", s.Publish(ctx, proposal)
+		}
+		return repository.PendingSafeProposal, "", nil
+	}
+
+	// the Safe moved past the nonce, but the service may lag behind the
+	// chain: the proposal is replaced only if the chain has no execution of it
+	txHash, err := s.execution(ctx, proposal)
+	if err != nil {
+		return "", "", err
+	}
+	if txHash != "" {
+		result, err := s.Confirm(ctx, proposal, txHash)
+		return result, txHash, err
+	}
+	return repository.ReplacedSafeProposal, "", nil
+}
+
+// execution looks for the transaction that executed a proposal in the
+// blocks since it was made, returning nothing if none did
+func (s *Safe) execution(ctx context.Context, proposal *entity.SafeProposal) (string, error) {
+	if proposal.Block == 0 {
+		return "", fmt.Errorf("Safe %s moved past the nonce of proposal %d, made before River recorded its block: "+
+			"record the transaction that executed it with safe confirm", proposal.Safe, proposal.ID)
+	}
+
+	head, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	if head.Number == nil {
+		return "", errors.New("the node returned no latest block")
+	}
+
+	safeAddr := common.HexToAddress(proposal.Safe)
+	hash := common.HexToHash(proposal.SafeTxHash)
+	for from := proposal.Block; from <= head.Number.Uint64(); from += executionBatchSize {
+		to := from + executionBatchSize - 1
+		if to > head.Number.Uint64() {
+			to = head.Number.Uint64()
+		}
+
+		logs, err := s.client.FilterLogs(ctx, goethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{safeAddr},
+			Topics:    [][]common.Hash{safe.ExecutionTopics()},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to read the executions of Safe %s: %w", proposal.Safe, err)
+		}
+
+		for i := range logs {
+			if executed, _ := safe.Execution([]*types.Log{&logs[i]}, safeAddr, hash); executed {
+				return logs[i].TxHash.Hex(), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Confirm checks on chain that the transaction executed the proposal,
+// returning whether the execution succeeded
+func (s *Safe) Confirm(ctx context.Context, proposal *entity.SafeProposal, txHash string) (repository.SafeProposalStatus, error) {
+	receipt, err := s.client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if err != nil {
+		return "", fmt.Errorf("receipt of %s: %w", txHash, err)
+	}
+
+	executed, success := safe.Execution(receipt.Logs, common.HexToAddress(proposal.Safe), common.HexToHash(proposal.SafeTxHash))
+	switch {
+	case !executed:
+		return "", fmt.Errorf("transaction %s did not execute Safe transaction %s", txHash, proposal.SafeTxHash)
+	case !success:
+		return repository.FailedSafeProposal, nil
+	}
+	return repository.ExecutedSafeProposal, nil
+}
+
+// publish posts the proposal to the transaction service, or writes it to a
+// file for the owners to import
+func (s *Safe) publish(ctx context.Context, proposal *safe.Proposal) error {
+	if s.opts.API != nil {
+		if err := s.opts.API.Propose(ctx, proposal); err != nil {
+			return err
+		}
+		log.Printf("proposed Safe transaction %s nonce %d", proposal.ContractTransactionHash, proposal.Nonce)
+		return nil
+	}
+
+	data, err := json.MarshalIndent(proposal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := s.exportPath(proposal.Nonce, proposal.ContractTransactionHash)
+	if err := os.WriteFile(name, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	log.Printf("exported Safe transaction %s nonce %d to %s", proposal.ContractTransactionHash, proposal.Nonce, name)
+	return nil
+}
+
+// exportMissing writes an exported proposal again if its file is missing,
+// because writing it failed
+func (s *Safe) exportMissing(ctx context.Context, proposal *entity.SafeProposal) error {
+	if proposal.Payload == "" {
+		return nil
+	}
+
+	_, err := os.Stat(s.exportPath(proposal.Nonce, proposal.SafeTxHash))
+	if errors.Is(err, os.ErrNotExist) {
+		return s.Publish(ctx, proposal)
+	}
+	return err
+}
+
+// exportPath returns the file a proposal is exported to
+func (s *Safe) exportPath(nonce uint64, safeTxHash string) string {
+	return filepath.Join(s.opts.ExportDir, fmt.Sprintf("safe-tx-%d-%s.json", nonce, safeTxHash))
+}
+
+// nonce returns the nonce of the next transaction of the Safe
+func (s *Safe) nonce(ctx context.Context) (uint64, error) {
+	data, err := s.client.CallContract(ctx, goethereum.CallMsg{To: &s.opts.Safe, Data: safe.NonceCall()}, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 32 {
+		return 0, fmt.Errorf("unexpected nonce of Safe %s: %x", s.opts.Safe.Hex(), data)
+	}
+	return new(big.Int).SetBytes(data).Uint64(), nil
+}
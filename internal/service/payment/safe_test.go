@@ -0,0 +1,245 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/safe"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+var testSafe = common.HexToAddress("0x00000000000000000000000000000000000005af")
+
+// newTestSafe returns a Safe at nonce 5 owned by the test key
+func newTestSafe(t *testing.T, client *ethereum.MockClient, opts SafeOptions) *Safe {
+	t.Helper()
+
+	client.CallContractFn = func(ctx context.Context, call goethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		require.Equal(t, testSafe, *call.To)
+		return common.LeftPadBytes(big.NewInt(5).Bytes(), 32), nil
+	}
+
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+
+	opts.Safe = testSafe
+	s, err := NewSafe(client, signer.NewKeySigner(key), opts)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSafe_Propose(t *testing.T) {
+	var posted safe.Proposal
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/safes/"+testSafe.Hex()+"/multisig-transactions/", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := &ethereum.MockClient{
+		HeaderByNumberFn: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: big.NewInt(90)}, nil
+		},
+	}
+	s := newTestSafe(t, client, SafeOptions{API: safe.NewAPI(server.URL, time.Second)})
+
+	payments := []*entity.Payment{
+		{ID: 1, Addr: "0x00000000000000000000000000000000000000aa", Amount: 100},
+		{ID: 2, Addr: "0x00000000000000000000000000000000000000bb", Amount: 200},
+	}
+
+	proposal, err := s.Propose(context.Background(), payments, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(90), proposal.Block)
+	// nothing is posted before the proposal is recorded
+	assert.Empty(t, posted.ContractTransactionHash)
+	require.NoError(t, s.Publish(context.Background(), proposal))
+	assert.Equal(t, uint64(5), proposal.Nonce)
+	assert.Equal(t, testSafe.Hex(), proposal.Safe)
+	assert.Equal(t, proposal.SafeTxHash, posted.ContractTransactionHash)
+
+	// the posted transaction hashes to what the owner signed
+	data, err := hexutil.Decode(posted.Data)
+	require.NoError(t, err)
+	tx := safe.MultiSend(common.HexToAddress(safe.DefaultMultiSendAddress), []safe.Call{
+		{To: common.HexToAddress(USDCContractAddress), Data: transferData(t, s, payments[0])},
+		{To: common.HexToAddress(USDCContractAddress), Data: transferData(t, s, payments[1])},
+	}, 5)
+	assert.Equal(t, tx.Data, data)
+
+	hash := tx.Hash(big.NewInt(1), testSafe)
+	assert.Equal(t, hash.Hex(), proposal.SafeTxHash)
+
+	sig, err := hexutil.Decode(posted.Signature)
+	require.NoError(t, err)
+	owner, err := safe.Owner(hash, sig)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(posted.Sender), owner)
+
+	// pending proposals push the nonce past the Safe's
+	proposal, err = s.Propose(context.Background(), payments, 6)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), proposal.Nonce)
+}
+
+func TestSafe_ProposeExport(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestSafe(t, &ethereum.MockClient{}, SafeOptions{ExportDir: dir})
+
+	proposal, err := s.Propose(context.Background(), []*entity.Payment{
+		{ID: 1, Addr: "0x00000000000000000000000000000000000000aa", Amount: 100},
+	}, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Publish(context.Background(), proposal))
+
+	name := filepath.Join(dir, "safe-tx-5-"+proposal.SafeTxHash+".json")
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+
+	var exported safe.Proposal
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Equal(t, proposal.SafeTxHash, exported.ContractTransactionHash)
+	assert.Equal(t, testSafe.Hex(), exported.Safe)
+
+	// a proposal whose export was lost is exported again
+	require.NoError(t, os.Remove(name))
+	status, _, err := s.Status(context.Background(), proposal)
+	require.NoError(t, err)
+	assert.Equal(t, repository.PendingSafeProposal, status)
+	_, err = os.Stat(name)
+	assert.NoError(t, err)
+}
+
+func TestSafe_Status(t *testing.T) {
+	proposal := &entity.SafeProposal{
+		ID:         1,
+		Safe:       testSafe.Hex(),
+		SafeTxHash: crypto.Keccak256Hash([]byte("safe tx")).Hex(),
+		Nonce:      5,
+		Block:      100,
+		Payload:    `{"safe": "` + testSafe.Hex() + `"}`,
+	}
+	txHash := "0x00000000000000000000000000000000000000000000000000000000000000e1"
+	executedHash := common.HexToHash(proposal.SafeTxHash)
+	executionLog := types.Log{
+		Address: testSafe,
+		Topics:  []common.Hash{crypto.Keccak256Hash([]byte("ExecutionSuccess(bytes32,uint256)"))},
+		Data:    append(executedHash.Bytes(), make([]byte, 32)...),
+		TxHash:  common.HexToHash(txHash),
+	}
+
+	var executed, known bool
+	var posts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		assert.Equal(t, "/api/v1/multisig-transactions/"+proposal.SafeTxHash+"/", r.URL.Path)
+		switch {
+		case executed:
+			_, _ = w.Write([]byte(`{"isExecuted": true, "isSuccessful": true, "transactionHash": "` + txHash + `"}`))
+		case known:
+			_, _ = w.Write([]byte(`{"isExecuted": false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var logs []types.Log
+	var queries []goethereum.FilterQuery
+	client := &ethereum.MockClient{
+		TransactionReceiptFn: func(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+			require.Equal(t, common.HexToHash(txHash), hash)
+			return &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{&executionLog}}, nil
+		},
+		HeaderByNumberFn: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: big.NewInt(7000)}, nil
+		},
+		FilterLogsFn: func(ctx context.Context, q goethereum.FilterQuery) ([]types.Log, error) {
+			queries = append(queries, q)
+			if q.ToBlock.Uint64() == 7000 {
+				return logs, nil
+			}
+			return nil, nil
+		},
+	}
+	s := newTestSafe(t, client, SafeOptions{API: safe.NewAPI(server.URL, time.Second)})
+	ctx := context.Background()
+
+	// a proposal the service does not know is published again
+	status, _, err := s.Status(ctx, proposal)
+	require.NoError(t, err)
+	assert.Equal(t, repository.PendingSafeProposal, status)
+	assert.Equal(t, 1, posts)
+
+	known = true
+	status, _, err = s.Status(ctx, proposal)
+	require.NoError(t, err)
+	assert.Equal(t, repository.PendingSafeProposal, status)
+	assert.Equal(t, 1, posts)
+
+	executed = true
+	status, tx, err := s.Status(ctx, proposal)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ExecutedSafeProposal, status)
+	assert.Equal(t, txHash, tx)
+
+	// the Safe moved past the nonce while the service lags behind: the
+	// execution is found on chain
+	executed = false
+	proposal.Nonce = 4
+	logs = []types.Log{executionLog}
+	status, tx, err = s.Status(ctx, proposal)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ExecutedSafeProposal, status)
+	assert.Equal(t, txHash, tx)
+	require.Len(t, queries, 2)
+	assert.Equal(t, uint64(100), queries[0].FromBlock.Uint64())
+	assert.Equal(t, uint64(5099), queries[0].ToBlock.Uint64())
+	assert.Equal(t, []common.Address{testSafe}, queries[0].Addresses)
+
+	// another transaction took the nonce
+	logs = nil
+	status, _, err = s.Status(ctx, proposal)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ReplacedSafeProposal, status)
+
+	// without the block of the proposal, it is not taken for replaced
+	proposal.Block = 0
+	_, _, err = s.Status(ctx, proposal)
+	assert.Error(t, err)
+
+	// a transaction that did not execute the proposal does not confirm it
+	proposal.SafeTxHash = crypto.Keccak256Hash([]byte("another tx")).Hex()
+	_, err = s.Confirm(ctx, proposal, txHash)
+	assert.Error(t, err)
+}
+
+func transferData(t *testing.T, s *Safe, p *entity.Payment) []byte {
+	t.Helper()
+
+	data, err := s.abi.Pack(MethodErc20Transfer, common.HexToAddress(p.Addr), big.NewInt(p.Amount))
+	require.NoError(t, err)
+	return data
+}
@@ -2,29 +2,314 @@ package payment
 
 import (
 	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log"
 	"math/big"
+	"strings"
+	"time"
 
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"gitlab.midas.dev/back/river/internal/client/ethereum"
-	"gitlab.midas.dev/back/river/internal/types"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/signer"
+	rivertypes "gitlab.midas.dev/back/river/internal/types"
+)
+
+//go:embed abi/erc20.json
+var erc20abi string
+
+const (
+	USDCContractAddress = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	MethodErc20Balance  = "balanceOf"
+	MethodErc20Transfer = "transfer"
+
+	gasLimit = uint64(100_000)
+)
+
+var (
+	// ErrInsufficientFunds is returned when no signer holds enough tokens for a payment
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrTransactionFailed is returned when a transfer was mined but reverted
+	ErrTransactionFailed = errors.New("transaction failed")
+	// ErrTransactionDropped is returned when a transfer will never be mined
+	// because another transaction took its nonce
+	ErrTransactionDropped = errors.New("transaction dropped")
 )
 
 // Service handles payment-related business logic
 type Service struct {
-	client ethereum.Client
-	// Add other fields as needed
+	client  ethereum.Client
+	abi     abi.ABI
+	token   common.Address
+	signers []signer.Signer
+
+	receiptInterval time.Duration
+	receiptRetries  int
 }
 
-// New creates a new payment service
-func New(client ethereum.Client) *Service {
+// New creates a new payment service paying from the signers' accounts
+func New(client ethereum.Client, signers []signer.Signer) (*Service, error) {
+	ab, err := abi.JSON(strings.NewReader(erc20abi))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signers) == 0 {
+		return nil, errors.New("no signers configured")
+	}
+
 	return &Service{
-		client: client,
+		client:          client,
+		abi:             ab,
+		token:           common.HexToAddress(USDCContractAddress),
+		signers:         signers,
+		receiptInterval: 2 * time.Second,
+		receiptRetries:  10,
+	}, nil
+}
+
+// Sign signs a transfer of tokens to the specified address from a signer
+// holding enough of them. The transaction is not sent: it is recorded first,
+// then sent with Broadcast.
+func (s *Service) Sign(ctx context.Context, to rivertypes.Address, valueAmount int64) (*entity.SignedTransfer, error) {
+	amount := big.NewInt(valueAmount)
+
+	from, err := s.selectSigner(ctx, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := s.client.PendingNonceAt(ctx, from.Address())
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("nonce %d\n", nonce)
+
+	gasPrice, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("gas price %d\n", gasPrice.Int64())
+
+	recipient := common.HexToAddress(to.String())
+	data, err := s.abi.Pack(MethodErc20Transfer, recipient, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := types.NewTransaction(nonce, s.token, big.NewInt(0), gasLimit, gasPrice, data)
+
+	chainID, err := s.client.NetworkID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("chain id - %d\n", chainID.Int64())
+
+	signedTx, err := from.SignTx(ctx, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.SignedTransfer{
+		TxHash: signedTx.Hash().Hex(),
+		From:   from.Address().Hex(),
+		Nonce:  nonce,
+		Raw:    hexutil.Encode(raw),
+	}, nil
+}
+
+// Broadcast sends a transaction signed by Sign and returns the mined transfer
+func (s *Service) Broadcast(ctx context.Context, signed *entity.SignedTransfer) (*entity.Transfer, error) {
+	signedTx, err := decodeTransaction(signed)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("send transaction %s to %s\n", signedTx.Hash().String(), signedTx.To().String())
+
+	err = s.client.SendTransaction(ctx, signedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mined(ctx, signed, signedTx)
+}
+
+// Resume completes a transfer whose transaction was signed and recorded but
+// may not have been sent or mined: it returns the transfer if it was mined,
+// and sends the same transaction again otherwise. It never signs another
+// one, so that a payment is never sent twice. ErrTransactionDropped is
+// returned when another transaction took its nonce.
+func (s *Service) Resume(ctx context.Context, signed *entity.SignedTransfer) (*entity.Transfer, error) {
+	signedTx, err := decodeTransaction(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.client.TransactionReceipt(ctx, signedTx.Hash())
+	if err != nil && !errors.Is(err, goethereum.NotFound) {
+		return nil, err
+	}
+	if err == nil && receipt != nil && receipt.BlockNumber != nil {
+		return s.transfer(signed, signedTx, receipt)
+	}
+
+	log.Printf("send transaction %s again\n", signedTx.Hash().String())
+	err = s.client.SendTransaction(ctx, signedTx)
+	if err != nil && !alreadySent(err) {
+		return nil, err
+	}
+
+	transfer, minedErr := s.mined(ctx, signed, signedTx)
+	if minedErr != nil && err != nil && strings.Contains(err.Error(), "nonce too low") {
+		return nil, fmt.Errorf("%w: nonce %d of %s was taken by another transaction than %s",
+			ErrTransactionDropped, signed.Nonce, signed.From, signed.TxHash)
+	}
+	return transfer, minedErr
+}
+
+// mined waits for the receipt of a sent transaction and returns the transfer
+func (s *Service) mined(ctx context.Context, signed *entity.SignedTransfer, signedTx *types.Transaction) (*entity.Transfer, error) {
+	receipt, err := waitReceipt(ctx, s.client, signedTx.Hash(), s.receiptInterval, s.receiptRetries)
+	if err != nil {
+		return nil, err
+	}
+	return s.transfer(signed, signedTx, receipt)
+}
+
+// transfer returns the transfer of a mined transaction, failing if it reverted
+func (s *Service) transfer(signed *entity.SignedTransfer, signedTx *types.Transaction, receipt *types.Receipt) (*entity.Transfer, error) {
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionFailed, signedTx.Hash().String())
+	}
+
+	gasUsed := new(big.Int).SetUint64(receipt.GasUsed)
+	return &entity.Transfer{
+		TxHash:  signedTx.Hash().Hex(),
+		From:    signed.From,
+		Token:   signedTx.To().Hex(),
+		GasUsed: int64(receipt.GasUsed),
+		GasFee:  gasUsed.Mul(gasUsed, signedTx.GasPrice()).Int64(),
+	}, nil
+}
+
+// decodeTransaction decodes the raw transaction of a signed transfer
+func decodeTransaction(signed *entity.SignedTransfer) (*types.Transaction, error) {
+	raw, err := hexutil.Decode(signed.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed transaction %s: %w", signed.TxHash, err)
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("malformed transaction %s: %w", signed.TxHash, err)
+	}
+	if tx.Hash().Hex() != signed.TxHash || tx.To() == nil {
+		return nil, fmt.Errorf("transaction %s was altered", signed.TxHash)
+	}
+	return tx, nil
+}
+
+// alreadySent reports whether a node refused a transaction because it has
+// it, or because its nonce was used, possibly by the transaction itself
+func alreadySent(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction") ||
+		strings.Contains(msg, "nonce too low")
+}
+
+// ReservedAddresses returns the addresses River itself uses, which must never
+// receive payments, described by what they are
+func (s *Service) ReservedAddresses() map[common.Address]string {
+	reserved := map[common.Address]string{s.token: "token contract"}
+	for _, sgn := range s.signers {
+		reserved[sgn.Address()] = "River signer"
 	}
+	return reserved
 }
 
-// Send transfers tokens to the specified address
-func (s *Service) Send(ctx context.Context, to types.Address, valueAmount int64) error {
-	// Implementation will be migrated from the existing payment package
-	return nil
+// selectSigner returns the first signer whose token balance covers the amount
+func (s *Service) selectSigner(ctx context.Context, amount *big.Int) (signer.Signer, error) {
+	for _, sgn := range s.signers {
+		balance, err := s.FetchTokenBalance(ctx, s.token, sgn.Address())
+		if err != nil {
+			log.Printf("fetch balance error - %v", err)
+			continue
+		}
+
+		log.Printf("employee amount - %s, wallet balance - %s\n", amount, balance)
+		if amount.Cmp(balance) < 0 {
+			return sgn, nil
+		}
+		log.Println("balance - insufficient funds")
+	}
+
+	return nil, ErrInsufficientFunds
+}
+
+// waitReceipt polls for the receipt of a sent transaction until it is mined
+// and returns it if the transaction succeeded
+func waitReceipt(ctx context.Context, client ethereum.Client, hash common.Hash, interval time.Duration, retries int) (*types.Receipt, error) {
+	for retry := 0; retry < retries; retry++ {
+		time.Sleep(interval)
+		log.Printf("get receipt hash #%s retry number #%d ", hash.String(), retry)
+
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if errors.Is(err, goethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if receipt != nil && receipt.BlockNumber != nil {
+			log.Printf("receipt status - %d", receipt.Status)
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return nil, fmt.Errorf("%w: %s", ErrTransactionFailed, hash.String())
+			}
+			return receipt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("transaction %s not mined after %d retries", hash.String(), retries)
+}
+
+// FetchTokenBalance returns the token balance of the address
+func (s *Service) FetchTokenBalance(ctx context.Context, tokenAddress common.Address, address common.Address) (*big.Int, error) {
+	return tokenBalance(ctx, s.client, s.abi, tokenAddress, address)
+}
+
+// tokenBalance calls balanceOf of the token for the address
+func tokenBalance(ctx context.Context, client ethereum.Client, ab abi.ABI, tokenAddress common.Address, address common.Address) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	input, err := ab.Pack(MethodErc20Balance, address)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := client.CallContract(ctx, goethereum.CallMsg{To: &tokenAddress, Data: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ab.Unpack(MethodErc20Balance, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
 }
 
 // Balance represents a token balance
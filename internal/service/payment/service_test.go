@@ -1,12 +1,195 @@
 package payment
 
 import (
+	"context"
+	"errors"
+	"math/big"
 	"testing"
 
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/client/ethereum"
+	"gitlab.midas.dev/back/river/internal/signer"
 )
 
+const testKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
 func TestPaymentService_Send(t *testing.T) {
 	// This is a placeholder test. In a real implementation, we would test the actual logic.
 	assert.True(t, true)
 }
+
+// newTestService returns a service whose key holds balance tokens
+func newTestService(t *testing.T, balance int64, client *ethereum.MockClient) *Service {
+	t.Helper()
+
+	client.CallContractFn = func(ctx context.Context, call goethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		return common.LeftPadBytes(big.NewInt(balance).Bytes(), 32), nil
+	}
+	client.TransactionReceiptFn = func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+		return &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(1), GasUsed: 50_000}, nil
+	}
+
+	signers, err := signer.FromHexKeys([]string{testKey})
+	require.NoError(t, err)
+
+	s, err := New(client, signers)
+	require.NoError(t, err)
+	s.receiptInterval = 0
+
+	return s
+}
+
+func TestPaymentService_SendTransfer(t *testing.T) {
+	var sent *types.Transaction
+	client := &ethereum.MockClient{
+		SendTransactionFn: func(ctx context.Context, tx *types.Transaction) error {
+			sent = tx
+			return nil
+		},
+	}
+	s := newTestService(t, 1_000_000, client)
+
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	signed, err := s.Sign(context.Background(), to, 250_000)
+	require.NoError(t, err)
+	assert.Nil(t, sent, "signing sends nothing")
+
+	transfer, err := s.Broadcast(context.Background(), signed)
+	require.NoError(t, err)
+
+	require.NotNil(t, sent)
+	assert.Equal(t, sent.Hash().Hex(), signed.TxHash)
+	assert.Equal(t, sent.Nonce(), signed.Nonce)
+	assert.Equal(t, sent.Hash().Hex(), transfer.TxHash)
+	assert.Equal(t, common.HexToAddress(USDCContractAddress).Hex(), transfer.Token)
+	assert.Equal(t, int64(50_000), transfer.GasUsed)
+	assert.Equal(t, new(big.Int).Mul(big.NewInt(50_000), sent.GasPrice()).Int64(), transfer.GasFee)
+	assert.Equal(t, common.HexToAddress(USDCContractAddress), *sent.To())
+
+	args, err := s.abi.Methods[MethodErc20Transfer].Inputs.Unpack(sent.Data()[4:])
+	require.NoError(t, err)
+	assert.Equal(t, to, args[0])
+	assert.Equal(t, big.NewInt(250_000), args[1])
+
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), sent)
+	require.NoError(t, err)
+	key, _ := crypto.HexToECDSA(testKey)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), from)
+}
+
+func TestPaymentService_SendInsufficientFunds(t *testing.T) {
+	client := &ethereum.MockClient{}
+	s := newTestService(t, 100, client)
+
+	_, err := s.Sign(context.Background(), common.Address{}, 250_000)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestPaymentService_SendReverted(t *testing.T) {
+	client := &ethereum.MockClient{}
+	s := newTestService(t, 1_000_000, client)
+	client.TransactionReceiptFn = func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+		return &types.Receipt{Status: types.ReceiptStatusFailed, BlockNumber: big.NewInt(1)}, nil
+	}
+
+	signed, err := s.Sign(context.Background(), common.Address{}, 250_000)
+	require.NoError(t, err)
+	_, err = s.Broadcast(context.Background(), signed)
+	assert.ErrorIs(t, err, ErrTransactionFailed)
+}
+
+func TestPaymentService_Resume(t *testing.T) {
+	ctx := context.Background()
+	notFound := func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+		return nil, goethereum.NotFound
+	}
+
+	t.Run("mined", func(t *testing.T) {
+		var sends int
+		client := &ethereum.MockClient{
+			SendTransactionFn: func(ctx context.Context, tx *types.Transaction) error {
+				sends++
+				return nil
+			},
+		}
+		s := newTestService(t, 1_000_000, client)
+		signed, err := s.Sign(ctx, common.Address{}, 250_000)
+		require.NoError(t, err)
+
+		transfer, err := s.Resume(ctx, signed)
+		require.NoError(t, err)
+		assert.Equal(t, signed.TxHash, transfer.TxHash)
+		assert.Zero(t, sends, "mined transactions are not sent again")
+	})
+
+	t.Run("sent again", func(t *testing.T) {
+		var sent []*types.Transaction
+		client := &ethereum.MockClient{}
+		s := newTestService(t, 1_000_000, client)
+		signed, err := s.Sign(ctx, common.Address{}, 250_000)
+		require.NoError(t, err)
+
+		client.TransactionReceiptFn = notFound
+		client.SendTransactionFn = func(ctx context.Context, tx *types.Transaction) error {
+			sent = append(sent, tx)
+			client.TransactionReceiptFn = func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+				return &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(2)}, nil
+			}
+			return errors.New("already known")
+		}
+
+		transfer, err := s.Resume(ctx, signed)
+		require.NoError(t, err)
+		require.Len(t, sent, 1)
+		assert.Equal(t, signed.TxHash, sent[0].Hash().Hex(), "the same transaction is sent again")
+		assert.Equal(t, signed.TxHash, transfer.TxHash)
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		client := &ethereum.MockClient{}
+		s := newTestService(t, 1_000_000, client)
+		s.receiptRetries = 2
+		signed, err := s.Sign(ctx, common.Address{}, 250_000)
+		require.NoError(t, err)
+
+		client.TransactionReceiptFn = notFound
+		client.SendTransactionFn = func(ctx context.Context, tx *types.Transaction) error {
+			return errors.New("nonce too low")
+		}
+
+		_, err = s.Resume(ctx, signed)
+		assert.ErrorIs(t, err, ErrTransactionDropped)
+	})
+
+	t.Run("altered", func(t *testing.T) {
+		s := newTestService(t, 1_000_000, &ethereum.MockClient{})
+		signed, err := s.Sign(ctx, common.Address{}, 250_000)
+		require.NoError(t, err)
+
+		signed.TxHash = common.Hash{1}.Hex()
+		_, err = s.Resume(ctx, signed)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewWithoutSigners(t *testing.T) {
+	_, err := New(&ethereum.MockClient{}, nil)
+	assert.Error(t, err)
+}
+
+func TestPaymentService_ReservedAddresses(t *testing.T) {
+	s := newTestService(t, 0, &ethereum.MockClient{})
+
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+
+	reserved := s.ReservedAddresses()
+	assert.Equal(t, "token contract", reserved[common.HexToAddress(USDCContractAddress)])
+	assert.Equal(t, "River signer", reserved[crypto.PubkeyToAddress(key.PublicKey)])
+	assert.Len(t, reserved, 2)
+}
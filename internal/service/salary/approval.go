@@ -0,0 +1,203 @@
+package salary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gitlab.midas.dev/back/river/internal/approval"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// ErrNotDraft is returned when approving a run that is not a draft
+var ErrNotDraft = errors.New("only draft payroll runs can be approved")
+
+// Manifest is a payroll run as its approvers see it
+type Manifest struct {
+	Salary    *entity.Salary
+	Text      string
+	Hash      common.Hash
+	Approvals int
+	Required  int
+}
+
+// Manifest returns the manifest of a payroll run and its valid approvals
+func (s *Service) Manifest(ctx context.Context, salaryID int64) (*Manifest, error) {
+	salary, err := s.salaryRepository.GetSalary(ctx, salaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.salaryRepository.ListPaymentsBySalaryID(ctx, salaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.manifest(ctx, salary, payments)
+}
+
+// Approve records an approver's signature of the run's manifest hash and
+// approves the run once enough approvers signed it
+func (s *Service) Approve(ctx context.Context, salaryID int64, signature []byte) (*Manifest, error) {
+	if !s.approval.Enabled() {
+		return nil, errors.New("approval is not configured, set APPROVERS")
+	}
+
+	m, err := s.Manifest(ctx, salaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Salary.Status != string(repository.DraftStatus) {
+		return nil, fmt.Errorf("%w: salary %d is %s", ErrNotDraft, salaryID, m.Salary.Status)
+	}
+
+	approver, err := s.approval.Approver(m.Hash, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.salaryRepository.AddApproval(ctx, &entity.Approval{
+		SalaryID:     salaryID,
+		Approver:     approver.Hex(),
+		ManifestHash: m.Hash.Hex(),
+		Signature:    hexutil.Encode(signature),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m, err = s.Manifest(ctx, salaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Approvals >= m.Required {
+		if err := s.salaryRepository.UpdateStatusToApproved(ctx, salaryID); err != nil {
+			return nil, err
+		}
+		m.Salary.Status = string(repository.ApprovedStatus)
+	}
+
+	return m, nil
+}
+
+// payApproved creates a draft for the period unless one exists, pays the
+// approved runs and reports the drafts still waiting for approval
+func (s *Service) payApproved(ctx context.Context, params repository.CreateSalaryParams) error {
+	drafts, err := s.salaryRepository.ListByStatus(ctx, repository.DraftStatus)
+	if err != nil {
+		return err
+	}
+
+	approved, err := s.salaryRepository.ListByStatus(ctx, repository.ApprovedStatus)
+	if err != nil {
+		return err
+	}
+
+	if !hasPeriod(drafts, params.Period) && !hasPeriod(approved, params.Period) {
+		params.Draft = true
+		err = s.salaryRepository.Create(ctx, params)
+		if err != nil && !(errors.Is(err, repository.ErrPeriodAlreadyPaid) && len(approved) > 0) {
+			return err
+		}
+
+		if err == nil {
+			drafts, err = s.salaryRepository.ListByStatus(ctx, repository.DraftStatus)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(approved) > 0 {
+		if err := s.pay(ctx, approved); err != nil {
+			return err
+		}
+	}
+
+	if len(drafts) > 0 {
+		return s.awaitingApproval(ctx, drafts)
+	}
+
+	return nil
+}
+
+// awaitingApproval returns ErrNotApproved describing the drafts
+func (s *Service) awaitingApproval(ctx context.Context, drafts []*entity.Salary) error {
+	descriptions := make([]string, 0, len(drafts))
+	for _, salary := range drafts {
+		m, err := s.Manifest(ctx, salary.ID)
+		if err != nil {
+			return err
+		}
+		descriptions = append(descriptions, fmt.Sprintf("salary %d (%s) has %d of %d approvals, manifest hash %s",
+			salary.ID, describePeriod(salary), m.Approvals, m.Required, m.Hash.Hex()))
+	}
+
+	return fmt.Errorf("%w: %s", approval.ErrNotApproved, strings.Join(descriptions, "; "))
+}
+
+// verifyApproved checks the approvals of a run against its current manifest
+// and returns it to draft if they no longer suffice, e.g. because a payment
+// was added after approval
+func (s *Service) verifyApproved(ctx context.Context, salary *entity.Salary, payments []*entity.Payment) error {
+	m, err := s.manifest(ctx, salary, payments)
+	if err != nil {
+		return err
+	}
+
+	if m.Approvals >= m.Required {
+		return nil
+	}
+
+	log.Printf("salary %d changed since it was approved, returning it to draft", salary.ID)
+	if err := s.salaryRepository.UpdateStatusToDraft(ctx, salary.ID); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: salary %d has %d of %d approvals for manifest hash %s",
+		approval.ErrNotApproved, salary.ID, m.Approvals, m.Required, m.Hash.Hex())
+}
+
+func (s *Service) manifest(ctx context.Context, salary *entity.Salary, payments []*entity.Payment) (*Manifest, error) {
+	text := approval.Manifest(salary, payments)
+	hash := approval.Hash(text)
+
+	approvals, err := s.salaryRepository.ListApprovals(ctx, salary.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manifest{
+		Salary:    salary,
+		Text:      text,
+		Hash:      hash,
+		Approvals: s.approval.Count(hash, approvals),
+		Required:  s.approval.Required,
+	}, nil
+}
+
+// hasPeriod reports whether one of the salaries pays the period
+func hasPeriod(salaries []*entity.Salary, period entity.Period) bool {
+	label := payroll.Label(period)
+	for _, salary := range salaries {
+		if salary.Period == label {
+			return true
+		}
+	}
+	return false
+}
+
+func describePeriod(salary *entity.Salary) string {
+	if salary.Period == "" {
+		return salary.Schedule
+	}
+	return salary.Period
+}
@@ -0,0 +1,102 @@
+package salary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/approval"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+func TestSalaryService_PayRequiresApproval(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	period := payroll.MonthPeriod(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	params := repository.CreateSalaryParams{Period: period, Schedule: "monthly"}
+	draft := &entity.Salary{ID: 1, Status: string(repository.DraftStatus), Period: "2026-10"}
+
+	repo.On("ListByStatus", mock.Anything, repository.DraftStatus).Return([]*entity.Salary{}, nil).Once()
+	repo.On("ListByStatus", mock.Anything, repository.DraftStatus).Return([]*entity.Salary{draft}, nil)
+	repo.On("ListByStatus", mock.Anything, repository.ApprovedStatus).Return([]*entity.Salary{}, nil)
+	repo.On("Create", mock.Anything, repository.CreateSalaryParams{Period: period, Schedule: "monthly", Draft: true}).
+		Return(nil).Once()
+	repo.On("GetSalary", mock.Anything, int64(1)).Return(draft, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{}, nil)
+	repo.On("ListApprovals", mock.Anything, int64(1)).Return([]*entity.Approval{}, nil)
+
+	policy := approval.Policy{Approvers: []common.Address{{1}, {2}}, Required: 2}
+	s := New(repo, new(MockPaymentService), Options{Approval: policy})
+
+	// the first pay creates a draft, the next one does not create another
+	assert.ErrorIs(t, s.Pay(context.Background(), params), approval.ErrNotApproved)
+	assert.ErrorIs(t, s.Pay(context.Background(), params), approval.ErrNotApproved)
+
+	repo.AssertNumberOfCalls(t, "Create", 1)
+	repo.AssertNotCalled(t, "ClaimSalary", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSalaryService_Approve(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	salary := &entity.Salary{ID: 1, Status: string(repository.DraftStatus), Period: "2026-10", Schedule: "monthly"}
+	payments := []*entity.Payment{{ID: 10, EmployeeID: 1, Addr: "0x00000000000000000000000000000000000000aa", Amount: 1000}}
+	hash := approval.Hash(approval.Manifest(salary, payments))
+
+	keys := []string{
+		"b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291",
+		"8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a",
+	}
+	var policy approval.Policy
+	var signatures [][]byte
+	for _, hexKey := range keys {
+		key, err := crypto.HexToECDSA(hexKey)
+		require.NoError(t, err)
+		sig, err := crypto.Sign(accounts.TextHash(hash.Bytes()), key)
+		require.NoError(t, err)
+
+		policy.Approvers = append(policy.Approvers, crypto.PubkeyToAddress(key.PublicKey))
+		signatures = append(signatures, sig)
+	}
+	policy.Required = 2
+
+	var approvals []*entity.Approval
+	for i, sig := range signatures {
+		approvals = append(approvals, &entity.Approval{
+			SalaryID:     1,
+			Approver:     policy.Approvers[i].Hex(),
+			ManifestHash: hash.Hex(),
+			Signature:    hexutil.Encode(sig),
+		})
+	}
+
+	repo.On("GetSalary", mock.Anything, int64(1)).Return(salary, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return(payments, nil)
+	// each approval reads the approvals before and after adding its own
+	repo.On("ListApprovals", mock.Anything, int64(1)).Return([]*entity.Approval{}, nil).Once()
+	repo.On("ListApprovals", mock.Anything, int64(1)).Return(approvals[:1], nil).Twice()
+	repo.On("ListApprovals", mock.Anything, int64(1)).Return(approvals, nil).Once()
+	repo.On("AddApproval", mock.Anything, approvals[0]).Return(nil).Once()
+	repo.On("AddApproval", mock.Anything, approvals[1]).Return(nil).Once()
+	repo.On("UpdateStatusToApproved", mock.Anything, int64(1)).Return(nil)
+
+	s := New(repo, new(MockPaymentService), Options{Approval: policy})
+
+	m, err := s.Approve(context.Background(), 1, signatures[0])
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.Approvals)
+	repo.AssertNotCalled(t, "UpdateStatusToApproved", mock.Anything, mock.Anything)
+
+	m, err = s.Approve(context.Background(), 1, signatures[1])
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Approvals)
+	assert.Equal(t, hash, m.Hash)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,180 @@
+package salary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/offline"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// ErrOfflineNotConfigured is returned when broadcasting without an offline account
+var ErrOfflineNotConfigured = errors.New("payments are not signed offline, set OFFLINE_ACCOUNT")
+
+// ErrPaymentsFailed is returned by Broadcast when transactions of the batch
+// were mined but reverted
+var ErrPaymentsFailed = errors.New("payments failed")
+
+// OfflineExporter defines the interface for paying through transactions signed offline
+type OfflineExporter interface {
+	// Address returns the offline account paying the salaries
+	Address() common.Address
+	// Export writes the unsigned transactions of the payments, with nonces of at least minNonce
+	Export(ctx context.Context, salaryID int64, payments []*entity.Payment, minNonce uint64) (*offline.Batch, error)
+	// Broadcast sends a signed transaction, returning whether it succeeded once mined
+	Broadcast(ctx context.Context, tx *types.Transaction) (bool, error)
+}
+
+// Broadcast sends the signed transactions of a batch after checking each
+// against its exported payment, so that no recipient, amount, nonce or fee
+// can be changed between export and broadcast. Payments already broadcast
+// are skipped, so that an interrupted broadcast can be run again. The
+// transactions after a failed one are still sent, as its nonce is used up;
+// the failed payments are returned as ErrPaymentsFailed once the batch is
+// broadcast, to be exported again by the next repay.
+func (s *Service) Broadcast(ctx context.Context, batch *offline.Batch) error {
+	if s.offline == nil {
+		return ErrOfflineNotConfigured
+	}
+
+	txs, err := s.checkBatch(ctx, batch)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for i, t := range batch.Transactions {
+		if txs[i] == nil {
+			continue
+		}
+
+		paid, err := s.offline.Broadcast(ctx, txs[i])
+		if err != nil {
+			// later nonces cannot be mined before this one
+			return fmt.Errorf("payment %d: %w", t.PaymentID, err)
+		}
+
+		if !paid {
+			log.Printf("payment %d transaction %s failed", t.PaymentID, txs[i].Hash().Hex())
+			failed = append(failed, fmt.Sprint(t.PaymentID))
+		}
+
+		err = s.salaryRepository.ResolveExport(ctx, t.PaymentID, t.SigningHash.Hex(), txs[i].Hash().Hex(), paid)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s reverted, export them again with repay --export-unsigned",
+			ErrPaymentsFailed, strings.Join(failed, ", "))
+	}
+
+	remaining, err := s.salaryRepository.ListPaymentsBySalaryID(ctx, batch.SalaryID)
+	if err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		return nil
+	}
+	return s.salaryRepository.UpdateStatusToDone(ctx, batch.SalaryID)
+}
+
+// checkBatch returns the signed transactions of the batch once each matches
+// the recipient, amount and signing hash recorded when its payment was
+// exported, or nil for those already paid
+func (s *Service) checkBatch(ctx context.Context, batch *offline.Batch) ([]*types.Transaction, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+	if batch.From != s.offline.Address() {
+		return nil, fmt.Errorf("%w: sent from %s, not the offline account %s",
+			offline.ErrInvalidBatch, batch.From.Hex(), s.offline.Address().Hex())
+	}
+
+	exported, err := s.salaryRepository.ListPaymentsByStatus(ctx, repository.ExportedStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := s.salaryRepository.ListPaymentsByStatus(ctx, repository.DoneStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make(map[int64]*entity.Payment, len(exported))
+	for _, p := range exported {
+		payments[p.ID] = p
+	}
+
+	paid := make(map[int64]*entity.Payment)
+	for _, p := range done {
+		paid[p.ID] = p
+	}
+
+	txs := make([]*types.Transaction, 0, len(batch.Transactions))
+	for _, t := range batch.Transactions {
+		if p, ok := paid[t.PaymentID]; ok && strings.EqualFold(p.SigningHash, t.SigningHash.Hex()) {
+			txs = append(txs, nil)
+			continue
+		}
+
+		p, ok := payments[t.PaymentID]
+		switch {
+		case !ok || p.SalaryID != batch.SalaryID:
+			return nil, fmt.Errorf("%w: %d", repository.ErrExportNotFound, t.PaymentID)
+		case !strings.EqualFold(p.SigningHash, t.SigningHash.Hex()) || p.Nonce != t.Nonce:
+			return nil, fmt.Errorf("%w: %d", repository.ErrExportNotFound, t.PaymentID)
+		case common.HexToAddress(p.Addr) != t.Recipient || p.Amount != t.Amount:
+			return nil, fmt.Errorf("%w: payment %d pays %d to %s, not %d to %s", offline.ErrInvalidBatch,
+				t.PaymentID, p.Amount, p.Addr, t.Amount, t.Recipient.Hex())
+		}
+
+		tx, err := batch.SignedTx(t)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
+
+// export exports the payments of a salary as unsigned transactions
+func (s *Service) export(ctx context.Context, salaryID int64, payments []*entity.Payment) error {
+	pending, err := s.salaryRepository.ListPaymentsByStatus(ctx, repository.ExportedStatus)
+	if err != nil {
+		return err
+	}
+
+	var minNonce uint64
+	for _, p := range pending {
+		if p.Nonce+1 > minNonce {
+			minNonce = p.Nonce + 1
+		}
+	}
+
+	batch, err := s.offline.Export(ctx, salaryID, payments, minNonce)
+	if err != nil {
+		return err
+	}
+
+	exports := make([]repository.PaymentExport, 0, len(batch.Transactions))
+	for _, t := range batch.Transactions {
+		exports = append(exports, repository.PaymentExport{
+			PaymentID:   t.PaymentID,
+			Nonce:       t.Nonce,
+			SigningHash: t.SigningHash.Hex(),
+			Token:       batch.Token.Hex(),
+			From:        batch.From.Hex(),
+		})
+	}
+
+	return s.salaryRepository.ExportPayments(ctx, exports)
+}
@@ -0,0 +1,217 @@
+package salary
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/offline"
+	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/signer"
+)
+
+// MockOfflineExporter is a mock implementation of the OfflineExporter interface
+type MockOfflineExporter struct {
+	mock.Mock
+}
+
+func (m *MockOfflineExporter) Address() common.Address {
+	return m.Called().Get(0).(common.Address)
+}
+
+func (m *MockOfflineExporter) Export(ctx context.Context, salaryID int64, payments []*entity.Payment, minNonce uint64) (*offline.Batch, error) {
+	args := m.Called(ctx, salaryID, payments, minNonce)
+	return args.Get(0).(*offline.Batch), args.Error(1)
+}
+
+func (m *MockOfflineExporter) Broadcast(ctx context.Context, tx *types.Transaction) (bool, error) {
+	args := m.Called(ctx, tx)
+	return args.Bool(0), args.Error(1)
+}
+
+// signedBatch returns a batch paying the payments, signed by a new key
+func signedBatch(t *testing.T, salaryID int64, payments []*entity.Payment, nonce uint64) *offline.Batch {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sgn := signer.NewKeySigner(key)
+
+	batch := &offline.Batch{
+		Version:  offline.Version,
+		SalaryID: salaryID,
+		ChainID:  big.NewInt(1),
+		From:     sgn.Address(),
+		Token:    common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+	}
+	for i, p := range payments {
+		batch.Transactions = append(batch.Transactions,
+			batch.NewTransaction(p.ID, common.HexToAddress(p.Addr), p.Amount, nonce+uint64(i), 100_000, big.NewInt(1)))
+	}
+	require.NoError(t, offline.Sign(context.Background(), batch, sgn))
+	return batch
+}
+
+func TestSalaryService_ExportsOffline(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	exporter := new(MockOfflineExporter)
+	addr := "0x00000000000000000000000000000000000000aa"
+
+	payments := []*entity.Payment{
+		{ID: 10, EmployeeID: 1, Addr: addr, Amount: 100, Status: string(repository.CreatedStatus)},
+		// exported earlier and waiting to be signed
+		{ID: 11, EmployeeID: 2, Addr: addr, Amount: 200, Status: string(repository.ExportedStatus), Nonce: 6},
+	}
+	batch := signedBatch(t, 2, payments[:1], 7)
+
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 2}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(2)).Return(payments, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(2), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(2)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("ListPaymentsByStatus", mock.Anything, repository.ExportedStatus).Return(payments[1:], nil)
+	exporter.On("Export", mock.Anything, int64(2), payments[:1], uint64(7)).Return(batch, nil)
+	repo.On("ExportPayments", mock.Anything, []repository.PaymentExport{
+		{PaymentID: 10, Nonce: 7, SigningHash: batch.Transactions[0].SigningHash.Hex(),
+			Token: batch.Token.Hex(), From: batch.From.Hex()},
+	}).Return(nil)
+
+	s := New(repo, nil, Options{Offline: exporter})
+	require.NoError(t, s.Repay(context.Background()))
+
+	repo.AssertExpectations(t)
+	exporter.AssertExpectations(t)
+	// the salary is done once its transactions are broadcast
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(2))
+}
+
+func TestSalaryService_Broadcast(t *testing.T) {
+	addr := "0x00000000000000000000000000000000000000aa"
+	payments := []*entity.Payment{
+		{ID: 10, SalaryID: 2, Addr: addr, Amount: 100},
+		{ID: 11, SalaryID: 2, Addr: addr, Amount: 200},
+	}
+	batch := signedBatch(t, 2, payments, 7)
+
+	exported := func(amount int64) []*entity.Payment {
+		rows := make([]*entity.Payment, 0, len(payments))
+		for i, p := range payments {
+			row := *p
+			row.Status = string(repository.ExportedStatus)
+			row.Nonce = batch.Transactions[i].Nonce
+			row.SigningHash = batch.Transactions[i].SigningHash.Hex()
+			rows = append(rows, &row)
+		}
+		rows[1].Amount = amount
+		return rows
+	}
+
+	t.Run("resolves the payments", func(t *testing.T) {
+		repo := new(MockSalaryRepository)
+		exporter := new(MockOfflineExporter)
+
+		// the first payment was paid by an interrupted broadcast
+		rows := exported(200)
+		rows[0].Status = string(repository.DoneStatus)
+
+		exporter.On("Address").Return(batch.From)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.ExportedStatus).Return(rows[1:], nil)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.DoneStatus).Return(rows[:1], nil)
+		tx, err := batch.SignedTx(batch.Transactions[1])
+		require.NoError(t, err)
+		exporter.On("Broadcast", mock.Anything, mock.MatchedBy(func(sent *types.Transaction) bool {
+			return sent.Hash() == tx.Hash()
+		})).Return(true, nil)
+		repo.On("ResolveExport", mock.Anything, int64(11), batch.Transactions[1].SigningHash.Hex(), tx.Hash().Hex(), true).Return(nil)
+		repo.On("ListPaymentsBySalaryID", mock.Anything, int64(2)).Return([]*entity.Payment{}, nil)
+		repo.On("UpdateStatusToDone", mock.Anything, int64(2)).Return(nil)
+
+		s := New(repo, nil, Options{Offline: exporter})
+		require.NoError(t, s.Broadcast(context.Background(), batch))
+
+		repo.AssertExpectations(t)
+		exporter.AssertExpectations(t)
+	})
+
+	t.Run("reports failed payments", func(t *testing.T) {
+		repo := new(MockSalaryRepository)
+		exporter := new(MockOfflineExporter)
+
+		first, err := batch.SignedTx(batch.Transactions[0])
+		require.NoError(t, err)
+		second, err := batch.SignedTx(batch.Transactions[1])
+		require.NoError(t, err)
+
+		exporter.On("Address").Return(batch.From)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.ExportedStatus).Return(exported(200), nil)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.DoneStatus).Return([]*entity.Payment{}, nil)
+		exporter.On("Broadcast", mock.Anything, mock.MatchedBy(func(sent *types.Transaction) bool {
+			return sent.Hash() == first.Hash()
+		})).Return(false, nil)
+		exporter.On("Broadcast", mock.Anything, mock.MatchedBy(func(sent *types.Transaction) bool {
+			return sent.Hash() == second.Hash()
+		})).Return(true, nil)
+		repo.On("ResolveExport", mock.Anything, int64(10), batch.Transactions[0].SigningHash.Hex(), first.Hash().Hex(), false).Return(nil)
+		repo.On("ResolveExport", mock.Anything, int64(11), batch.Transactions[1].SigningHash.Hex(), second.Hash().Hex(), true).Return(nil)
+
+		// the later nonce is still sent, and the failure is reported
+		s := New(repo, nil, Options{Offline: exporter})
+		err = s.Broadcast(context.Background(), batch)
+		require.ErrorIs(t, err, ErrPaymentsFailed)
+		assert.Contains(t, err.Error(), "10 reverted")
+
+		repo.AssertExpectations(t)
+		exporter.AssertExpectations(t)
+		repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(2))
+	})
+
+	t.Run("rejects a changed amount", func(t *testing.T) {
+		repo := new(MockSalaryRepository)
+		exporter := new(MockOfflineExporter)
+
+		exporter.On("Address").Return(batch.From)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.ExportedStatus).Return(exported(150), nil)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.DoneStatus).Return([]*entity.Payment{}, nil)
+
+		s := New(repo, nil, Options{Offline: exporter})
+		require.ErrorIs(t, s.Broadcast(context.Background(), batch), offline.ErrInvalidBatch)
+
+		exporter.AssertNotCalled(t, "Broadcast", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects another signing hash", func(t *testing.T) {
+		repo := new(MockSalaryRepository)
+		exporter := new(MockOfflineExporter)
+
+		rows := exported(200)
+		rows[1].SigningHash = common.Hash{1}.Hex()
+
+		exporter.On("Address").Return(batch.From)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.ExportedStatus).Return(rows, nil)
+		repo.On("ListPaymentsByStatus", mock.Anything, repository.DoneStatus).Return([]*entity.Payment{}, nil)
+
+		s := New(repo, nil, Options{Offline: exporter})
+		require.ErrorIs(t, s.Broadcast(context.Background(), batch), repository.ErrExportNotFound)
+
+		exporter.AssertNotCalled(t, "Broadcast", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects another sender", func(t *testing.T) {
+		exporter := new(MockOfflineExporter)
+		exporter.On("Address").Return(common.Address{1})
+
+		s := New(new(MockSalaryRepository), nil, Options{Offline: exporter})
+		require.ErrorIs(t, s.Broadcast(context.Background(), batch), offline.ErrInvalidBatch)
+	})
+
+	t.Run("requires an offline account", func(t *testing.T) {
+		s := New(new(MockSalaryRepository), nil, Options{})
+		require.ErrorIs(t, s.Broadcast(context.Background(), batch), ErrOfflineNotConfigured)
+	})
+}
@@ -0,0 +1,154 @@
+package salary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// ErrSafeNotConfigured is returned by Safe commands when payments are not made from a Safe
+var ErrSafeNotConfigured = errors.New("payments are not made from a Safe, set SAFE_ADDRESS")
+
+// SafeProposer defines the interface for paying through Safe transactions
+type SafeProposer interface {
+	// Propose signs a transaction sending the payments, with a nonce of at least minNonce
+	Propose(ctx context.Context, payments []*entity.Payment, minNonce uint64) (*entity.SafeProposal, error)
+	// Publish hands a signed proposal to the owners of the Safe
+	Publish(ctx context.Context, proposal *entity.SafeProposal) error
+	// Status returns the state of a pending proposal and the transaction that executed it
+	Status(ctx context.Context, proposal *entity.SafeProposal) (repository.SafeProposalStatus, string, error)
+	// Confirm checks that the transaction executed the proposal
+	Confirm(ctx context.Context, proposal *entity.SafeProposal, txHash string) (repository.SafeProposalStatus, error)
+}
+
+// SafeProposals returns the proposals waiting for the owners of the Safe
+func (s *Service) SafeProposals(ctx context.Context) ([]*entity.SafeProposal, error) {
+	return s.salaryRepository.ListSafeProposals(ctx, repository.PendingSafeProposal)
+}
+
+// SyncProposals records the outcome of the pending proposals. The payments of
+// failed or replaced proposals are proposed again by the next repay.
+func (s *Service) SyncProposals(ctx context.Context) error {
+	if s.safe == nil {
+		return ErrSafeNotConfigured
+	}
+
+	proposals, err := s.SafeProposals(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, proposal := range proposals {
+		status, txHash, err := s.safe.Status(ctx, proposal)
+		if err != nil {
+			log.Printf("safe proposal %d status error: %v", proposal.ID, err)
+			continue
+		}
+
+		if status == repository.PendingSafeProposal {
+			continue
+		}
+
+		if err := s.resolveProposal(ctx, proposal, status, txHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfirmProposal records that the transaction executed a pending proposal,
+// for Safes whose transactions are not tracked by a transaction service
+func (s *Service) ConfirmProposal(ctx context.Context, id int64, txHash string) (repository.SafeProposalStatus, error) {
+	if s.safe == nil {
+		return "", ErrSafeNotConfigured
+	}
+
+	proposals, err := s.SafeProposals(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, proposal := range proposals {
+		if proposal.ID != id {
+			continue
+		}
+
+		status, err := s.safe.Confirm(ctx, proposal, txHash)
+		if err != nil {
+			return "", err
+		}
+		return status, s.resolveProposal(ctx, proposal, status, txHash)
+	}
+
+	return "", fmt.Errorf("%w: %d", repository.ErrSafeProposalNotFound, id)
+}
+
+// resolveProposal records the outcome of a proposal and completes its salary
+// once nothing is left to pay
+func (s *Service) resolveProposal(ctx context.Context, proposal *entity.SafeProposal, status repository.SafeProposalStatus, txHash string) error {
+	log.Printf("safe proposal %d %s %s", proposal.ID, status, txHash)
+
+	err := s.salaryRepository.ResolveSafeProposal(ctx, proposal.ID, status, txHash)
+	if err != nil {
+		return err
+	}
+
+	if status != repository.ExecutedSafeProposal {
+		return nil
+	}
+
+	remaining, err := s.salaryRepository.ListPaymentsBySalaryID(ctx, proposal.SalaryID)
+	if err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		return nil
+	}
+	return s.salaryRepository.UpdateStatusToDone(ctx, proposal.SalaryID)
+}
+
+// propose proposes the payments of a salary in a single Safe transaction
+func (s *Service) propose(ctx context.Context, salaryID int64, payments []*entity.Payment) error {
+	pending, err := s.SafeProposals(ctx)
+	if err != nil {
+		return err
+	}
+
+	var minNonce uint64
+	for _, p := range pending {
+		if p.Nonce+1 > minNonce {
+			minNonce = p.Nonce + 1
+		}
+	}
+
+	proposal, err := s.safe.Propose(ctx, payments, minNonce)
+	if err != nil {
+		return err
+	}
+	proposal.SalaryID = salaryID
+
+	ids := make([]int64, 0, len(payments))
+	for _, p := range payments {
+		ids = append(ids, p.ID)
+	}
+
+	// the proposal is recorded before it is published, so that no signed
+	// proposal goes without its payments; one that failed to publish is
+	// published again when the proposals are synced
+	id, err := s.salaryRepository.AddSafeProposal(ctx, proposal, ids)
+	if err != nil {
+		return err
+	}
+	proposal.ID = id
+
+	if err := s.safe.Publish(ctx, proposal); err != nil {
+		return fmt.Errorf("salary %d proposal %d was recorded but not published, the next repay publishes it: %w",
+			salaryID, id, err)
+	}
+	log.Printf("salary %d proposed to Safe %s as proposal %d", salaryID, proposal.Safe, id)
+	return nil
+}
@@ -0,0 +1,125 @@
+package salary
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/repository"
+)
+
+// MockSafeProposer is a mock implementation of the SafeProposer interface
+type MockSafeProposer struct {
+	mock.Mock
+}
+
+func (m *MockSafeProposer) Propose(ctx context.Context, payments []*entity.Payment, minNonce uint64) (*entity.SafeProposal, error) {
+	args := m.Called(ctx, payments, minNonce)
+	return args.Get(0).(*entity.SafeProposal), args.Error(1)
+}
+
+func (m *MockSafeProposer) Publish(ctx context.Context, proposal *entity.SafeProposal) error {
+	args := m.Called(ctx, proposal)
+	return args.Error(0)
+}
+
+func (m *MockSafeProposer) Status(ctx context.Context, proposal *entity.SafeProposal) (repository.SafeProposalStatus, string, error) {
+	args := m.Called(ctx, proposal)
+	return args.Get(0).(repository.SafeProposalStatus), args.String(1), args.Error(2)
+}
+
+func (m *MockSafeProposer) Confirm(ctx context.Context, proposal *entity.SafeProposal, txHash string) (repository.SafeProposalStatus, error) {
+	args := m.Called(ctx, proposal, txHash)
+	return args.Get(0).(repository.SafeProposalStatus), args.Error(1)
+}
+
+func TestSalaryService_ProposesToSafe(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	proposer := new(MockSafeProposer)
+	addr := "0x00000000000000000000000000000000000000aa"
+
+	pending := &entity.SafeProposal{ID: 1, SalaryID: 1, Nonce: 7, SafeTxHash: "0x01"}
+	payments := []*entity.Payment{
+		{ID: 10, EmployeeID: 1, Addr: addr, Amount: 100, Status: string(repository.CreatedStatus)},
+		{ID: 11, EmployeeID: 2, Addr: addr, Amount: 200, Status: string(repository.CreatedStatus)},
+		// proposed earlier and waiting for the owners
+		{ID: 12, EmployeeID: 3, Addr: addr, Amount: 300, Status: string(repository.ProposedStatus)},
+	}
+
+	repo.On("ListSafeProposals", mock.Anything, repository.PendingSafeProposal).Return([]*entity.SafeProposal{pending}, nil)
+	proposer.On("Status", mock.Anything, pending).Return(repository.PendingSafeProposal, "", nil)
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 2}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(2)).Return(payments, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(2), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(2)).Return(&repository.SpendingHistory{}, nil)
+	proposer.On("Propose", mock.Anything, payments[:2], uint64(8)).
+		Return(&entity.SafeProposal{Safe: "0x05af", SafeTxHash: "0x02", Nonce: 8}, nil)
+	repo.On("AddSafeProposal", mock.Anything, &entity.SafeProposal{SalaryID: 2, Safe: "0x05af", SafeTxHash: "0x02", Nonce: 8},
+		[]int64{10, 11}).Return(int64(2), nil)
+	proposer.On("Publish", mock.Anything, &entity.SafeProposal{ID: 2, SalaryID: 2, Safe: "0x05af", SafeTxHash: "0x02", Nonce: 8}).
+		Return(nil)
+
+	s := New(repo, nil, Options{Safe: proposer})
+	require.NoError(t, s.Repay(context.Background()))
+
+	repo.AssertExpectations(t)
+	proposer.AssertExpectations(t)
+	// the salary is done once the owners executed the proposal
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(2))
+}
+
+func TestSalaryService_ProposeRecordsBeforePublishing(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	proposer := new(MockSafeProposer)
+	payments := []*entity.Payment{{ID: 10, EmployeeID: 1, Addr: "0x00000000000000000000000000000000000000aa", Amount: 100}}
+
+	repo.On("ListSafeProposals", mock.Anything, repository.PendingSafeProposal).Return([]*entity.SafeProposal{}, nil)
+	proposer.On("Propose", mock.Anything, payments, uint64(0)).Return(&entity.SafeProposal{SafeTxHash: "0x02"}, nil)
+
+	// a proposal that could not be recorded is not published
+	repo.On("AddSafeProposal", mock.Anything, mock.Anything, []int64{10}).Return(int64(0), errors.New("disk full")).Once()
+	s := New(repo, nil, Options{Safe: proposer})
+	require.Error(t, s.propose(context.Background(), 2, payments))
+	proposer.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+
+	// one that could not be published stays recorded for the next sync
+	repo.On("AddSafeProposal", mock.Anything, mock.Anything, []int64{10}).Return(int64(3), nil).Once()
+	proposer.On("Publish", mock.Anything, mock.Anything).Return(errors.New("service unavailable")).Once()
+	err := s.propose(context.Background(), 2, payments)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "proposal 3 was recorded")
+	repo.AssertNotCalled(t, "ResolveSafeProposal", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSalaryService_SyncProposals(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	proposer := new(MockSafeProposer)
+
+	executed := &entity.SafeProposal{ID: 1, SalaryID: 1, Nonce: 7, SafeTxHash: "0x01"}
+	replaced := &entity.SafeProposal{ID: 2, SalaryID: 2, Nonce: 8, SafeTxHash: "0x02"}
+
+	repo.On("ListSafeProposals", mock.Anything, repository.PendingSafeProposal).
+		Return([]*entity.SafeProposal{executed, replaced}, nil)
+	proposer.On("Status", mock.Anything, executed).Return(repository.ExecutedSafeProposal, "0xe1", nil)
+	proposer.On("Status", mock.Anything, replaced).Return(repository.ReplacedSafeProposal, "", nil)
+	repo.On("ResolveSafeProposal", mock.Anything, int64(1), repository.ExecutedSafeProposal, "0xe1").Return(nil)
+	repo.On("ResolveSafeProposal", mock.Anything, int64(2), repository.ReplacedSafeProposal, "").Return(nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{}, nil)
+	repo.On("UpdateStatusToDone", mock.Anything, int64(1)).Return(nil)
+
+	s := New(repo, nil, Options{Safe: proposer})
+	require.NoError(t, s.SyncProposals(context.Background()))
+
+	repo.AssertExpectations(t)
+	// the payments of the replaced proposal are proposed again by the next repay
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(2))
+}
+
+func TestSalaryService_SyncProposalsWithoutSafe(t *testing.T) {
+	s := New(new(MockSalaryRepository), nil, Options{})
+	require.ErrorIs(t, s.SyncProposals(context.Background()), ErrSafeNotConfigured)
+}
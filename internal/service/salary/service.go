@@ -2,38 +2,105 @@ package salary
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"gitlab.midas.dev/back/river/internal/approval"
 	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
 	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/service/payment"
 	"gitlab.midas.dev/back/river/internal/types"
 )
 
+// paymentWindow is the time over which the payments of a salary are spread
+const paymentWindow = 30 * time.Minute
+
+// ErrInvalidPaymentItem is returned for one-off payments that cannot be paid
+var ErrInvalidPaymentItem = errors.New("invalid payment item")
+
 // Service handles salary-related business logic
 type Service struct {
 	salaryRepository repository.SalaryRepository
 	paymentService   PaymentService
+	paymentWindow    time.Duration
+	limits           payroll.Limits
+	approval         approval.Policy
+	recipients       RecipientChecker
+	safe             SafeProposer
+	offline          OfflineExporter
 }
 
-// PaymentService defines the interface for payment operations
+// Options configures the guardrails of the salary service
+type Options struct {
+	// Limits holds back payments that break a spending limit
+	Limits payroll.Limits
+	// Approval requires runs to be approved before they are paid when enabled
+	Approval approval.Policy
+	// Recipients holds back payments to unsafe addresses when set
+	Recipients RecipientChecker
+	// Safe proposes payments as Safe transactions instead of sending them when set
+	Safe SafeProposer
+	// Offline exports payments as unsigned transactions instead of sending them when set
+	Offline OfflineExporter
+}
+
+// PaymentService defines the interface for payment operations. Transfers are
+// signed, recorded, then broadcast, so that a payment interrupted while sent
+// is resumed with the transaction recorded rather than signed again.
 type PaymentService interface {
-	Send(ctx context.Context, to types.Address, valueAmount int64) error
+	Sign(ctx context.Context, to types.Address, valueAmount int64) (*entity.SignedTransfer, error)
+	Broadcast(ctx context.Context, signed *entity.SignedTransfer) (*entity.Transfer, error)
+	// Resume returns the transfer of a recorded transaction if it was mined,
+	// sending it again otherwise
+	Resume(ctx context.Context, signed *entity.SignedTransfer) (*entity.Transfer, error)
+}
+
+// RecipientChecker defines the interface for recipient address validation
+type RecipientChecker interface {
+	Check(ctx context.Context, payments []*entity.Payment) map[int64]error
 }
 
 // New creates a new salary service
-func New(salaryRepository repository.SalaryRepository, paymentService PaymentService) *Service {
+func New(salaryRepository repository.SalaryRepository, paymentService PaymentService, opts Options) *Service {
 	return &Service{
 		salaryRepository: salaryRepository,
 		paymentService:   paymentService,
+		paymentWindow:    paymentWindow,
+		limits:           opts.Limits,
+		approval:         opts.Approval,
+		recipients:       opts.Recipients,
+		safe:             opts.Safe,
+		offline:          opts.Offline,
 	}
 }
 
-// Repay processes salaries that are in processing status
+// Repay processes salaries that are in processing status, and approved ones
+// when approval is required. Paying from a Safe, it first records the outcome
+// of pending proposals.
 func (s *Service) Repay(ctx context.Context) error {
+	if s.safe != nil {
+		if err := s.SyncProposals(ctx); err != nil {
+			return err
+		}
+	}
+
 	salaries, err := s.salaryRepository.ListByStatus(ctx, repository.ProcessingStatus)
 	if err != nil {
 		return err
 	}
 
+	if s.approval.Enabled() {
+		approved, err := s.salaryRepository.ListByStatus(ctx, repository.ApprovedStatus)
+		if err != nil {
+			return err
+		}
+		salaries = append(salaries, approved...)
+	}
+
 	if len(salaries) > 0 {
 		err = s.pay(ctx, salaries)
 		if err != nil {
@@ -43,9 +110,34 @@ func (s *Service) Repay(ctx context.Context) error {
 	return nil
 }
 
-// Pay creates a new salary and processes it
-func (s *Service) Pay(ctx context.Context) error {
-	err := s.startPay(ctx)
+// Pending returns the number of salaries left in processing status, i.e.
+// those with payments that still need to be repaid, and those waiting for
+// approval when approval is required
+func (s *Service) Pending(ctx context.Context) (int, error) {
+	statuses := []repository.PaymentStatus{repository.ProcessingStatus}
+	if s.approval.Enabled() {
+		statuses = append(statuses, repository.DraftStatus, repository.ApprovedStatus)
+	}
+
+	var pending int
+	for _, status := range statuses {
+		salaries, err := s.salaryRepository.ListByStatus(ctx, status)
+		if err != nil {
+			return 0, err
+		}
+		pending += len(salaries)
+	}
+	return pending, nil
+}
+
+// Pay creates a new salary for the given period and processes it. When
+// approval is required, it creates a draft instead and pays approved runs.
+func (s *Service) Pay(ctx context.Context, params repository.CreateSalaryParams) error {
+	if s.approval.Enabled() {
+		return s.payApproved(ctx, params)
+	}
+
+	err := s.startPay(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -65,17 +157,352 @@ func (s *Service) Pay(ctx context.Context) error {
 	return nil
 }
 
+// AddPayment adds a one-off payment to an unfinished payroll run, or queues
+// it for the next run when item.SalaryID is zero. When approval is required,
+// only drafts take new payments.
+func (s *Service) AddPayment(ctx context.Context, item repository.PaymentItem) (int64, error) {
+	if err := validatePaymentItem(item); err != nil {
+		return 0, err
+	}
+
+	if s.approval.Enabled() && item.SalaryID != 0 {
+		salary, err := s.salaryRepository.GetSalary(ctx, item.SalaryID)
+		if err != nil {
+			return 0, err
+		}
+		if salary.Status != string(repository.DraftStatus) {
+			return 0, fmt.Errorf("%w: salary %d is %s, only drafts take new payments",
+				ErrInvalidPaymentItem, salary.ID, salary.Status)
+		}
+	}
+
+	return s.salaryRepository.AddPayment(ctx, item)
+}
+
+// PayAdHoc pays one-off payments in a payroll run of their own. When approval
+// is required, the run is created as a draft and returned in ErrNotApproved.
+func (s *Service) PayAdHoc(ctx context.Context, items []repository.PaymentItem) error {
+	for _, item := range items {
+		if err := validatePaymentItem(item); err != nil {
+			return err
+		}
+	}
+
+	salaryID, err := s.salaryRepository.CreateAdHoc(ctx, items, s.approval.Enabled())
+	if err != nil {
+		return err
+	}
+
+	if s.approval.Enabled() {
+		salary, err := s.salaryRepository.GetSalary(ctx, salaryID)
+		if err != nil {
+			return err
+		}
+		return s.awaitingApproval(ctx, []*entity.Salary{salary})
+	}
+
+	return s.pay(ctx, []*entity.Salary{{ID: salaryID}})
+}
+
+func validatePaymentItem(item repository.PaymentItem) error {
+	switch item.Category {
+	case repository.BonusCategory, repository.ReimbursementCategory, repository.AdjustmentCategory:
+	default:
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidPaymentItem, item.Category)
+	}
+
+	if item.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidPaymentItem)
+	}
+
+	return nil
+}
+
 // startPay creates a new salary record
-func (s *Service) startPay(ctx context.Context) error {
-	err := s.salaryRepository.Create(ctx)
+func (s *Service) startPay(ctx context.Context, params repository.CreateSalaryParams) error {
+	err := s.salaryRepository.Create(ctx, params)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// pay processes the actual payment for salaries
+// ReviewPayment releases a payment held back for review so that the next
+// repay sends it, or rejects it so that it is never sent
+func (s *Service) ReviewPayment(ctx context.Context, id int64, approve bool) error {
+	return s.salaryRepository.ReviewPayment(ctx, id, approve)
+}
+
+// PaymentsForReview returns the payments held back for review
+func (s *Service) PaymentsForReview(ctx context.Context) ([]*entity.Payment, error) {
+	return s.salaryRepository.ListPaymentsByStatus(ctx, repository.NeedsReviewStatus)
+}
+
+// pay processes the actual payment for salaries. Cancelling ctx stops
+// processing between payments; a payment already being sent is completed.
+// Payments breaking a spending limit or to an unsafe recipient are held for
+// review and keep their salary in processing. Paying from a Safe, the payments
+// are proposed in a single transaction and the salary is done once executed.
+// Signing offline, they are exported and the salary is done once broadcast.
 func (s *Service) pay(ctx context.Context, salaries []*entity.Salary) error {
-	// Implementation will be migrated from the existing salary package
+	log.Println("start pay")
+	log.Printf("%d salaries\n", len(salaries))
+
+	for _, salary := range salaries {
+		log.Printf("salary id - %d\n", salary.ID)
+		payments, err := s.salaryRepository.ListPaymentsBySalaryID(ctx, salary.ID)
+		if err != nil {
+			return err
+		}
+
+		if salary.Status == string(repository.ApprovedStatus) {
+			if err := s.verifyApproved(ctx, salary, payments); err != nil {
+				return err
+			}
+		}
+
+		// another River process paying the same salary claims it first
+		err = s.salaryRepository.ClaimSalary(ctx, salary, repository.ProcessingStatus)
+		if errors.Is(err, repository.ErrStatusConflict) {
+			log.Println(err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		payments, held, err := s.checkPayments(ctx, salary.ID, payments)
+		if err != nil {
+			return err
+		}
+
+		// payments River was sending itself are resumed with their
+		// transaction, whichever way the others are paid
+		if s.safe != nil || s.offline != nil {
+			var resumed int
+			payments, resumed = s.resumeSent(ctx, payments)
+			held += resumed
+		}
+
+		if s.safe != nil && len(payments) > 0 {
+			if err := s.propose(ctx, salary.ID, payments); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if s.offline != nil && len(payments) > 0 {
+			if err := s.export(ctx, salary.ID, payments); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var wait time.Duration
+		if len(payments) > 0 {
+			wait = s.paymentWindow / time.Duration(len(payments))
+		}
+		log.Printf("wait - %s", wait)
+
+		var countErrPayments int
+		for i, paymt := range payments {
+			if i > 0 {
+				if err := sleep(ctx, wait); err != nil {
+					log.Printf("stopped before payment %d of salary %d", paymt.ID, salary.ID)
+					return err
+				}
+			}
+
+			if !s.payOne(withoutCancel(ctx), paymt) {
+				countErrPayments++
+			}
+		}
+
+		if countErrPayments == 0 && held == 0 {
+			err = s.salaryRepository.UpdateStatusToDone(ctx, salary.ID)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
+
+// checkPayments moves the payments breaking a spending limit or to an unsafe
+// recipient to needs_review and returns the ones to send along with the
+// number held back, already proposed to the Safe or exported
+func (s *Service) checkPayments(ctx context.Context, salaryID int64, payments []*entity.Payment) ([]*entity.Payment, int, error) {
+	history, err := s.salaryRepository.SpendingHistory(ctx, salaryID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	violations := s.limits.Check(payments, history)
+	if s.recipients != nil {
+		// recipient problems take precedence, approving a limit does not fix them
+		for id, violation := range s.recipients.Check(ctx, payments) {
+			violations[id] = violation
+		}
+	}
+
+	send := make([]*entity.Payment, 0, len(payments))
+	var held int
+	for _, paymt := range payments {
+		switch repository.PaymentStatus(paymt.Status) {
+		case repository.NeedsReviewStatus, repository.ProposedStatus, repository.ExportedStatus:
+			held++
+			continue
+		}
+
+		if violation, ok := violations[paymt.ID]; ok {
+			log.Printf("payment %d held for review: %v", paymt.ID, violation)
+			err = s.salaryRepository.UpdatePaymentStatusToNeedsReview(ctx, paymt.ID, violation.Error())
+			if err != nil {
+				return nil, 0, err
+			}
+			held++
+			continue
+		}
+
+		send = append(send, paymt)
+	}
+
+	return send, held, nil
+}
+
+// payOne claims a single payment, sends it and records its status,
+// reporting success. A payment claimed by another run is left to it. The
+// transaction is signed first and recorded with the claim, so that a payment
+// whose transaction is not mined in time stays in processing with it and is
+// resumed by the next repay, never sent with another transaction.
+func (s *Service) payOne(ctx context.Context, paymt *entity.Payment) bool {
+	if paymt.Status == string(repository.ProcessingStatus) {
+		return s.resumeOne(ctx, paymt)
+	}
+
+	if !common.IsHexAddress(paymt.Addr) {
+		log.Printf("in not hex address - %s", paymt.Addr)
+		return false
+	}
+
+	signed, err := s.paymentService.Sign(ctx, common.HexToAddress(paymt.Addr), paymt.Amount)
+	if err != nil {
+		log.Printf("payment error: %v", err)
+		return false
+	}
+
+	err = s.salaryRepository.ClaimPaymentSend(ctx, paymt, signed)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	transfer, err := s.paymentService.Broadcast(ctx, signed)
+	return s.complete(ctx, paymt, transfer, err)
+}
+
+// resumeSent resumes the payments River was sending itself and returns the
+// others, along with the number of those resumed that are not done yet
+func (s *Service) resumeSent(ctx context.Context, payments []*entity.Payment) ([]*entity.Payment, int) {
+	unsent := make([]*entity.Payment, 0, len(payments))
+	var pending int
+	for _, paymt := range payments {
+		if paymt.Status != string(repository.ProcessingStatus) {
+			unsent = append(unsent, paymt)
+			continue
+		}
+		if !s.resumeOne(withoutCancel(ctx), paymt) {
+			pending++
+		}
+	}
+	return unsent, pending
+}
+
+// resumeOne completes a payment interrupted while sent with the transaction
+// recorded when it was claimed. Without one, River cannot tell whether it
+// was sent, so it is held for review rather than sent again.
+func (s *Service) resumeOne(ctx context.Context, paymt *entity.Payment) bool {
+	if paymt.SignedTx == "" {
+		log.Printf("payment %d held for review: interrupted without a recorded transaction", paymt.ID)
+		err := s.salaryRepository.UpdatePaymentStatusToNeedsReview(ctx, paymt.ID,
+			"interrupted while sent without a recorded transaction, check the chain before releasing it")
+		if err != nil {
+			log.Println(err)
+		}
+		return false
+	}
+
+	err := s.salaryRepository.ClaimPayment(ctx, paymt, repository.ProcessingStatus)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	transfer, err := s.paymentService.Resume(ctx, &entity.SignedTransfer{
+		TxHash: paymt.TxHash,
+		From:   paymt.Payer,
+		Nonce:  paymt.Nonce,
+		Raw:    paymt.SignedTx,
+	})
+	return s.complete(ctx, paymt, transfer, err)
+}
+
+// complete records the outcome of sending a payment. Payments whose
+// transaction reverted or was dropped are held for review; those not mined
+// yet stay in processing to be resumed.
+func (s *Service) complete(ctx context.Context, paymt *entity.Payment, transfer *entity.Transfer, err error) bool {
+	if errors.Is(err, payment.ErrTransactionFailed) || errors.Is(err, payment.ErrTransactionDropped) {
+		log.Printf("payment %d held for review: %v", paymt.ID, err)
+		err = s.salaryRepository.UpdatePaymentStatusToNeedsReview(ctx, paymt.ID, err.Error())
+		if err != nil {
+			log.Println(err)
+		}
+		return false
+	}
+	if err != nil {
+		log.Printf("payment error: %v", err)
+		return false
+	}
+
+	err = s.salaryRepository.CompletePayment(ctx, paymt, transfer)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return true
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// withoutCancel returns a context carrying the values of ctx that is never
+// cancelled, so that an interrupted run does not abandon a sent transaction
+func withoutCancel(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
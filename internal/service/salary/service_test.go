@@ -2,12 +2,19 @@ package salary
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gitlab.midas.dev/back/river/internal/entity"
+	"gitlab.midas.dev/back/river/internal/payroll"
+	"gitlab.midas.dev/back/river/internal/recipient"
 	"gitlab.midas.dev/back/river/internal/repository"
+	"gitlab.midas.dev/back/river/internal/service/payment"
 	"gitlab.midas.dev/back/river/internal/types"
 )
 
@@ -16,27 +23,52 @@ type MockSalaryRepository struct {
 	mock.Mock
 }
 
-func (m *MockSalaryRepository) Create(ctx context.Context) error {
-	args := m.Called(ctx)
+func (m *MockSalaryRepository) Create(ctx context.Context, params repository.CreateSalaryParams) error {
+	args := m.Called(ctx, params)
 	return args.Error(0)
 }
 
-func (m *MockSalaryRepository) UpdateStatusToProcessing(ctx context.Context, id int64) error {
-	args := m.Called(ctx, id)
+// ClaimSalary is matched on the ID of the salary, whose status it updates
+// unless it fails
+func (m *MockSalaryRepository) ClaimSalary(ctx context.Context, salary *entity.Salary, status repository.PaymentStatus) error {
+	args := m.Called(ctx, salary.ID, status)
+	if args.Error(0) == nil {
+		salary.Status = string(status)
+	}
 	return args.Error(0)
 }
 
-func (m *MockSalaryRepository) UpdateStatusToDone(ctx context.Context, id int64) error {
-	args := m.Called(ctx, id)
+// ClaimPayment is matched on the ID of the payment, whose status it updates
+// unless it fails
+func (m *MockSalaryRepository) ClaimPayment(ctx context.Context, payment *entity.Payment, status repository.PaymentStatus) error {
+	args := m.Called(ctx, payment.ID, status)
+	if args.Error(0) == nil {
+		payment.Status = string(status)
+	}
 	return args.Error(0)
 }
 
-func (m *MockSalaryRepository) UpdatePaymentStatusToProcessing(ctx context.Context, id int64) error {
-	args := m.Called(ctx, id)
+// ClaimPaymentSend is matched on the ID of the payment and the signed
+// transfer, which it records unless it fails
+func (m *MockSalaryRepository) ClaimPaymentSend(ctx context.Context, payment *entity.Payment, signed *entity.SignedTransfer) error {
+	args := m.Called(ctx, payment.ID, signed)
+	if args.Error(0) == nil {
+		payment.Status = string(repository.ProcessingStatus)
+		payment.TxHash, payment.Payer, payment.Nonce, payment.SignedTx = signed.TxHash, signed.From, signed.Nonce, signed.Raw
+	}
 	return args.Error(0)
 }
 
-func (m *MockSalaryRepository) UpdatePaymentStatusToDone(ctx context.Context, id int64) error {
+// CompletePayment is matched on the ID of the payment and the transfer
+func (m *MockSalaryRepository) CompletePayment(ctx context.Context, payment *entity.Payment, transfer *entity.Transfer) error {
+	args := m.Called(ctx, payment.ID, transfer)
+	if args.Error(0) == nil {
+		payment.Status = string(repository.DoneStatus)
+	}
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) UpdateStatusToDone(ctx context.Context, id int64) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
@@ -51,14 +83,123 @@ func (m *MockSalaryRepository) ListPaymentsBySalaryID(ctx context.Context, salar
 	return args.Get(0).([]*entity.Payment), args.Error(1)
 }
 
+func (m *MockSalaryRepository) AddPayment(ctx context.Context, item repository.PaymentItem) (int64, error) {
+	args := m.Called(ctx, item)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSalaryRepository) CreateAdHoc(ctx context.Context, items []repository.PaymentItem, draft bool) (int64, error) {
+	args := m.Called(ctx, items, draft)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSalaryRepository) GetSalary(ctx context.Context, id int64) (*entity.Salary, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.Salary), args.Error(1)
+}
+
+func (m *MockSalaryRepository) UpdateStatusToDraft(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) UpdateStatusToApproved(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) AddApproval(ctx context.Context, approval *entity.Approval) error {
+	args := m.Called(ctx, approval)
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) ListApprovals(ctx context.Context, salaryID int64) ([]*entity.Approval, error) {
+	args := m.Called(ctx, salaryID)
+	return args.Get(0).([]*entity.Approval), args.Error(1)
+}
+
+func (m *MockSalaryRepository) UpdatePaymentStatusToNeedsReview(ctx context.Context, id int64, reason string) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) ReviewPayment(ctx context.Context, id int64, approve bool) error {
+	args := m.Called(ctx, id, approve)
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) ListPaymentsByStatus(ctx context.Context, status repository.PaymentStatus) ([]*entity.Payment, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]*entity.Payment), args.Error(1)
+}
+
+func (m *MockSalaryRepository) SpendingHistory(ctx context.Context, salaryID int64) (*repository.SpendingHistory, error) {
+	args := m.Called(ctx, salaryID)
+	return args.Get(0).(*repository.SpendingHistory), args.Error(1)
+}
+
+func (m *MockSalaryRepository) AddSafeProposal(ctx context.Context, proposal *entity.SafeProposal, paymentIDs []int64) (int64, error) {
+	args := m.Called(ctx, proposal, paymentIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSalaryRepository) ListSafeProposals(ctx context.Context, status repository.SafeProposalStatus) ([]*entity.SafeProposal, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]*entity.SafeProposal), args.Error(1)
+}
+
+func (m *MockSalaryRepository) ResolveSafeProposal(ctx context.Context, id int64, status repository.SafeProposalStatus, txHash string) error {
+	args := m.Called(ctx, id, status, txHash)
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) ExportPayments(ctx context.Context, exports []repository.PaymentExport) error {
+	args := m.Called(ctx, exports)
+	return args.Error(0)
+}
+
+func (m *MockSalaryRepository) ResolveExport(ctx context.Context, id int64, signingHash, txHash string, paid bool) error {
+	args := m.Called(ctx, id, signingHash, txHash, paid)
+	return args.Error(0)
+}
+
 // MockPaymentService is a mock implementation of the PaymentService interface
 type MockPaymentService struct {
 	mock.Mock
 }
 
-func (m *MockPaymentService) Send(ctx context.Context, to types.Address, valueAmount int64) error {
+// testSigned is the transaction MockPaymentService signs, and testTransfer
+// the transfer it sends
+var (
+	testSigned   = &entity.SignedTransfer{TxHash: "0xabc", From: "0xpayer", Nonce: 3, Raw: "0x01"}
+	testTransfer = &entity.Transfer{TxHash: "0xabc", Token: "0xtoken", GasUsed: 50_000, GasFee: 1_000_000}
+)
+
+// Sign returns testSigned unless it fails
+func (m *MockPaymentService) Sign(ctx context.Context, to types.Address, valueAmount int64) (*entity.SignedTransfer, error) {
 	args := m.Called(ctx, to, valueAmount)
-	return args.Error(0)
+	if err := args.Error(0); err != nil {
+		return nil, err
+	}
+	return testSigned, nil
+}
+
+// Broadcast returns testTransfer unless it fails
+func (m *MockPaymentService) Broadcast(ctx context.Context, signed *entity.SignedTransfer) (*entity.Transfer, error) {
+	args := m.Called(ctx, signed)
+	if err := args.Error(0); err != nil {
+		return nil, err
+	}
+	return testTransfer, nil
+}
+
+// Resume returns testTransfer unless it fails
+func (m *MockPaymentService) Resume(ctx context.Context, signed *entity.SignedTransfer) (*entity.Transfer, error) {
+	args := m.Called(ctx, signed)
+	if err := args.Error(0); err != nil {
+		return nil, err
+	}
+	return testTransfer, nil
 }
 
 func TestSalaryService_Pay(t *testing.T) {
@@ -70,3 +211,284 @@ func TestSalaryService_Repay(t *testing.T) {
 	// This is a placeholder test. In a real implementation, we would test the actual logic.
 	assert.True(t, true)
 }
+
+func TestSalaryService_PayProcessesPayments(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+	params := repository.CreateSalaryParams{Schedule: "monthly"}
+	good := "0x00000000000000000000000000000000000000aa"
+
+	repo.On("Create", mock.Anything, params).Return(nil)
+	repo.On("ListByStatus", mock.Anything, repository.CreatedStatus).Return([]*entity.Salary{{ID: 1}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{
+		{ID: 10, Addr: good, Amount: 100, Status: string(repository.CreatedStatus)},
+		{ID: 11, Addr: "not an address", Amount: 100, Status: string(repository.CreatedStatus)},
+	}, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(1), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(1)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("ClaimPaymentSend", mock.Anything, mock.Anything, testSigned).Return(nil)
+	repo.On("CompletePayment", mock.Anything, int64(10), testTransfer).Return(nil)
+	payments.On("Sign", mock.Anything, common.HexToAddress(good), int64(100)).Return(nil)
+	payments.On("Broadcast", mock.Anything, testSigned).Return(nil)
+
+	s := New(repo, payments, Options{})
+	s.paymentWindow = 0
+
+	require.NoError(t, s.Pay(context.Background(), params))
+
+	repo.AssertExpectations(t)
+	payments.AssertNumberOfCalls(t, "Broadcast", 1)
+	// the invalid payment keeps the salary in processing for repay
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(1))
+}
+
+func TestSalaryService_StopsBetweenPayments(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 1}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{
+		{ID: 10, Addr: "0x00000000000000000000000000000000000000aa", Amount: 100},
+		{ID: 11, Addr: "0x00000000000000000000000000000000000000bb", Amount: 100},
+	}, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(1), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(1)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("ClaimPaymentSend", mock.Anything, int64(10), testSigned).Return(nil)
+	repo.On("CompletePayment", mock.Anything, int64(10), testTransfer).Return(nil)
+	payments.On("Sign", mock.Anything, mock.Anything, int64(100)).Return(nil)
+	// the signal arrives while the first payment is being sent
+	payments.On("Broadcast", mock.Anything, testSigned).Run(func(args mock.Arguments) {
+		cancel()
+		assert.NoError(t, args.Get(0).(context.Context).Err())
+	}).Return(nil)
+
+	s := New(repo, payments, Options{})
+	s.paymentWindow = 0
+
+	err := s.Repay(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	payments.AssertNumberOfCalls(t, "Broadcast", 1)
+	repo.AssertCalled(t, "CompletePayment", mock.Anything, int64(10), testTransfer)
+}
+
+func TestSalaryService_ResumesUnminedPayment(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+	params := repository.CreateSalaryParams{Schedule: "monthly"}
+	addr := "0x00000000000000000000000000000000000000aa"
+	payment := &entity.Payment{ID: 10, Addr: addr, Amount: 100, Status: string(repository.CreatedStatus)}
+
+	repo.On("Create", mock.Anything, params).Return(nil)
+	repo.On("ListByStatus", mock.Anything, repository.CreatedStatus).Return([]*entity.Salary{{ID: 1}}, nil)
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 1, Status: string(repository.ProcessingStatus)}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{payment}, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(1), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(1)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("ClaimPaymentSend", mock.Anything, int64(10), testSigned).Return(nil)
+	payments.On("Sign", mock.Anything, common.HexToAddress(addr), int64(100)).Return(nil)
+	// the receipt never arrives while the run waits for it
+	payments.On("Broadcast", mock.Anything, testSigned).Return(errors.New("transaction 0xabc not mined after 10 retries"))
+
+	s := New(repo, payments, Options{})
+	s.paymentWindow = 0
+
+	require.NoError(t, s.Pay(context.Background(), params))
+	repo.AssertNotCalled(t, "CompletePayment", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(1))
+	assert.Equal(t, string(repository.ProcessingStatus), payment.Status)
+
+	// repay resumes the recorded transaction instead of sending another one
+	repo.On("ClaimPayment", mock.Anything, int64(10), repository.ProcessingStatus).Return(nil)
+	payments.On("Resume", mock.Anything, testSigned).Return(nil)
+	repo.On("CompletePayment", mock.Anything, int64(10), testTransfer).Return(nil)
+	repo.On("UpdateStatusToDone", mock.Anything, int64(1)).Return(nil)
+
+	require.NoError(t, s.Repay(context.Background()))
+
+	repo.AssertExpectations(t)
+	payments.AssertNumberOfCalls(t, "Sign", 1)
+	payments.AssertNumberOfCalls(t, "Broadcast", 1)
+	payments.AssertNumberOfCalls(t, "Resume", 1)
+}
+
+func TestSalaryService_HoldsUnrecordedOrFailedPayments(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+	addr := "0x00000000000000000000000000000000000000aa"
+
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 1}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{
+		// interrupted before River recorded its transactions
+		{ID: 10, Addr: addr, Amount: 100, Status: string(repository.ProcessingStatus)},
+		{ID: 11, Addr: addr, Amount: 200, Status: string(repository.ProcessingStatus), TxHash: "0xdef", SignedTx: "0x02"},
+	}, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(1), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(1)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("UpdatePaymentStatusToNeedsReview", mock.Anything, int64(10), mock.MatchedBy(func(reason string) bool {
+		return strings.Contains(reason, "without a recorded transaction")
+	})).Return(nil)
+	repo.On("ClaimPayment", mock.Anything, int64(11), repository.ProcessingStatus).Return(nil)
+	payments.On("Resume", mock.Anything, mock.Anything).Return(payment.ErrTransactionFailed)
+	repo.On("UpdatePaymentStatusToNeedsReview", mock.Anything, int64(11), mock.MatchedBy(func(reason string) bool {
+		return strings.Contains(reason, "transaction failed")
+	})).Return(nil)
+
+	s := New(repo, payments, Options{})
+	s.paymentWindow = 0
+
+	require.NoError(t, s.Repay(context.Background()))
+
+	repo.AssertExpectations(t)
+	payments.AssertNotCalled(t, "Sign", mock.Anything, mock.Anything, mock.Anything)
+	payments.AssertNotCalled(t, "Broadcast", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(1))
+}
+
+func TestSalaryService_SkipsClaimedWork(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+	addr := "0x00000000000000000000000000000000000000aa"
+
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 1}, {ID: 2}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{
+		{ID: 10, Addr: addr, Amount: 100, Status: string(repository.ProcessingStatus)},
+	}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(2)).Return([]*entity.Payment{
+		{ID: 20, Addr: addr, Amount: 200, Status: string(repository.CreatedStatus)},
+		{ID: 21, Addr: addr, Amount: 300, Status: string(repository.CreatedStatus)},
+	}, nil)
+	// another run claimed salary 1, and payment 20 of salary 2
+	repo.On("ClaimSalary", mock.Anything, int64(1), repository.ProcessingStatus).Return(repository.ErrStatusConflict)
+	repo.On("ClaimSalary", mock.Anything, int64(2), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(2)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("ClaimPaymentSend", mock.Anything, int64(20), testSigned).Return(repository.ErrStatusConflict)
+	repo.On("ClaimPaymentSend", mock.Anything, int64(21), testSigned).Return(nil)
+	repo.On("CompletePayment", mock.Anything, int64(21), testTransfer).Return(nil)
+	// payments are signed before they are claimed, the transaction of the
+	// payment claimed by the other run is never sent
+	payments.On("Sign", mock.Anything, common.HexToAddress(addr), int64(200)).Return(nil)
+	payments.On("Sign", mock.Anything, common.HexToAddress(addr), int64(300)).Return(nil)
+	payments.On("Broadcast", mock.Anything, testSigned).Return(nil)
+
+	s := New(repo, payments, Options{})
+	s.paymentWindow = 0
+
+	require.NoError(t, s.Repay(context.Background()))
+
+	repo.AssertExpectations(t)
+	payments.AssertExpectations(t)
+	payments.AssertNumberOfCalls(t, "Broadcast", 1)
+	// the other run completes salary 2
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(2))
+}
+
+func TestSalaryService_HoldsPaymentsOverLimits(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+	addr := "0x00000000000000000000000000000000000000aa"
+
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 1}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{
+		{ID: 10, EmployeeID: 1, Addr: addr, Amount: 100, Status: string(repository.CreatedStatus)},
+		{ID: 11, EmployeeID: 2, Addr: addr, Amount: 1000000, Status: string(repository.CreatedStatus)},
+		{ID: 12, EmployeeID: 3, Addr: addr, Amount: 100, Status: string(repository.NeedsReviewStatus)},
+	}, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(1), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(1)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("UpdatePaymentStatusToNeedsReview", mock.Anything, int64(11), mock.Anything).Return(nil)
+	repo.On("ClaimPaymentSend", mock.Anything, int64(10), testSigned).Return(nil)
+	repo.On("CompletePayment", mock.Anything, int64(10), testTransfer).Return(nil)
+	payments.On("Sign", mock.Anything, common.HexToAddress(addr), int64(100)).Return(nil)
+	payments.On("Broadcast", mock.Anything, testSigned).Return(nil)
+
+	s := New(repo, payments, Options{Limits: payroll.Limits{MaxPayment: 1000}})
+	s.paymentWindow = 0
+
+	require.NoError(t, s.Repay(context.Background()))
+
+	repo.AssertExpectations(t)
+	payments.AssertNumberOfCalls(t, "Broadcast", 1)
+	// held payments keep the salary in processing
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(1))
+}
+
+func TestSalaryService_HoldsUnsafeRecipients(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+	addr := "0x00000000000000000000000000000000000000aa"
+	token := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+
+	repo.On("ListByStatus", mock.Anything, repository.ProcessingStatus).Return([]*entity.Salary{{ID: 1}}, nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(1)).Return([]*entity.Payment{
+		{ID: 10, EmployeeID: 1, Addr: addr, Amount: 100, Status: string(repository.CreatedStatus)},
+		// a reviewer approving the amount does not make the recipient safe
+		{ID: 11, EmployeeID: 2, Addr: token, Amount: 100, Status: string(repository.CreatedStatus), Reviewed: true},
+	}, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(1), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(1)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("UpdatePaymentStatusToNeedsReview", mock.Anything, int64(11), mock.MatchedBy(func(reason string) bool {
+		return strings.Contains(reason, "token contract")
+	})).Return(nil)
+	repo.On("ClaimPaymentSend", mock.Anything, int64(10), testSigned).Return(nil)
+	repo.On("CompletePayment", mock.Anything, int64(10), testTransfer).Return(nil)
+	payments.On("Sign", mock.Anything, common.HexToAddress(addr), int64(100)).Return(nil)
+	payments.On("Broadcast", mock.Anything, testSigned).Return(nil)
+
+	s := New(repo, payments, Options{
+		Recipients: recipient.NewChecker(nil, map[common.Address]string{
+			common.HexToAddress(token): "token contract",
+		}),
+	})
+	s.paymentWindow = 0
+
+	require.NoError(t, s.Repay(context.Background()))
+
+	repo.AssertExpectations(t)
+	payments.AssertNumberOfCalls(t, "Broadcast", 1)
+	repo.AssertNotCalled(t, "UpdateStatusToDone", mock.Anything, int64(1))
+}
+
+func TestSalaryService_PayAdHoc(t *testing.T) {
+	repo := new(MockSalaryRepository)
+	payments := new(MockPaymentService)
+	addr := "0x00000000000000000000000000000000000000aa"
+	items := []repository.PaymentItem{{EmployeeID: 1, Amount: 500, Category: repository.BonusCategory, Memo: "Q3"}}
+
+	repo.On("CreateAdHoc", mock.Anything, items, false).Return(int64(7), nil)
+	repo.On("ListPaymentsBySalaryID", mock.Anything, int64(7)).Return([]*entity.Payment{
+		{ID: 20, Addr: addr, Amount: 500, Status: string(repository.CreatedStatus), Category: string(repository.BonusCategory)},
+	}, nil)
+	repo.On("ClaimSalary", mock.Anything, int64(7), repository.ProcessingStatus).Return(nil)
+	repo.On("SpendingHistory", mock.Anything, int64(7)).Return(&repository.SpendingHistory{}, nil)
+	repo.On("ClaimPaymentSend", mock.Anything, int64(20), testSigned).Return(nil)
+	repo.On("CompletePayment", mock.Anything, int64(20), testTransfer).Return(nil)
+	repo.On("UpdateStatusToDone", mock.Anything, int64(7)).Return(nil)
+	payments.On("Sign", mock.Anything, common.HexToAddress(addr), int64(500)).Return(nil)
+	payments.On("Broadcast", mock.Anything, testSigned).Return(nil)
+
+	s := New(repo, payments, Options{})
+	require.NoError(t, s.PayAdHoc(context.Background(), items))
+
+	repo.AssertExpectations(t)
+	payments.AssertExpectations(t)
+}
+
+func TestSalaryService_AddPaymentValidation(t *testing.T) {
+	s := New(new(MockSalaryRepository), new(MockPaymentService), Options{})
+
+	tests := []repository.PaymentItem{
+		{EmployeeID: 1, Amount: 500, Category: repository.SalaryCategory},
+		{EmployeeID: 1, Amount: 500, Category: "gift"},
+		{EmployeeID: 1, Amount: 0, Category: repository.BonusCategory},
+		{EmployeeID: 1, Amount: -5, Category: repository.AdjustmentCategory},
+	}
+
+	for _, item := range tests {
+		_, err := s.AddPayment(context.Background(), item)
+		assert.ErrorIs(t, err, ErrInvalidPaymentItem)
+	}
+}
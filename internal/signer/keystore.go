@@ -0,0 +1,132 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KeystoreSigner signs with an account of an encrypted Web3 Secret Storage
+// keystore, as written by geth and other go-ethereum based tools
+type KeystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+// Keystore is a directory of encrypted key files
+type Keystore struct {
+	ks *keystore.KeyStore
+}
+
+// OpenKeystore opens the keystore directory using the standard scrypt parameters
+func OpenKeystore(dir string) *Keystore {
+	return openKeystore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+// openKeystore opens the directory; the scrypt parameters only apply to newly written keys
+func openKeystore(dir string, scryptN, scryptP int) *Keystore {
+	return &Keystore{ks: keystore.NewKeyStore(dir, scryptN, scryptP)}
+}
+
+// Accounts returns the accounts stored in the keystore
+func (k *Keystore) Accounts() []accounts.Account {
+	return k.ks.Accounts()
+}
+
+// NewAccount generates a key and stores it encrypted with the passphrase
+func (k *Keystore) NewAccount(passphrase string) (accounts.Account, error) {
+	if passphrase == "" {
+		return accounts.Account{}, errors.New("empty passphrase")
+	}
+	return k.ks.NewAccount(passphrase)
+}
+
+// Import stores the hex encoded private key encrypted with the passphrase
+func (k *Keystore) Import(hexKey string, passphrase string) (accounts.Account, error) {
+	if passphrase == "" {
+		return accounts.Account{}, errors.New("empty passphrase")
+	}
+
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		// never include the key itself in the error
+		return accounts.Account{}, errors.New("invalid private key")
+	}
+	return k.ks.ImportECDSA(key, passphrase)
+}
+
+// Signers unlocks the given accounts, or every account of the keystore when
+// none are given, with the passphrase
+func (k *Keystore) Signers(addresses []string, passphrase string) ([]Signer, error) {
+	var accs []accounts.Account
+	if len(addresses) == 0 {
+		accs = k.ks.Accounts()
+	}
+
+	for _, addr := range addresses {
+		addr = strings.TrimSpace(addr)
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid keystore account %q", addr)
+		}
+
+		acc, err := k.ks.Find(accounts.Account{Address: common.HexToAddress(addr)})
+		if err != nil {
+			return nil, fmt.Errorf("keystore account %s: %w", addr, err)
+		}
+		accs = append(accs, acc)
+	}
+
+	if len(accs) == 0 {
+		return nil, errors.New("no accounts in keystore")
+	}
+
+	signers := make([]Signer, 0, len(accs))
+	for _, acc := range accs {
+		if err := k.ks.Unlock(acc, passphrase); err != nil {
+			return nil, fmt.Errorf("unlock %s: %w", acc.Address.Hex(), err)
+		}
+		signers = append(signers, &KeystoreSigner{ks: k.ks, account: acc})
+	}
+
+	return signers, nil
+}
+
+// Address returns the keystore account
+func (s *KeystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+// SignTx signs the transaction with the unlocked keystore account
+func (s *KeystoreSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.ks.SignTx(s.account, tx, chainID)
+}
+
+// SignText signs the message with the unlocked keystore account
+func (s *KeystoreSigner) SignText(_ context.Context, text []byte) ([]byte, error) {
+	sig, err := s.ks.SignHash(s.account, accounts.TextHash(text))
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// ReadPassphraseFile returns the first line of a passphrase file
+func ReadPassphraseFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	passphrase, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimRight(passphrase, "\r"), nil
+}
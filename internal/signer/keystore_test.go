@@ -0,0 +1,76 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeystore(t *testing.T) *Keystore {
+	t.Helper()
+	return openKeystore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+}
+
+func TestKeystore_ImportAndSign(t *testing.T) {
+	ks := newTestKeystore(t)
+
+	acc, err := ks.Import(testKey, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(testAddress), acc.Address)
+
+	_, err = ks.Import("0xnot-a-key", "secret")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "not-a-key")
+
+	_, err = ks.Signers(nil, "wrong")
+	assert.Error(t, err)
+
+	signers, err := ks.Signers([]string{testAddress}, "secret")
+	require.NoError(t, err)
+	require.Len(t, signers, 1)
+
+	chainID := big.NewInt(1)
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	signed, err := signers[0].SignTx(context.Background(), tx, chainID)
+	require.NoError(t, err)
+
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(testAddress), from)
+}
+
+func TestKeystore_NewAccount(t *testing.T) {
+	ks := newTestKeystore(t)
+
+	_, err := ks.NewAccount("")
+	assert.Error(t, err)
+
+	acc, err := ks.NewAccount("secret")
+	require.NoError(t, err)
+	assert.Len(t, ks.Accounts(), 1)
+
+	signers, err := ks.Signers(nil, "secret")
+	require.NoError(t, err)
+	require.Len(t, signers, 1)
+	assert.Equal(t, acc.Address, signers[0].Address())
+
+	_, err = ks.Signers([]string{testAddress}, "secret")
+	assert.Error(t, err)
+}
+
+func TestReadPassphraseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pass")
+	require.NoError(t, os.WriteFile(path, []byte("secret\r\nignored\n"), 0600))
+
+	passphrase, err := ReadPassphraseFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", passphrase)
+}
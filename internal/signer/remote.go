@@ -0,0 +1,236 @@
+package signer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Remote signer JSON-RPC flavours
+const (
+	// ClefAPI uses account_signTransaction as served by Clef
+	ClefAPI = "clef"
+	// Web3SignerAPI uses eth_signTransaction as served by Web3Signer
+	Web3SignerAPI = "web3signer"
+)
+
+// ErrRemoteSignature is returned when a remote signer returns a transaction
+// that differs from the one it was asked to sign
+var ErrRemoteSignature = errors.New("remote signer returned an unexpected transaction")
+
+// RemoteOptions configures the connection to a remote signer
+type RemoteOptions struct {
+	// API is ClefAPI or Web3SignerAPI
+	API string
+	// Timeout bounds each signing request; Clef waits for manual approval
+	Timeout time.Duration
+	// TLS configures HTTPS connections, e.g. with a private CA or a client certificate
+	TLS *tls.Config
+}
+
+// RemoteSigner signs by calling an external signer over JSON-RPC, so the
+// key never enters River's process
+type RemoteSigner struct {
+	client  *rpc.Client
+	api     string
+	timeout time.Duration
+	account common.Address
+}
+
+// sendTxArgs are the transaction arguments of both signing APIs
+type sendTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big     `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 *hexutil.Bytes  `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId,omitempty"`
+}
+
+// clefSignResult is the result of account_signTransaction
+type clefSignResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// NewRemote returns a signer per account served by the remote signer at url
+func NewRemote(url string, accounts []string, opts RemoteOptions) ([]Signer, error) {
+	api := strings.ToLower(strings.TrimSpace(opts.API))
+	if api == "" {
+		api = ClefAPI
+	}
+	if api != ClefAPI && api != Web3SignerAPI {
+		return nil, fmt.Errorf("unknown remote signer API %q", opts.API)
+	}
+
+	if len(accounts) == 0 {
+		return nil, errors.New("no remote signer accounts configured")
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: opts.TLS, Proxy: http.ProxyFromEnvironment},
+	}
+
+	client, err := rpc.DialHTTPWithClient(url, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("dial remote signer: %w", err)
+	}
+
+	signers := make([]Signer, 0, len(accounts))
+	for _, acc := range accounts {
+		acc = strings.TrimSpace(acc)
+		if !common.IsHexAddress(acc) {
+			return nil, fmt.Errorf("invalid remote signer account %q", acc)
+		}
+
+		signers = append(signers, &RemoteSigner{
+			client:  client,
+			api:     api,
+			timeout: timeout,
+			account: common.HexToAddress(acc),
+		})
+	}
+
+	return signers, nil
+}
+
+// TLSConfig returns a client TLS configuration trusting caFile in addition to
+// the system roots and presenting the certificate pair if one is given
+func TLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// Address returns the remote account
+func (s *RemoteSigner) Address() common.Address {
+	return s.account
+}
+
+// SignTx asks the remote signer to sign the transaction and checks that the
+// returned transaction is the requested one, signed by the account
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	args := s.txArgs(tx, chainID)
+
+	var raw hexutil.Bytes
+	switch s.api {
+	case Web3SignerAPI:
+		// Web3Signer is configured with its chain id
+		args.ChainID = nil
+		if err := s.client.CallContext(ctx, &raw, "eth_signTransaction", args); err != nil {
+			return nil, fmt.Errorf("remote signer: %w", err)
+		}
+	default:
+		var res clefSignResult
+		if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+			return nil, fmt.Errorf("remote signer: %w", err)
+		}
+		raw = res.Raw
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer: decode transaction: %w", err)
+	}
+
+	if err := s.verify(tx, signed, chainID); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
+
+func (s *RemoteSigner) txArgs(tx *types.Transaction, chainID *big.Int) sendTxArgs {
+	data := hexutil.Bytes(tx.Data())
+	args := sendTxArgs{
+		From:    s.account,
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+
+	args.To = tx.To()
+
+	if tx.Type() == types.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	return args
+}
+
+// verify makes sure the remote signer did not alter the transaction
+func (s *RemoteSigner) verify(want, got *types.Transaction, chainID *big.Int) error {
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), got)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRemoteSignature, err)
+	}
+
+	switch {
+	case from != s.account:
+		return fmt.Errorf("%w: signed by %s", ErrRemoteSignature, from.Hex())
+	case got.Nonce() != want.Nonce(),
+		got.Gas() != want.Gas(),
+		got.GasPrice().Cmp(want.GasPrice()) != 0,
+		got.GasTipCap().Cmp(want.GasTipCap()) != 0,
+		got.Value().Cmp(want.Value()) != 0,
+		string(got.Data()) != string(want.Data()),
+		(got.To() == nil) != (want.To() == nil),
+		got.To() != nil && *got.To() != *want.To():
+		return ErrRemoteSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standInSigner serves account_signTransaction and eth_signTransaction for a single key
+type standInSigner struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+	// tamper alters the transaction before signing
+	tamper func(*types.LegacyTx)
+	delay  time.Duration
+}
+
+func (s *standInSigner) sign(ctx context.Context, args sendTxArgs) (hexutil.Bytes, error) {
+	if args.From != crypto.PubkeyToAddress(s.key.PublicKey) {
+		return nil, errors.New("unknown account")
+	}
+
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	legacy := &types.LegacyTx{
+		Nonce:    uint64(args.Nonce),
+		GasPrice: args.GasPrice.ToInt(),
+		Gas:      uint64(args.Gas),
+		Value:    args.Value.ToInt(),
+	}
+	legacy.To = args.To
+	if args.Data != nil {
+		legacy.Data = *args.Data
+	}
+	if s.tamper != nil {
+		s.tamper(legacy)
+	}
+
+	signed, err := types.SignNewTx(s.key, types.LatestSignerForChainID(s.chainID), legacy)
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+type clefService struct{ *standInSigner }
+
+func (s clefService) SignTransaction(ctx context.Context, args sendTxArgs) (*clefSignResult, error) {
+	raw, err := s.sign(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return &clefSignResult{Raw: raw}, nil
+}
+
+type web3SignerService struct{ *standInSigner }
+
+func (s web3SignerService) SignTransaction(ctx context.Context, args sendTxArgs) (hexutil.Bytes, error) {
+	return s.sign(ctx, args)
+}
+
+func newStandInServer(t *testing.T, s *standInSigner) (string, *tls.Config) {
+	t.Helper()
+
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName("account", clefService{s}))
+	require.NoError(t, srv.RegisterName("eth", web3SignerService{s}))
+
+	httpSrv := httptest.NewUnstartedServer(srv)
+	// rejected handshakes are expected in the certificate test
+	httpSrv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	httpSrv.StartTLS()
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Stop()
+	})
+
+	pool := x509.NewCertPool()
+	pool.AddCert(httpSrv.Certificate())
+
+	return httpSrv.URL, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+}
+
+func TestRemoteSigner_SignTx(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+
+	chainID := big.NewInt(5)
+	url, tlsConfig := newStandInServer(t, &standInSigner{key: key, chainID: chainID})
+
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tx := types.NewTransaction(7, to, big.NewInt(0), 100000, big.NewInt(3), []byte{0xa9, 0x05, 0x9c, 0xbb})
+
+	for _, api := range []string{ClefAPI, Web3SignerAPI} {
+		t.Run(api, func(t *testing.T) {
+			signers, err := NewRemote(url, []string{testAddress}, RemoteOptions{API: api, Timeout: 5 * time.Second, TLS: tlsConfig})
+			require.NoError(t, err)
+			require.Len(t, signers, 1)
+			assert.Equal(t, common.HexToAddress(testAddress), signers[0].Address())
+
+			signed, err := signers[0].SignTx(context.Background(), tx, chainID)
+			require.NoError(t, err)
+			assert.Equal(t, tx.Nonce(), signed.Nonce())
+			assert.Equal(t, tx.Data(), signed.Data())
+
+			from, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+			require.NoError(t, err)
+			assert.Equal(t, common.HexToAddress(testAddress), from)
+		})
+	}
+}
+
+func TestRemoteSigner_RejectsTamperedTx(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+
+	chainID := big.NewInt(5)
+	attacker := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	url, tlsConfig := newStandInServer(t, &standInSigner{
+		key:     key,
+		chainID: chainID,
+		tamper:  func(tx *types.LegacyTx) { tx.To = &attacker },
+	})
+
+	signers, err := NewRemote(url, []string{testAddress}, RemoteOptions{TLS: tlsConfig})
+	require.NoError(t, err)
+
+	tx := types.NewTransaction(1, common.HexToAddress("0xaa"), big.NewInt(0), 21000, big.NewInt(1), nil)
+	_, err = signers[0].SignTx(context.Background(), tx, chainID)
+	assert.ErrorIs(t, err, ErrRemoteSignature)
+}
+
+func TestRemoteSigner_Timeout(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+
+	chainID := big.NewInt(5)
+	url, tlsConfig := newStandInServer(t, &standInSigner{key: key, chainID: chainID, delay: time.Second})
+
+	signers, err := NewRemote(url, []string{testAddress}, RemoteOptions{Timeout: 50 * time.Millisecond, TLS: tlsConfig})
+	require.NoError(t, err)
+
+	tx := types.NewTransaction(1, common.HexToAddress("0xaa"), big.NewInt(0), 21000, big.NewInt(1), nil)
+	_, err = signers[0].SignTx(context.Background(), tx, chainID)
+	assert.Error(t, err)
+}
+
+func TestRemoteSigner_UntrustedCertificate(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+
+	chainID := big.NewInt(5)
+	url, _ := newStandInServer(t, &standInSigner{key: key, chainID: chainID})
+
+	signers, err := NewRemote(url, []string{testAddress}, RemoteOptions{})
+	require.NoError(t, err)
+
+	tx := types.NewTransaction(1, common.HexToAddress("0xaa"), big.NewInt(0), 21000, big.NewInt(1), nil)
+	_, err = signers[0].SignTx(context.Background(), tx, chainID)
+	assert.Error(t, err)
+}
+
+func TestNewRemote_Validation(t *testing.T) {
+	_, err := NewRemote("http://localhost:8550", nil, RemoteOptions{})
+	assert.Error(t, err)
+
+	_, err = NewRemote("http://localhost:8550", []string{"0xnope"}, RemoteOptions{})
+	assert.Error(t, err)
+
+	_, err = NewRemote("http://localhost:8550", []string{testAddress}, RemoteOptions{API: "vault"})
+	assert.Error(t, err)
+}
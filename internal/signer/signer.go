@@ -0,0 +1,104 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions on behalf of a single account
+type Signer interface {
+	// Address returns the account the signer signs for
+	Address() common.Address
+
+	// SignTx returns the transaction signed for the given chain
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// MessageSigner signs messages with EIP-191 personal_sign
+type MessageSigner interface {
+	Signer
+
+	// SignText returns the 65 byte signature of the message, with V of 27 or 28
+	SignText(ctx context.Context, text []byte) ([]byte, error)
+}
+
+// KeySigner signs with a private key held in memory
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates a signer for the private key
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// FromHexKeys creates in-memory signers from hex encoded private keys
+func FromHexKeys(keys []string) ([]Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no private keys configured")
+	}
+
+	signers := make([]Signer, 0, len(keys))
+	for i, key := range keys {
+		pk, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(key), "0x"))
+		if err != nil {
+			// never include the key itself in the error
+			return nil, fmt.Errorf("invalid private key #%d: %w", i+1, err)
+		}
+
+		signers = append(signers, NewKeySigner(pk))
+	}
+
+	return signers, nil
+}
+
+// Address returns the account of the key
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs the transaction with the key
+func (s *KeySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// SignText signs the message with the key
+func (s *KeySigner) SignText(_ context.Context, text []byte) ([]byte, error) {
+	sig, err := crypto.Sign(accounts.TextHash(text), s.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// RecoverText returns the account that signed the message with EIP-191
+// personal_sign. V may be 0, 1, 27 or 28.
+func RecoverText(text []byte, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("signature must be %d bytes", crypto.SignatureLength)
+	}
+
+	sig := make([]byte, len(signature))
+	copy(sig, signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(accounts.TextHash(text), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pub), nil
+}
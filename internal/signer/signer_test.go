@@ -0,0 +1,65 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey     = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+	testAddress = "0x71562b71999873DB5b286dF957af199Ec94617F7"
+)
+
+func TestFromHexKeys(t *testing.T) {
+	signers, err := FromHexKeys([]string{testKey, " 0x" + testKey})
+	require.NoError(t, err)
+	require.Len(t, signers, 2)
+	assert.Equal(t, common.HexToAddress(testAddress), signers[0].Address())
+	assert.Equal(t, signers[0].Address(), signers[1].Address())
+
+	_, err = FromHexKeys([]string{"not a key"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "not a key")
+
+	_, err = FromHexKeys(nil)
+	assert.Error(t, err)
+}
+
+func TestKeySigner_SignTx(t *testing.T) {
+	signers, err := FromHexKeys([]string{testKey})
+	require.NoError(t, err)
+
+	chainID := big.NewInt(5)
+	tx := types.NewTransaction(1, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+
+	signed, err := signers[0].SignTx(context.Background(), tx, chainID)
+	require.NoError(t, err)
+
+	from, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+	require.NoError(t, err)
+	assert.Equal(t, signers[0].Address(), from)
+}
+
+func TestKeySigner_SignText(t *testing.T) {
+	signers, err := FromHexKeys([]string{testKey})
+	require.NoError(t, err)
+
+	text := []byte("manifest hash")
+	sig, err := signers[0].(MessageSigner).SignText(context.Background(), text)
+	require.NoError(t, err)
+	require.Len(t, sig, crypto.SignatureLength)
+	assert.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset])
+
+	sig[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(accounts.TextHash(text), sig)
+	require.NoError(t, err)
+	assert.Equal(t, signers[0].Address(), crypto.PubkeyToAddress(*pub))
+}